@@ -18,6 +18,7 @@ import (
 	token "github.com/apolsh/yapr-gophkeeper/internal/backend/token_manager"
 	"github.com/apolsh/yapr-gophkeeper/internal/config"
 	"github.com/apolsh/yapr-gophkeeper/internal/logger"
+	"github.com/apolsh/yapr-gophkeeper/internal/misc/scheduler"
 )
 
 var (
@@ -68,9 +69,15 @@ func main() {
 	}
 
 	tokenManger := token.NewJWTTokenManager(cfg.TokenSecretKey)
-	gophkeeperService := service.NewGophkeeperService(tokenManger, userStorage, secretStorage)
+	gophkeeperService := service.NewGophkeeperService(tokenManger, userStorage, secretStorage, []byte(cfg.TokenSecretKey))
 	grpcServer := grpc.NewGRPCGophkeeperServer(cfg.ServerAddr, gophkeeperService, tokenManger)
 
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	chunkGC := scheduler.NewScheduler(gophkeeperService.CollectUnreferencedChunks, func(err error) {
+		log.Error(fmt.Errorf("failed to remove unreferenced chunks: %w", err))
+	})
+	chunkGC.RunWithInterval(gcCtx, time.Duration(cfg.ChunkGCPeriod)*time.Second)
+
 	done := make(chan bool)
 	quit := make(chan os.Signal, 1)
 
@@ -87,6 +94,8 @@ func main() {
 		if err != nil {
 			log.Fatal(fmt.Errorf("could not gracefully shutdown the grpc server: %v", err))
 		}
+		stopGC()
+		chunkGC.Close()
 
 		userStorage.Close()
 		secretStorage.Close()
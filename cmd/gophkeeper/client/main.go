@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"time"
 
 	"github.com/apolsh/yapr-gophkeeper/cmd/gophkeeper/tls"
@@ -72,7 +73,9 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ctrl := controller.NewGophkeeperController(&menu, serverClient, localStorage, &encoder.AESGMCEncoder{})
+	ctrl := controller.NewGophkeeperController(&menu, serverClient, localStorage, &encoder.SecretItemEncoder{})
+	ctrl.SetIdleTimeout(time.Duration(cfg.IdleTimeout) * time.Second)
+	ctrl.SetSSHAgentSocketPath(filepath.Join(cfg.BaseDir, "agent.sock"))
 	synchronization := scheduler.NewScheduler(ctrl.SynchronizeSecretItems, menu.ShowError)
 	synchronization.RunWithInterval(ctx, time.Duration(cfg.SyncPeriod)*time.Second)
 
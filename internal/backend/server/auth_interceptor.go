@@ -14,15 +14,22 @@ import (
 
 const UserIDKey = "user_id"
 
-func unaryAuthInterceptor(tokenManager token.TokenManager, authMethods map[string]bool) grpc.UnaryServerInterceptor {
+func unaryAuthInterceptor(tokenManager token.TokenManager, authMethods, restrictedMethods map[string]bool) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (resp interface{}, err error) {
+		if restrictedMethods[info.FullMethod] {
+			if newCtx, err := authorize(tokenManager.ParseRestrictedToken, ctx); err != nil {
+				return nil, err
+			} else {
+				return handler(newCtx, req)
+			}
+		}
 		if authMethods[info.FullMethod] {
-			if newCtx, err := authorize(tokenManager, ctx); err != nil {
+			if newCtx, err := authorize(tokenManager.ParseToken, ctx); err != nil {
 				return nil, err
 			} else {
 				return handler(newCtx, req)
@@ -33,15 +40,19 @@ func unaryAuthInterceptor(tokenManager token.TokenManager, authMethods map[strin
 	}
 }
 
-func streamAuthInterceptor(tokenManager token.TokenManager, authMethods map[string]bool) grpc.StreamServerInterceptor {
+func streamAuthInterceptor(tokenManager token.TokenManager, authMethods, restrictedMethods map[string]bool) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{},
 		ss grpc.ServerStream,
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
 	) error {
-		if authMethods[info.FullMethod] {
-			if newCtx, err := authorize(tokenManager, ss.Context()); err != nil {
+		if authMethods[info.FullMethod] || restrictedMethods[info.FullMethod] {
+			parse := tokenManager.ParseToken
+			if restrictedMethods[info.FullMethod] {
+				parse = tokenManager.ParseRestrictedToken
+			}
+			if newCtx, err := authorize(parse, ss.Context()); err != nil {
 				return err
 			} else {
 				sw := newStreamContextWrapper(ss)
@@ -53,7 +64,7 @@ func streamAuthInterceptor(tokenManager token.TokenManager, authMethods map[stri
 	}
 }
 
-func authorize(tokenManager token.TokenManager, ctx context.Context) (context.Context, error) {
+func authorize(parseToken func(tokenString string) (int64, error), ctx context.Context) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "metadata is not provided")
@@ -65,7 +76,7 @@ func authorize(tokenManager token.TokenManager, ctx context.Context) (context.Co
 	}
 
 	accessToken := values[0]
-	userID, err := tokenManager.ParseToken(accessToken)
+	userID, err := parseToken(accessToken)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "token is invalid: %v", err)
 	}
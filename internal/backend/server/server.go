@@ -26,13 +26,33 @@ import (
 var log = logger.LoggerOfComponent("grpc-handler")
 
 type GophkeeperService interface {
-	Login(ctx context.Context, login string, password string) (string, model.User, error)
-	Register(ctx context.Context, login string, password string) (string, model.User, error)
+	GetAuthParams(ctx context.Context, login string) (model.AuthParams, error)
+	Login(ctx context.Context, login, password, authKey string) (string, model.User, error)
+	Register(ctx context.Context, login string, authKey string, kdf model.KDFParams, wrappedVaultKey []byte, cipherSuite string,
+		recoveryKit model.RecoveryKit) (string, model.User, error)
 	GetSecretSyncMetaByUser(ctx context.Context, id int64) ([]dto.SecretSyncMetadata, error)
-	GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, name string) (dto.SecretSyncMetadata, error)
+	GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, nameIndex string) (dto.SecretSyncMetadata, error)
 	GetSecret(ctx context.Context, userID int, secretID string) (model.EncodedSecret, error)
 	SaveEncodedSecret(ctx context.Context, ownerID int, secret model.EncodedSecret) error
 	DeleteSecret(ctx context.Context, ownerID int, secretID string) error
+	ChangePassword(ctx context.Context, userID int, oldAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error)
+	GetUser(ctx context.Context, userID int) (model.User, error)
+	StartKeyRotation(ctx context.Context, userID int, pendingWrappedVaultKey []byte) (model.User, error)
+	FinishKeyRotation(ctx context.Context, userID int) (model.User, error)
+	SetRecoveryKit(ctx context.Context, userID int, recoveryKit model.RecoveryKit) (model.User, error)
+	GetRecoveryKit(ctx context.Context, login, recoveryAuthKey string) (model.RecoveryKit, error)
+	RecoverAccount(ctx context.Context, login, recoveryAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error)
+	VerifyTwoFactor(ctx context.Context, userID int, code string) (string, model.User, error)
+	EnableTwoFactor(ctx context.Context, userID int, secret, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int, code string) (model.User, error)
+	GetAttachmentsMeta(ctx context.Context, userID int) ([]model.EncodedAttachment, error)
+	GetAttachment(ctx context.Context, userID int, attachmentID string) (model.EncodedAttachment, error)
+	SaveAttachment(ctx context.Context, ownerID int, attachment model.EncodedAttachment) error
+	DeleteAttachment(ctx context.Context, ownerID int, attachmentID string) error
+	GetMissingChunks(ctx context.Context, ownerID int, hashes []string) ([]string, error)
+	SaveChunks(ctx context.Context, ownerID int, next func() (model.SecretChunk, error)) (int64, error)
+	SetSecretChunks(ctx context.Context, ownerID int, secretID string, hashes []string) error
+	GetSecretChunks(ctx context.Context, ownerID int, secretID string, offset int64, send func(chunk model.SecretChunk) error) error
 }
 
 type gophkeeperGRPCHandler struct {
@@ -49,6 +69,8 @@ type GRPCGophkeeperServer struct {
 	Server       *grpc.Server
 	tokenManager tokenManager.TokenManager
 	authMethods  map[string]bool
+	// restrictedMethods methods which accept only restricted token of two-factor login.
+	restrictedMethods map[string]bool
 }
 
 // Start starts server.
@@ -59,8 +81,8 @@ func (s *GRPCGophkeeperServer) Start() error {
 	}
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(unaryAuthInterceptor(s.tokenManager, s.authMethods)),
-		grpc.StreamInterceptor(streamAuthInterceptor(s.tokenManager, s.authMethods)))
+		grpc.UnaryInterceptor(unaryAuthInterceptor(s.tokenManager, s.authMethods, s.restrictedMethods)),
+		grpc.StreamInterceptor(streamAuthInterceptor(s.tokenManager, s.authMethods, s.restrictedMethods)))
 
 	s.Server = grpcServer
 
@@ -79,8 +101,8 @@ func (s *GRPCGophkeeperServer) StartTLS(cfg *tls.Config) error {
 	}
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(unaryAuthInterceptor(s.tokenManager, s.authMethods)),
-		grpc.StreamInterceptor(streamAuthInterceptor(s.tokenManager, s.authMethods)),
+		grpc.UnaryInterceptor(unaryAuthInterceptor(s.tokenManager, s.authMethods, s.restrictedMethods)),
+		grpc.StreamInterceptor(streamAuthInterceptor(s.tokenManager, s.authMethods, s.restrictedMethods)),
 		grpc.Creds(tlsCreds),
 	)
 
@@ -100,16 +122,30 @@ func (s *GRPCGophkeeperServer) Stop(_ context.Context) error {
 // NewGRPCGophkeeperServer GRPCGophkeeperServer constructor.
 func NewGRPCGophkeeperServer(serverAddr string, service GophkeeperService, manager tokenManager.TokenManager) *GRPCGophkeeperServer {
 	return &GRPCGophkeeperServer{
-		addr:         serverAddr,
-		service:      service,
-		tokenManager: manager,
-		authMethods:  pb.DefaultAuthMethods,
+		addr:              serverAddr,
+		service:           service,
+		tokenManager:      manager,
+		authMethods:       pb.DefaultAuthMethods,
+		restrictedMethods: pb.RestrictedAuthMethods,
 	}
 }
 
+// GetAuthParams returns parameters for auth key derivation before login.
+func (s *gophkeeperGRPCHandler) GetAuthParams(ctx context.Context, name *pb.Name) (*pb.AuthParams, error) {
+	params, err := s.service.GetAuthParams(ctx, name.GetName())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorEmptyValue, err) || errors.Is(service.ErrUserNotFound, err) {
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoAuthParams(params), nil
+}
+
 // Login login user.
 func (s *gophkeeperGRPCHandler) Login(ctx context.Context, credentials *pb.Credentials) (*pb.AuthMeta, error) {
-	token, user, err := s.service.Login(ctx, credentials.Login, credentials.Password)
+	token, user, err := s.service.Login(ctx, credentials.Login, credentials.Password, credentials.GetAuthKey())
 	if err != nil {
 		log.Error(err)
 		if errors.Is(errs.ErrorInvalidPassword, err) || errors.Is(errs.ErrorEmptyValue, err) || errors.Is(service.ErrUserNotFound, err) {
@@ -122,7 +158,9 @@ func (s *gophkeeperGRPCHandler) Login(ctx context.Context, credentials *pb.Crede
 
 // Register register user.
 func (s *gophkeeperGRPCHandler) Register(ctx context.Context, credentials *pb.Credentials) (*pb.AuthMeta, error) {
-	token, user, err := s.service.Register(ctx, credentials.Login, credentials.Password)
+	kdf := pb.KDFParamsFromProto(credentials.GetKdfParams())
+	recoveryKit := pb.RecoveryKitFromProto(credentials.GetRecoveryKit())
+	token, user, err := s.service.Register(ctx, credentials.Login, credentials.GetAuthKey(), kdf, credentials.GetWrappedVaultKey(), credentials.GetCipherSuite(), recoveryKit)
 	if err != nil {
 		log.Error(err)
 		if errors.Is(errs.ErrorEmptyValue, err) {
@@ -131,6 +169,10 @@ func (s *gophkeeperGRPCHandler) Register(ctx context.Context, credentials *pb.Cr
 		if errors.Is(errs.ErrorLoginIsAlreadyUsed, err) {
 			return nil, status.Errorf(codes.AlreadyExists, err.Error())
 		}
+		if errors.Is(errs.ErrorInvalidKDFParams, err) || errors.Is(errs.ErrorUnknownCipherSuite, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
 	}
 	return &pb.AuthMeta{Token: token, User: pb.NewProtoUserFromUser(user)}, nil
 }
@@ -151,6 +193,7 @@ func (s *gophkeeperGRPCHandler) GetSecretSyncMeta(ctx context.Context, _ *emptyp
 	return &pb.GetSecretsSyncDataResponse{Items: protoSyncMeta}, nil
 }
 
+// GetSecretSyncMetaByName returns metadata for secret synchronization, name contains blind index of secret name.
 func (s *gophkeeperGRPCHandler) GetSecretSyncMetaByName(ctx context.Context, name *pb.Name) (*pb.SecretSyncData, error) {
 	id, err := getUserID(ctx)
 	if err != nil {
@@ -194,6 +237,9 @@ func (s *gophkeeperGRPCHandler) SaveEncodedSecret(ctx context.Context, encodedSe
 		if errors.Is(service.ErrOwnerMissmatch, err) {
 			return nil, status.Errorf(codes.PermissionDenied, err.Error())
 		}
+		if errors.Is(service.ErrKeyRotationInProgress, err) || errors.Is(service.ErrOutdatedVaultKey, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Unknown, err.Error())
 	}
 
@@ -218,6 +264,250 @@ func (s *gophkeeperGRPCHandler) DeleteSecret(ctx context.Context, secretID *pb.S
 	return &emptypb.Empty{}, nil
 }
 
+// GetAttachmentsMeta returns attachments of user without encrypted content.
+func (s *gophkeeperGRPCHandler) GetAttachmentsMeta(ctx context.Context, _ *emptypb.Empty) (*pb.Attachments, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	attachments, err := s.service.GetAttachmentsMeta(ctx, ownerID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	items := make([]*pb.EncodedAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		items = append(items, pb.NewProtoEncodedAttachment(attachment))
+	}
+	return &pb.Attachments{Items: items}, nil
+}
+
+// GetAttachment returns EncodedAttachment with encrypted content by ID.
+func (s *gophkeeperGRPCHandler) GetAttachment(ctx context.Context, attachmentID *pb.AttachmentID) (*pb.EncodedAttachment, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	attachment, err := s.service.GetAttachment(ctx, ownerID, attachmentID.GetAttachmentID())
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoEncodedAttachment(attachment), nil
+}
+
+// SaveAttachment saves EncodedAttachment of secret.
+func (s *gophkeeperGRPCHandler) SaveAttachment(ctx context.Context, attachment *pb.EncodedAttachment) (*emptypb.Empty, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.service.SaveAttachment(ctx, ownerID, pb.EncodedAttachmentFromProto(attachment))
+	if err != nil {
+		if errors.Is(service.ErrOwnerMissmatch, err) {
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		if errors.Is(service.ErrAttachmentWithoutContent, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(service.ErrKeyRotationInProgress, err) || errors.Is(service.ErrOutdatedVaultKey, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteAttachment deletes EncodedAttachment by ID.
+func (s *gophkeeperGRPCHandler) DeleteAttachment(ctx context.Context, attachmentID *pb.AttachmentID) (*emptypb.Empty, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.service.DeleteAttachment(ctx, ownerID, attachmentID.GetAttachmentID())
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetMissingChunks returns addresses of chunks which are not uploaded yet among requested ones.
+func (s *gophkeeperGRPCHandler) GetMissingChunks(ctx context.Context, req *pb.ChunkHashes) (*pb.ChunkHashes, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	missing, err := s.service.GetMissingChunks(ctx, ownerID, req.GetHashes())
+	if err != nil {
+		log.Error(err)
+		return nil, chunkStatusError(err)
+	}
+	return &pb.ChunkHashes{Hashes: missing}, nil
+}
+
+// UploadChunks saves chunks sent by client, returns number of saved chunks.
+func (s *gophkeeperGRPCHandler) UploadChunks(stream pb.Gophkeeper_UploadChunksServer) error {
+	ownerID, err := getUserID(stream.Context())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	count, err := s.service.SaveChunks(stream.Context(), ownerID, func() (model.SecretChunk, error) {
+		chunk, err := stream.Recv()
+		if err != nil {
+			return model.SecretChunk{}, err
+		}
+		return pb.SecretChunkFromProto(chunk), nil
+	})
+	if err != nil {
+		log.Error(err)
+		return chunkStatusError(err)
+	}
+	return stream.SendAndClose(&pb.UploadedChunks{Count: count})
+}
+
+// SetSecretChunks sets chunks file of secret consists of.
+func (s *gophkeeperGRPCHandler) SetSecretChunks(ctx context.Context, req *pb.SecretChunkRefs) (*emptypb.Empty, error) {
+	ownerID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	err = s.service.SetSecretChunks(ctx, ownerID, req.GetSecretID(), req.GetHashes())
+	if err != nil {
+		log.Error(err)
+		return nil, chunkStatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DownloadSecretChunks sends chunks of secret starting from requested offset.
+func (s *gophkeeperGRPCHandler) DownloadSecretChunks(req *pb.ChunkRequest, stream pb.Gophkeeper_DownloadSecretChunksServer) error {
+	ownerID, err := getUserID(stream.Context())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	err = s.service.GetSecretChunks(stream.Context(), ownerID, req.GetSecretID(), req.GetOffset(), func(chunk model.SecretChunk) error {
+		return stream.Send(pb.NewProtoSecretChunk(chunk))
+	})
+	if err != nil {
+		log.Error(err)
+		return chunkStatusError(err)
+	}
+	return nil
+}
+
+func chunkStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(errs.ErrItemNotFound, err) {
+		return status.Errorf(codes.NotFound, err.Error())
+	}
+	if errors.Is(model.ErrChunkOutOfOrder, err) || errors.Is(model.ErrInvalidChunkHash, err) || errors.Is(errs.ErrorEmptyValue, err) {
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(model.ErrMissingChunks, err) {
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Unknown, err.Error())
+}
+
+// ChangePassword changes user password and re-wrapped vault key.
+func (s *gophkeeperGRPCHandler) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.AuthMeta, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	kdf := pb.KDFParamsFromProto(req.GetKdfParams())
+	token, user, err := s.service.ChangePassword(ctx, userID, req.GetOldAuthKey(), req.GetNewAuthKey(), kdf, req.GetWrappedVaultKey())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorInvalidPassword, err) {
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(errs.ErrorEmptyValue, err) || errors.Is(errs.ErrorInvalidKDFParams, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(service.ErrKeyRotationInProgress, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &pb.AuthMeta{Token: token, User: pb.NewProtoUserFromUser(user)}, nil
+}
+
+// StartKeyRotation starts vault key rotation or returns rotation which is already in progress.
+func (s *gophkeeperGRPCHandler) StartKeyRotation(ctx context.Context, req *pb.KeyRotationRequest) (*pb.User, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	user, err := s.service.StartKeyRotation(ctx, userID, req.GetWrappedVaultKey())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorEmptyValue, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoUserFromUser(user), nil
+}
+
+// FinishKeyRotation makes new vault key current one.
+func (s *gophkeeperGRPCHandler) FinishKeyRotation(ctx context.Context, _ *emptypb.Empty) (*pb.User, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	user, err := s.service.FinishKeyRotation(ctx, userID)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(service.ErrKeyRotationNotStarted, err) || errors.Is(service.ErrKeyRotationIncomplete, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoUserFromUser(user), nil
+}
+
+// GetChangeEvents returns account change events happened after specified timestamp.
+func (s *gophkeeperGRPCHandler) GetChangeEvents(ctx context.Context, req *pb.ChangeEventsRequest) (*pb.ChangeEvents, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	user, err := s.service.GetUser(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+
+	events := make([]*pb.ChangeEvent, 0, 1)
+	if user.Timestamp > req.GetSince() {
+		events = append(events, &pb.ChangeEvent{
+			Type:    pb.EVENT_TYPE_PASSWORD_CHANGE,
+			Payload: &pb.ChangeEvent_User{User: pb.NewProtoUserFromUser(user)},
+		})
+	}
+	return &pb.ChangeEvents{Events: events}, nil
+}
+
 func getUserID(ctx context.Context) (int, error) {
 	meta, ok := metadata.FromIncomingContext(ctx)
 
@@ -239,3 +529,113 @@ func convertSecretSyncMetaToProto(syncMetas []dto.SecretSyncMetadata) []*pb.Secr
 	}
 	return protoSyncMeta
 }
+
+// SetRecoveryKit stores new recovery kit of user.
+func (s *gophkeeperGRPCHandler) SetRecoveryKit(ctx context.Context, req *pb.RecoveryKit) (*pb.User, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+
+	user, err := s.service.SetRecoveryKit(ctx, userID, pb.RecoveryKitFromProto(req))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorEmptyValue, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(service.ErrKeyRotationInProgress, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoUserFromUser(user), nil
+}
+
+// GetRecoveryKit returns vault key wrapped with recovery key.
+func (s *gophkeeperGRPCHandler) GetRecoveryKit(ctx context.Context, req *pb.RecoveryRequest) (*pb.RecoveryKit, error) {
+	recoveryKit, err := s.service.GetRecoveryKit(ctx, req.GetLogin(), req.GetRecoveryAuthKey())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorInvalidRecoveryKey, err) || errors.Is(errs.ErrorEmptyValue, err) || errors.Is(service.ErrUserNotFound, err) {
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return pb.NewProtoRecoveryKit(recoveryKit), nil
+}
+
+// RecoverAccount sets new password of user who proved possession of recovery key.
+func (s *gophkeeperGRPCHandler) RecoverAccount(ctx context.Context, req *pb.RecoverAccountRequest) (*pb.AuthMeta, error) {
+	kdf := pb.KDFParamsFromProto(req.GetKdfParams())
+	token, user, err := s.service.RecoverAccount(ctx, req.GetLogin(), req.GetRecoveryAuthKey(), req.GetNewAuthKey(), kdf, req.GetWrappedVaultKey())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorInvalidRecoveryKey, err) || errors.Is(service.ErrUserNotFound, err) {
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(errs.ErrorEmptyValue, err) || errors.Is(errs.ErrorInvalidKDFParams, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(service.ErrKeyRotationInProgress, err) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &pb.AuthMeta{Token: token, User: pb.NewProtoUserFromUser(user)}, nil
+}
+
+// VerifyTwoFactor completes two-factor login, requires restricted token issued by Login.
+func (s *gophkeeperGRPCHandler) VerifyTwoFactor(ctx context.Context, code *pb.TwoFactorCode) (*pb.AuthMeta, error) {
+	id, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+	token, user, err := s.service.VerifyTwoFactor(ctx, id, code.GetCode())
+	if err != nil {
+		log.Error(err)
+		return nil, twoFactorStatusError(err)
+	}
+	return &pb.AuthMeta{Token: token, User: pb.NewProtoUserFromUser(user)}, nil
+}
+
+// EnableTwoFactor enables second factor of user and returns recovery codes.
+func (s *gophkeeperGRPCHandler) EnableTwoFactor(ctx context.Context, setup *pb.TwoFactorSetup) (*pb.RecoveryCodes, error) {
+	id, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+	recoveryCodes, err := s.service.EnableTwoFactor(ctx, id, setup.GetSecret(), setup.GetCode())
+	if err != nil {
+		log.Error(err)
+		if errors.Is(errs.ErrorEmptyValue, err) || errors.Is(model.ErrInvalidOTPSecret, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, twoFactorStatusError(err)
+	}
+	return &pb.RecoveryCodes{Codes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor disables second factor of user.
+func (s *gophkeeperGRPCHandler) DisableTwoFactor(ctx context.Context, code *pb.TwoFactorCode) (*pb.User, error) {
+	id, err := getUserID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to extract user id: "+err.Error())
+	}
+	user, err := s.service.DisableTwoFactor(ctx, id, code.GetCode())
+	if err != nil {
+		log.Error(err)
+		return nil, twoFactorStatusError(err)
+	}
+	return pb.NewProtoUserFromUser(user), nil
+}
+
+func twoFactorStatusError(err error) error {
+	if errors.Is(errs.ErrorInvalidTwoFactorCode, err) || errors.Is(service.ErrTooManyTwoFactorAttempts, err) ||
+		errors.Is(errs.ErrorEmptyValue, err) || errors.Is(service.ErrUserNotFound, err) {
+		return status.Errorf(codes.Unauthenticated, err.Error())
+	}
+	if errors.Is(service.ErrTwoFactorAlreadyEnabled, err) || errors.Is(service.ErrTwoFactorNotEnabled, err) {
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Unknown, err.Error())
+}
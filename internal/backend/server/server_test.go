@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"errors"
+	"io"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -50,6 +53,7 @@ func (s *GRPCServerSuite) SetupTest() {
 			log.Fatal(err)
 		}
 	}()
+	waitForListener(":3333")
 	conn, err := grpc.Dial(":3333", grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Fatal(err)
@@ -57,6 +61,19 @@ func (s *GRPCServerSuite) SetupTest() {
 	s.client = pb.NewGophkeeperClient(conn)
 }
 
+// waitForListener blocks until server starts accepting connections.
+func waitForListener(addr string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func (s *GRPCServerSuite) TearDownTest() {
 	if s.server != nil {
 		err := s.server.Stop(context.Background())
@@ -71,10 +88,13 @@ var (
 	userID             int64 = 1
 	userLogin                = "login"
 	userPassword             = "password"
+	userAuthKey              = "authKey"
 	userHashedPassword       = "hashedPassword"
 	userTimestamp      int64 = 1679391035652
 	userToken                = "token"
-	user                     = model.User{ID: userID, Login: userLogin, HashedPassword: userHashedPassword, Timestamp: userTimestamp}
+	userKDF                  = model.KDFParams{Algorithm: model.KDFArgon2id, Salt: []byte("0123456789abcdef"), Time: 3, Memory: 65536, Threads: 4}
+	userWrappedKey           = []byte("wrappedVaultKey")
+	user                     = model.User{ID: userID, Login: userLogin, HashedPassword: userHashedPassword, KDF: userKDF, WrappedVaultKey: userWrappedKey, Timestamp: userTimestamp}
 	secretID                 = "1"
 	secretName               = "secretName"
 	secretDescription        = "secretDescription"
@@ -84,25 +104,46 @@ var (
 	secretEncContent         = []byte("bytes")
 	syncMeta                 = dto.SecretSyncMetadata{ID: secretID, Hash: secretHash, Timestamp: secretTimestamp}
 	syncMetas                = []dto.SecretSyncMetadata{{ID: secretID, Hash: secretHash, Timestamp: secretTimestamp}}
+	recoveryKit              = model.RecoveryKit{AuthKey: "recoveryAuthKey", WrappedVaultKey: []byte("recoveryWrappedVaultKey")}
 	encodedSecret            = model.EncodedSecret{ID: secretID, Name: secretName, Owner: userID, Description: secretDescription, Type: secretType, EncodedContent: secretEncContent, Hash: secretHash, Timestamp: secretTimestamp}
 )
 
 func (s *GRPCServerSuite) TestRegisterSuccess() {
-	s.service.EXPECT().Register(gomock.All(), userLogin, userPassword).Return(userToken, user, nil)
-	authMeta, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword})
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, userKDF, userWrappedKey, model.CipherSuiteXChaCha20Poly1305, model.RecoveryKit{}).Return(userToken, user, nil)
+	authMeta, err := s.client.Register(context.Background(), &pb.Credentials{
+		Login:           userLogin,
+		AuthKey:         userAuthKey,
+		KdfParams:       pb.NewProtoKDFParams(userKDF),
+		WrappedVaultKey: userWrappedKey,
+		CipherSuite:     model.CipherSuiteXChaCha20Poly1305,
+	})
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), authMeta)
 	assert.NotNil(s.T(), authMeta.GetUser())
 	assert.Equal(s.T(), authMeta.GetUser().GetID(), userID)
 	assert.Equal(s.T(), authMeta.GetUser().GetUsername(), userLogin)
-	assert.Equal(s.T(), authMeta.GetUser().GetPasswordHash(), userHashedPassword)
 	assert.Equal(s.T(), authMeta.GetUser().GetTimestamp(), userTimestamp)
+	assert.Equal(s.T(), userKDF, pb.KDFParamsFromProto(authMeta.GetUser().GetKdfParams()))
+	assert.Equal(s.T(), userWrappedKey, authMeta.GetUser().GetWrappedVaultKey())
+	assert.Equal(s.T(), authMeta.GetToken(), userToken)
+}
+
+func (s *GRPCServerSuite) TestRegisterWithRecoveryKit() {
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, userKDF, userWrappedKey, "", recoveryKit).Return(userToken, user, nil)
+	authMeta, err := s.client.Register(context.Background(), &pb.Credentials{
+		Login:           userLogin,
+		AuthKey:         userAuthKey,
+		KdfParams:       pb.NewProtoKDFParams(userKDF),
+		WrappedVaultKey: userWrappedKey,
+		RecoveryKit:     pb.NewProtoRecoveryKit(recoveryKit),
+	})
+	assert.NoError(s.T(), err)
 	assert.Equal(s.T(), authMeta.GetToken(), userToken)
 }
 
 func (s *GRPCServerSuite) TestRegisterErrorLoginIsAlreadyUsed() {
-	s.service.EXPECT().Register(gomock.All(), userLogin, userPassword).Return("", model.User{}, errs.ErrorLoginIsAlreadyUsed)
-	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword})
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorLoginIsAlreadyUsed)
+	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, AuthKey: userAuthKey})
 	assert.NotNil(s.T(), err)
 	st, ok := status.FromError(err)
 	assert.True(s.T(), ok)
@@ -110,9 +151,29 @@ func (s *GRPCServerSuite) TestRegisterErrorLoginIsAlreadyUsed() {
 	assert.Equal(s.T(), errs.ErrorLoginIsAlreadyUsed.Error(), st.Message())
 }
 
+func (s *GRPCServerSuite) TestRegisterErrorInvalidKDFParams() {
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorInvalidKDFParams)
+	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, AuthKey: userAuthKey, KdfParams: &pb.KDFParams{Algorithm: "md5"}})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+	assert.Equal(s.T(), errs.ErrorInvalidKDFParams.Error(), st.Message())
+}
+
+func (s *GRPCServerSuite) TestRegisterErrorUnknownCipherSuite() {
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, gomock.Any(), gomock.Any(), "des", gomock.Any()).Return("", model.User{}, errs.ErrorUnknownCipherSuite)
+	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, AuthKey: userAuthKey, CipherSuite: "des"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+	assert.Equal(s.T(), errs.ErrorUnknownCipherSuite.Error(), st.Message())
+}
+
 func (s *GRPCServerSuite) TestRegisterErrorEmptyValue() {
-	s.service.EXPECT().Register(gomock.All(), userLogin, userPassword).Return("", model.User{}, errs.ErrorEmptyValue)
-	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword})
+	s.service.EXPECT().Register(gomock.All(), userLogin, userAuthKey, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorEmptyValue)
+	_, err := s.client.Register(context.Background(), &pb.Credentials{Login: userLogin, AuthKey: userAuthKey})
 	assert.NotNil(s.T(), err)
 	st, ok := status.FromError(err)
 	assert.True(s.T(), ok)
@@ -120,22 +181,47 @@ func (s *GRPCServerSuite) TestRegisterErrorEmptyValue() {
 	assert.Equal(s.T(), errs.ErrorEmptyValue.Error(), st.Message())
 }
 
+func (s *GRPCServerSuite) TestGetAuthParamsSuccess() {
+	s.service.EXPECT().GetAuthParams(gomock.Any(), userLogin).Return(model.AuthParams{KDF: userKDF, ZeroKnowledge: true}, nil)
+	params, err := s.client.GetAuthParams(context.Background(), &pb.Name{Name: userLogin})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), userKDF, pb.KDFParamsFromProto(params.GetKdfParams()))
+	assert.True(s.T(), params.GetZeroKnowledge())
+}
+
+func (s *GRPCServerSuite) TestGetAuthParamsErrorUserNotFound() {
+	s.service.EXPECT().GetAuthParams(gomock.Any(), userLogin).Return(model.AuthParams{}, service.ErrUserNotFound)
+	_, err := s.client.GetAuthParams(context.Background(), &pb.Name{Name: userLogin})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
 func (s *GRPCServerSuite) TestLoginSuccess() {
-	s.service.EXPECT().Login(gomock.All(), userLogin, userPassword).Return(userToken, user, nil)
-	authMeta, err := s.client.Login(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword})
+	s.service.EXPECT().Login(gomock.All(), userLogin, "", userAuthKey).Return(userToken, user, nil)
+	authMeta, err := s.client.Login(context.Background(), &pb.Credentials{Login: userLogin, AuthKey: userAuthKey})
 	assert.NoError(s.T(), err)
 	assert.NotNil(s.T(), authMeta)
 	assert.NotNil(s.T(), authMeta.GetUser())
 	assert.Equal(s.T(), authMeta.GetUser().GetID(), userID)
 	assert.Equal(s.T(), authMeta.GetUser().GetUsername(), userLogin)
-	assert.Equal(s.T(), authMeta.GetUser().GetPasswordHash(), userHashedPassword)
 	assert.Equal(s.T(), authMeta.GetUser().GetTimestamp(), userTimestamp)
+	assert.Equal(s.T(), userKDF, pb.KDFParamsFromProto(authMeta.GetUser().GetKdfParams()))
+	assert.Equal(s.T(), userWrappedKey, authMeta.GetUser().GetWrappedVaultKey())
+	assert.Equal(s.T(), authMeta.GetToken(), userToken)
+}
+
+func (s *GRPCServerSuite) TestLoginLegacyPassword() {
+	s.service.EXPECT().Login(gomock.All(), userLogin, userPassword, userAuthKey).Return(userToken, user, nil)
+	authMeta, err := s.client.Login(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword, AuthKey: userAuthKey})
+	assert.NoError(s.T(), err)
 	assert.Equal(s.T(), authMeta.GetToken(), userToken)
 }
 
 func (s *GRPCServerSuite) TestLoginErrorEmptyValue() {
-	s.service.EXPECT().Login(gomock.All(), userLogin, userPassword).Return("", model.User{}, errs.ErrorEmptyValue)
-	_, err := s.client.Login(context.Background(), &pb.Credentials{Login: userLogin, Password: userPassword})
+	s.service.EXPECT().Login(gomock.All(), userLogin, "", "").Return("", model.User{}, errs.ErrorEmptyValue)
+	_, err := s.client.Login(context.Background(), &pb.Credentials{Login: userLogin})
 	assert.NotNil(s.T(), err)
 	st, ok := status.FromError(err)
 	assert.True(s.T(), ok)
@@ -276,6 +362,18 @@ func (s *GRPCServerSuite) TestSaveEncodedSecretErr() {
 	assert.Equal(s.T(), codes.Unknown, st.Code())
 }
 
+func (s *GRPCServerSuite) TestSaveEncodedSecretErrKeyRotationInProgress() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveEncodedSecret(gomock.Any(), int(userID), encodedSecret).Return(service.ErrKeyRotationInProgress)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SaveEncodedSecret(ctx, pb.EncSecretProtoFromEncSecret(encodedSecret))
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.FailedPrecondition, st.Code())
+	assert.Equal(s.T(), service.ErrKeyRotationInProgress.Error(), st.Message())
+}
+
 func (s *GRPCServerSuite) TestDeleteSecretSuccess() {
 	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
 	s.service.EXPECT().DeleteSecret(gomock.Any(), int(userID), secretID).Return(nil)
@@ -306,3 +404,459 @@ func (s *GRPCServerSuite) TestDeleteSecretError() {
 	assert.True(s.T(), ok)
 	assert.Equal(s.T(), codes.Unknown, st.Code())
 }
+
+func (s *GRPCServerSuite) TestChangePasswordSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().ChangePassword(gomock.Any(), int(userID), userAuthKey, "newAuthKey", userKDF, userWrappedKey).Return(userToken, user, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	authMeta, err := s.client.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		OldAuthKey:      userAuthKey,
+		NewAuthKey:      "newAuthKey",
+		KdfParams:       pb.NewProtoKDFParams(userKDF),
+		WrappedVaultKey: userWrappedKey,
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), userToken, authMeta.GetToken())
+	assert.Equal(s.T(), userWrappedKey, authMeta.GetUser().GetWrappedVaultKey())
+}
+
+func (s *GRPCServerSuite) TestChangePasswordNoAuth() {
+	_, err := s.client.ChangePassword(context.Background(), &pb.ChangePasswordRequest{OldAuthKey: userAuthKey, NewAuthKey: "newAuthKey"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestChangePasswordErrorInvalidPassword() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().ChangePassword(gomock.Any(), int(userID), userAuthKey, "newAuthKey", gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorInvalidPassword)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.ChangePassword(ctx, &pb.ChangePasswordRequest{OldAuthKey: userAuthKey, NewAuthKey: "newAuthKey"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+	assert.Equal(s.T(), errs.ErrorInvalidPassword.Error(), st.Message())
+}
+
+func (s *GRPCServerSuite) TestChangePasswordErrorEmptyValue() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().ChangePassword(gomock.Any(), int(userID), userAuthKey, "newAuthKey", gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorEmptyValue)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.ChangePassword(ctx, &pb.ChangePasswordRequest{OldAuthKey: userAuthKey, NewAuthKey: "newAuthKey"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+}
+
+func (s *GRPCServerSuite) TestGetChangeEventsPasswordChanged() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetUser(gomock.Any(), int(userID)).Return(user, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	events, err := s.client.GetChangeEvents(ctx, &pb.ChangeEventsRequest{Since: userTimestamp - 1})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, len(events.GetEvents()))
+	event := events.GetEvents()[0]
+	assert.Equal(s.T(), pb.EVENT_TYPE_PASSWORD_CHANGE, event.GetType())
+	assert.Equal(s.T(), userTimestamp, event.GetUser().GetTimestamp())
+}
+
+func (s *GRPCServerSuite) TestGetChangeEventsNoChanges() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetUser(gomock.Any(), int(userID)).Return(user, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	events, err := s.client.GetChangeEvents(ctx, &pb.ChangeEventsRequest{Since: userTimestamp})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, len(events.GetEvents()))
+}
+
+func (s *GRPCServerSuite) TestStartKeyRotationSuccess() {
+	pendingKey := []byte("pendingVaultKey")
+	rotatingUser := user
+	rotatingUser.PendingWrappedVaultKey = pendingKey
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().StartKeyRotation(gomock.Any(), int(userID), pendingKey).Return(rotatingUser, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	res, err := s.client.StartKeyRotation(ctx, &pb.KeyRotationRequest{WrappedVaultKey: pendingKey})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), pendingKey, res.GetPendingWrappedVaultKey())
+	assert.Equal(s.T(), userWrappedKey, res.GetWrappedVaultKey())
+}
+
+func (s *GRPCServerSuite) TestStartKeyRotationNoAuth() {
+	_, err := s.client.StartKeyRotation(context.Background(), &pb.KeyRotationRequest{WrappedVaultKey: userWrappedKey})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestStartKeyRotationErrorEmptyValue() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().StartKeyRotation(gomock.Any(), int(userID), gomock.Any()).Return(model.User{}, errs.ErrorEmptyValue)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.StartKeyRotation(ctx, &pb.KeyRotationRequest{})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+}
+
+func (s *GRPCServerSuite) TestFinishKeyRotationSuccess() {
+	rotatedUser := user
+	rotatedUser.KeyVersion = 1
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().FinishKeyRotation(gomock.Any(), int(userID)).Return(rotatedUser, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	res, err := s.client.FinishKeyRotation(ctx, &emptypb.Empty{})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), res.GetKeyVersion())
+}
+
+func (s *GRPCServerSuite) TestFinishKeyRotationErrorIncomplete() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().FinishKeyRotation(gomock.Any(), int(userID)).Return(model.User{}, service.ErrKeyRotationIncomplete)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.FinishKeyRotation(ctx, &emptypb.Empty{})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.FailedPrecondition, st.Code())
+	assert.Equal(s.T(), service.ErrKeyRotationIncomplete.Error(), st.Message())
+}
+
+func (s *GRPCServerSuite) TestSetRecoveryKitSuccess() {
+	userWithRecovery := user
+	userWithRecovery.RecoveryWrappedVaultKey = recoveryKit.WrappedVaultKey
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SetRecoveryKit(gomock.Any(), int(userID), recoveryKit).Return(userWithRecovery, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	res, err := s.client.SetRecoveryKit(ctx, pb.NewProtoRecoveryKit(recoveryKit))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), recoveryKit.WrappedVaultKey, res.GetRecoveryWrappedVaultKey())
+}
+
+func (s *GRPCServerSuite) TestSetRecoveryKitNoAuth() {
+	_, err := s.client.SetRecoveryKit(context.Background(), pb.NewProtoRecoveryKit(recoveryKit))
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestGetRecoveryKitSuccess() {
+	s.service.EXPECT().GetRecoveryKit(gomock.Any(), userLogin, recoveryKit.AuthKey).Return(model.RecoveryKit{WrappedVaultKey: recoveryKit.WrappedVaultKey}, nil)
+	res, err := s.client.GetRecoveryKit(context.Background(), &pb.RecoveryRequest{Login: userLogin, RecoveryAuthKey: recoveryKit.AuthKey})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), recoveryKit.WrappedVaultKey, res.GetWrappedVaultKey())
+	assert.Empty(s.T(), res.GetAuthKey())
+}
+
+func (s *GRPCServerSuite) TestGetRecoveryKitErrorInvalidRecoveryKey() {
+	s.service.EXPECT().GetRecoveryKit(gomock.Any(), userLogin, "wrong").Return(model.RecoveryKit{}, errs.ErrorInvalidRecoveryKey)
+	_, err := s.client.GetRecoveryKit(context.Background(), &pb.RecoveryRequest{Login: userLogin, RecoveryAuthKey: "wrong"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+	assert.Equal(s.T(), errs.ErrorInvalidRecoveryKey.Error(), st.Message())
+}
+
+func (s *GRPCServerSuite) TestRecoverAccountSuccess() {
+	s.service.EXPECT().RecoverAccount(gomock.Any(), userLogin, recoveryKit.AuthKey, "newAuthKey", userKDF, userWrappedKey).Return(userToken, user, nil)
+	authMeta, err := s.client.RecoverAccount(context.Background(), &pb.RecoverAccountRequest{
+		Login:           userLogin,
+		RecoveryAuthKey: recoveryKit.AuthKey,
+		NewAuthKey:      "newAuthKey",
+		KdfParams:       pb.NewProtoKDFParams(userKDF),
+		WrappedVaultKey: userWrappedKey,
+	})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), userToken, authMeta.GetToken())
+	assert.Equal(s.T(), userWrappedKey, authMeta.GetUser().GetWrappedVaultKey())
+}
+
+func (s *GRPCServerSuite) TestRecoverAccountErrorInvalidRecoveryKey() {
+	s.service.EXPECT().RecoverAccount(gomock.Any(), userLogin, "wrong", "newAuthKey", gomock.Any(), gomock.Any()).Return("", model.User{}, errs.ErrorInvalidRecoveryKey)
+	_, err := s.client.RecoverAccount(context.Background(), &pb.RecoverAccountRequest{Login: userLogin, RecoveryAuthKey: "wrong", NewAuthKey: "newAuthKey"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestVerifyTwoFactorSuccess() {
+	s.tokenManager.EXPECT().ParseRestrictedToken("restrictedToken").Return(userID, nil)
+	s.service.EXPECT().VerifyTwoFactor(gomock.Any(), int(userID), "123456").Return(userToken, user, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, "restrictedToken")
+	authMeta, err := s.client.VerifyTwoFactor(ctx, &pb.TwoFactorCode{Code: "123456"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), userToken, authMeta.GetToken())
+	assert.Equal(s.T(), userID, authMeta.GetUser().GetID())
+}
+
+func (s *GRPCServerSuite) TestVerifyTwoFactorErrorFullToken() {
+	s.tokenManager.EXPECT().ParseRestrictedToken(userToken).Return(int64(0), errors.New("token scope mismatch"))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.VerifyTwoFactor(ctx, &pb.TwoFactorCode{Code: "123456"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestVerifyTwoFactorErrorInvalidCode() {
+	s.tokenManager.EXPECT().ParseRestrictedToken("restrictedToken").Return(userID, nil)
+	s.service.EXPECT().VerifyTwoFactor(gomock.Any(), int(userID), "000000").Return("", model.User{}, errs.ErrorInvalidTwoFactorCode)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, "restrictedToken")
+	_, err := s.client.VerifyTwoFactor(ctx, &pb.TwoFactorCode{Code: "000000"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+	assert.Equal(s.T(), errs.ErrorInvalidTwoFactorCode.Error(), st.Message())
+}
+
+func (s *GRPCServerSuite) TestEnableTwoFactorSuccess() {
+	recoveryCodes := []string{"aaaaaaaa-bbbbbbbb", "cccccccc-dddddddd"}
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().EnableTwoFactor(gomock.Any(), int(userID), "SECRET", "123456").Return(recoveryCodes, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	resp, err := s.client.EnableTwoFactor(ctx, &pb.TwoFactorSetup{Secret: "SECRET", Code: "123456"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), recoveryCodes, resp.GetCodes())
+}
+
+func (s *GRPCServerSuite) TestEnableTwoFactorNoAuth() {
+	_, err := s.client.EnableTwoFactor(context.Background(), &pb.TwoFactorSetup{Secret: "SECRET", Code: "123456"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestEnableTwoFactorErrorAlreadyEnabled() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().EnableTwoFactor(gomock.Any(), int(userID), "SECRET", "123456").Return(nil, service.ErrTwoFactorAlreadyEnabled)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.EnableTwoFactor(ctx, &pb.TwoFactorSetup{Secret: "SECRET", Code: "123456"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.FailedPrecondition, st.Code())
+}
+
+func (s *GRPCServerSuite) TestDisableTwoFactorSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().DisableTwoFactor(gomock.Any(), int(userID), "123456").Return(user, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	protoUser, err := s.client.DisableTwoFactor(ctx, &pb.TwoFactorCode{Code: "123456"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), userID, protoUser.GetID())
+	assert.False(s.T(), protoUser.GetTwoFactorEnabled())
+}
+
+func (s *GRPCServerSuite) TestDisableTwoFactorErrorTooManyAttempts() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().DisableTwoFactor(gomock.Any(), int(userID), "000000").Return(model.User{}, service.ErrTooManyTwoFactorAttempts)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.DisableTwoFactor(ctx, &pb.TwoFactorCode{Code: "000000"})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+var encodedAttachment = model.EncodedAttachment{ID: "2", SecretID: secretID, Owner: userID, Name: "name", Size: 5, EncodedContent: secretEncContent, Hash: secretHash, Timestamp: secretTimestamp}
+
+func (s *GRPCServerSuite) TestGetAttachmentsMetaSuccess() {
+	meta := encodedAttachment
+	meta.EncodedContent = nil
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetAttachmentsMeta(gomock.Any(), int(userID)).Return([]model.EncodedAttachment{meta}, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	attachments, err := s.client.GetAttachmentsMeta(ctx, &emptypb.Empty{})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), attachments.GetItems(), 1)
+	assert.Equal(s.T(), meta, pb.EncodedAttachmentFromProto(attachments.GetItems()[0]))
+}
+
+func (s *GRPCServerSuite) TestGetAttachmentsMetaNoAuth() {
+	_, err := s.client.GetAttachmentsMeta(context.Background(), &emptypb.Empty{})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+}
+
+func (s *GRPCServerSuite) TestGetAttachmentSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetAttachment(gomock.Any(), int(userID), encodedAttachment.ID).Return(encodedAttachment, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	attachment, err := s.client.GetAttachment(ctx, &pb.AttachmentID{AttachmentID: encodedAttachment.ID})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), encodedAttachment, pb.EncodedAttachmentFromProto(attachment))
+}
+
+func (s *GRPCServerSuite) TestGetAttachmentErrNotFound() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetAttachment(gomock.Any(), int(userID), encodedAttachment.ID).Return(model.EncodedAttachment{}, errs.ErrItemNotFound)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.GetAttachment(ctx, &pb.AttachmentID{AttachmentID: encodedAttachment.ID})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.NotFound, st.Code())
+}
+
+func (s *GRPCServerSuite) TestSaveAttachmentSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveAttachment(gomock.Any(), int(userID), encodedAttachment).Return(nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SaveAttachment(ctx, pb.NewProtoEncodedAttachment(encodedAttachment))
+	assert.NoError(s.T(), err)
+}
+
+func (s *GRPCServerSuite) TestSaveAttachmentErrSecretNotFound() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveAttachment(gomock.Any(), int(userID), encodedAttachment).Return(errs.ErrItemNotFound)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SaveAttachment(ctx, pb.NewProtoEncodedAttachment(encodedAttachment))
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.NotFound, st.Code())
+}
+
+func (s *GRPCServerSuite) TestSaveAttachmentErrOutdatedVaultKey() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveAttachment(gomock.Any(), int(userID), encodedAttachment).Return(service.ErrOutdatedVaultKey)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SaveAttachment(ctx, pb.NewProtoEncodedAttachment(encodedAttachment))
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.FailedPrecondition, st.Code())
+}
+
+func (s *GRPCServerSuite) TestDeleteAttachmentSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().DeleteAttachment(gomock.Any(), int(userID), encodedAttachment.ID).Return(nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.DeleteAttachment(ctx, &pb.AttachmentID{AttachmentID: encodedAttachment.ID})
+	assert.NoError(s.T(), err)
+}
+
+var chunkHash = strings.Repeat("ab", 32)
+
+var secretChunks = []model.SecretChunk{{SecretID: secretID, Index: 0, Hash: chunkHash, Data: []byte("first")}, {SecretID: secretID, Index: 1, Hash: chunkHash, Data: []byte("first")}}
+
+func (s *GRPCServerSuite) TestGetMissingChunksSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetMissingChunks(gomock.Any(), int(userID), []string{chunkHash}).Return([]string{chunkHash}, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	missing, err := s.client.GetMissingChunks(ctx, &pb.ChunkHashes{Hashes: []string{chunkHash}})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{chunkHash}, missing.GetHashes())
+}
+
+func (s *GRPCServerSuite) TestGetMissingChunksErrInvalidHash() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetMissingChunks(gomock.Any(), int(userID), []string{"hash"}).Return(nil, model.ErrInvalidChunkHash)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.GetMissingChunks(ctx, &pb.ChunkHashes{Hashes: []string{"hash"}})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+}
+
+func (s *GRPCServerSuite) TestUploadChunksSuccess() {
+	var received []model.SecretChunk
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveChunks(gomock.Any(), int(userID), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ownerID int, next func() (model.SecretChunk, error)) (int64, error) {
+			for {
+				chunk, err := next()
+				if errors.Is(err, io.EOF) {
+					return int64(len(received)), nil
+				}
+				if err != nil {
+					return 0, err
+				}
+				received = append(received, chunk)
+			}
+		})
+	uploaded := model.SecretChunk{Hash: chunkHash, Data: []byte("first")}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	stream, err := s.client.UploadChunks(ctx)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), stream.Send(pb.NewProtoSecretChunk(uploaded)))
+	count, err := stream.CloseAndRecv()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), count.GetCount())
+	assert.Equal(s.T(), []model.SecretChunk{uploaded}, received)
+}
+
+func (s *GRPCServerSuite) TestUploadChunksErrInvalidHash() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SaveChunks(gomock.Any(), int(userID), gomock.Any()).Return(int64(0), model.ErrInvalidChunkHash)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	stream, err := s.client.UploadChunks(ctx)
+	require.NoError(s.T(), err)
+	_, err = stream.CloseAndRecv()
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+}
+
+func (s *GRPCServerSuite) TestSetSecretChunksSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SetSecretChunks(gomock.Any(), int(userID), secretID, []string{chunkHash, chunkHash}).Return(nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SetSecretChunks(ctx, &pb.SecretChunkRefs{SecretID: secretID, Hashes: []string{chunkHash, chunkHash}})
+	assert.NoError(s.T(), err)
+}
+
+func (s *GRPCServerSuite) TestSetSecretChunksErrMissingChunks() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().SetSecretChunks(gomock.Any(), int(userID), secretID, []string{chunkHash}).Return(model.ErrMissingChunks)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	_, err := s.client.SetSecretChunks(ctx, &pb.SecretChunkRefs{SecretID: secretID, Hashes: []string{chunkHash}})
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.FailedPrecondition, st.Code())
+}
+
+func (s *GRPCServerSuite) TestDownloadSecretChunksSuccess() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetSecretChunks(gomock.Any(), int(userID), secretID, int64(1), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ownerID int, secretID string, offset int64, send func(chunk model.SecretChunk) error) error {
+			return send(secretChunks[offset])
+		})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	stream, err := s.client.DownloadSecretChunks(ctx, &pb.ChunkRequest{SecretID: secretID, Offset: 1})
+	require.NoError(s.T(), err)
+	chunk, err := stream.Recv()
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), secretChunks[1], pb.SecretChunkFromProto(chunk))
+	_, err = stream.Recv()
+	assert.ErrorIs(s.T(), err, io.EOF)
+}
+
+func (s *GRPCServerSuite) TestDownloadSecretChunksErrNotFound() {
+	s.tokenManager.EXPECT().ParseToken(userToken).Return(userID, nil)
+	s.service.EXPECT().GetSecretChunks(gomock.Any(), int(userID), secretID, int64(0), gomock.Any()).Return(errs.ErrItemNotFound)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthKey, userToken)
+	stream, err := s.client.DownloadSecretChunks(ctx, &pb.ChunkRequest{SecretID: secretID})
+	require.NoError(s.T(), err)
+	_, err = stream.Recv()
+	assert.NotNil(s.T(), err)
+	st, ok := status.FromError(err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), codes.NotFound, st.Code())
+}
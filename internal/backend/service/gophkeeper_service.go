@@ -3,8 +3,16 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	tokenManager "github.com/apolsh/yapr-gophkeeper/internal/backend/token_manager"
 	"github.com/apolsh/yapr-gophkeeper/internal/model"
@@ -16,9 +24,35 @@ import (
 // UserStorage storage of users.
 type UserStorage interface {
 	// NewUser creates new user
-	NewUser(ctx context.Context, login string, s string) (model.User, error)
+	NewUser(ctx context.Context, user model.User) (model.User, error)
 	// GetUserByLogin returns user by login
 	GetUserByLogin(ctx context.Context, login string) (model.User, error)
+	// GetUserByID returns user by ID
+	GetUserByID(ctx context.Context, id int64) (model.User, error)
+	// UpdateUserCredentials atomically replaces user password hash, KDF parameters and wrapped vault key
+	UpdateUserCredentials(ctx context.Context, user model.User) (model.User, error)
+	// UpdateAuthKeyHash replaces hash of legacy password with hash of auth key
+	UpdateAuthKeyHash(ctx context.Context, userID int64, authKeyHash string) error
+	// StartKeyRotation sets pending wrapped vault key if key rotation is not in progress yet, returns updated user
+	StartKeyRotation(ctx context.Context, userID int64, pendingWrappedVaultKey []byte) (model.User, error)
+	// FinishKeyRotation replaces wrapped vault key with pending one and increments key version, removes recovery kit
+	FinishKeyRotation(ctx context.Context, userID int64) (model.User, error)
+	// UpdateRecoveryKit replaces hash of recovery key and vault key wrapped with recovery key
+	UpdateRecoveryKit(ctx context.Context, userID int64, recoveryKeyHash string, recoveryWrappedVaultKey []byte) (model.User, error)
+	// GetTwoFactorState returns second factor of user
+	GetTwoFactorState(ctx context.Context, userID int64) (model.TwoFactorState, error)
+	// EnableTwoFactor sets TOTP seed of user and replaces recovery codes
+	EnableTwoFactor(ctx context.Context, userID int64, secret string, lastCounter int64, recoveryCodeHashes []string) error
+	// DisableTwoFactor removes TOTP seed and recovery codes of user
+	DisableTwoFactor(ctx context.Context, userID int64) error
+	// AcceptTwoFactorCounter stores time step of accepted code, returns false if code was already used
+	AcceptTwoFactorCounter(ctx context.Context, userID int64, counter int64) (bool, error)
+	// UseRecoveryCode removes recovery code of user, returns false if there is no such code
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	// RegisterTwoFactorFailure increments number of wrong codes and returns it
+	RegisterTwoFactorFailure(ctx context.Context, userID int64) (int, error)
+	// ResetTwoFactorFailures resets number of wrong codes
+	ResetTwoFactorFailures(ctx context.Context, userID int64) error
 	// Close for graceful shutdown
 	Close()
 }
@@ -27,14 +61,36 @@ type UserStorage interface {
 type SecretStorage interface {
 	// GetSecretSyncMetaByUser returns metadata for secret synchronization
 	GetSecretSyncMetaByUser(ctx context.Context, userID int64) ([]dto.SecretSyncMetadata, error)
-	// GetSecretSyncMetaByOwnerAndName returns metadata for secret synchronization by ownerID and secret name
-	GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, name string) (dto.SecretSyncMetadata, error)
+	// GetSecretSyncMetaByOwnerAndName returns metadata for secret synchronization by ownerID and blind index of secret name
+	GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, nameIndex string) (dto.SecretSyncMetadata, error)
 	// GetSecretByID returns EncodedSecret by ID
 	GetSecretByID(ctx context.Context, userID int, secretID string) (model.EncodedSecret, error)
 	// SaveEncodedSecret saves EncodedSecret
 	SaveEncodedSecret(ctx context.Context, secret model.EncodedSecret) error
 	// DeleteEncodedSecret deletes EncodedSecret
 	DeleteEncodedSecret(ctx context.Context, ownerID int, secretID string) error
+	// CountSecretsWithOutdatedKey returns number of user secrets encrypted with vault key older than keyVersion
+	CountSecretsWithOutdatedKey(ctx context.Context, ownerID int, keyVersion int64) (int, error)
+	// GetAttachmentsMetaByUser returns attachments of user without encrypted content
+	GetAttachmentsMetaByUser(ctx context.Context, userID int64) ([]model.EncodedAttachment, error)
+	// GetAttachmentByID returns EncodedAttachment with encrypted content by ID
+	GetAttachmentByID(ctx context.Context, userID int, attachmentID string) (model.EncodedAttachment, error)
+	// SaveAttachment saves EncodedAttachment
+	SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error
+	// DeleteAttachment deletes EncodedAttachment
+	DeleteAttachment(ctx context.Context, ownerID int, attachmentID string) error
+	// CountAttachmentsWithOutdatedKey returns number of user attachments encrypted with vault key older than keyVersion
+	CountAttachmentsWithOutdatedKey(ctx context.Context, ownerID int, keyVersion int64) (int, error)
+	// GetExistingChunks returns addresses of stored chunks of user among specified ones and marks them as used
+	GetExistingChunks(ctx context.Context, ownerID int, hashes []string) ([]string, error)
+	// SaveChunk saves chunk, already stored chunk is kept
+	SaveChunk(ctx context.Context, ownerID int, chunk model.SecretChunk) error
+	// SetSecretChunks replaces references of secret to chunks, returns model.ErrMissingChunks if some chunk is not stored
+	SetSecretChunks(ctx context.Context, ownerID int, secretID string, hashes []string) error
+	// GetSecretChunks passes chunks of secret starting from offset to send in order of their indexes
+	GetSecretChunks(ctx context.Context, ownerID int, secretID string, offset int64, send func(chunk model.SecretChunk) error) error
+	// DeleteUnreferencedChunks deletes chunks not referenced by any secret and not used since specified time
+	DeleteUnreferencedChunks(ctx context.Context, unusedSince time.Time) (int64, error)
 	// Close for graceful shutdown
 	Close()
 }
@@ -44,6 +100,32 @@ var (
 	ErrUserNotFound = errors.New("the specified user is not registered in the system")
 	// ErrOwnerMissmatch owner missmatch error.
 	ErrOwnerMissmatch = errors.New("requested item belongs to another user")
+	// ErrKeyRotationInProgress vault key rotation is in progress, secrets encrypted with old key are not accepted.
+	ErrKeyRotationInProgress = errors.New("vault key rotation is in progress")
+	// ErrOutdatedVaultKey secret is encrypted with vault key which was already rotated.
+	ErrOutdatedVaultKey = errors.New("secret is encrypted with outdated vault key")
+	// ErrKeyRotationNotStarted vault key rotation is not started.
+	ErrKeyRotationNotStarted = errors.New("vault key rotation is not started")
+	// ErrKeyRotationIncomplete not all secrets are re-encrypted with the new vault key.
+	ErrKeyRotationIncomplete = errors.New("not all secrets are re-encrypted with the new vault key")
+	// ErrAttachmentWithoutContent attachment is saved without encrypted content.
+	ErrAttachmentWithoutContent = errors.New("attachment content is missing")
+	// ErrTooManyTwoFactorAttempts too many wrong one-time passwords, only recovery code is accepted.
+	ErrTooManyTwoFactorAttempts = errors.New("too many wrong two-factor codes, use recovery code")
+	// ErrTwoFactorAlreadyEnabled second factor is already enabled.
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled second factor is not enabled.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+)
+
+const (
+	// maxTwoFactorAttempts number of wrong one-time passwords after which only recovery codes are accepted.
+	maxTwoFactorAttempts = 5
+	recoveryCodesCount   = 10
+	// recoveryCodeHalfSize number of random bytes in each of two groups of recovery code.
+	recoveryCodeHalfSize = 5
+	// unreferencedChunkTTL period unreferenced chunk is kept for, so chunks uploaded for secret survive until secret references them.
+	unreferencedChunkTTL = 24 * time.Hour
 )
 
 // GophkeeperServiceImpl service for EncodedSecret and User management.
@@ -51,34 +133,307 @@ type GophkeeperServiceImpl struct {
 	tokenManager  tokenManager.TokenManager
 	userStorage   UserStorage
 	secretStorage SecretStorage
+	// fakeSaltKey key salts of logins which are not registered are derived with.
+	fakeSaltKey []byte
 }
 
-// NewGophkeeperService GophkeeperServiceImpl constructor.
-func NewGophkeeperService(tokenManager tokenManager.TokenManager, userStorage UserStorage, secretStorage SecretStorage) *GophkeeperServiceImpl {
+// fakeSaltLabel domain separation label of key salts of logins which are not registered are derived with.
+const fakeSaltLabel = "gophkeeper fake salt"
+
+// NewGophkeeperService GophkeeperServiceImpl constructor, serverSecret is used to derive
+// parameters returned for logins which are not registered.
+func NewGophkeeperService(tokenManager tokenManager.TokenManager, userStorage UserStorage, secretStorage SecretStorage, serverSecret []byte) *GophkeeperServiceImpl {
+	mac := hmac.New(sha256.New, serverSecret)
+	mac.Write([]byte(fakeSaltLabel))
 	return &GophkeeperServiceImpl{
 		tokenManager:  tokenManager,
 		userStorage:   userStorage,
 		secretStorage: secretStorage,
+		fakeSaltKey:   mac.Sum(nil),
+	}
+}
+
+// GetAuthParams returns parameters client needs to derive auth key from password before login.
+// Parameters of login which is not registered are made up, so response does not reveal whether user exists.
+func (s *GophkeeperServiceImpl) GetAuthParams(ctx context.Context, login string) (model.AuthParams, error) {
+	if login == "" {
+		return model.AuthParams{}, errs.ErrorEmptyValue
+	}
+	user, err := s.userStorage.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return s.fakeAuthParams(login), nil
+		}
+		return model.AuthParams{}, err
 	}
+	return model.AuthParams{KDF: user.KDF, ZeroKnowledge: user.ZeroKnowledgeAuth}, nil
+}
+
+// fakeAuthParams returns default parameters with salt derived from login, so repeated requests get the same salt.
+func (s *GophkeeperServiceImpl) fakeAuthParams(login string) model.AuthParams {
+	mac := hmac.New(sha256.New, s.fakeSaltKey)
+	mac.Write([]byte(login))
+	return model.AuthParams{KDF: model.NewArgon2idParams(mac.Sum(nil)[:model.DefaultKDFSaltSize]), ZeroKnowledge: true}
 }
 
-// Login login user.
-func (s *GophkeeperServiceImpl) Login(ctx context.Context, login string, password string) (string, model.User, error) {
-	if login == "" || password == "" {
+// Login login user with auth key derived from password on client.
+// Legacy users whose password hash is stored prove themselves with password once,
+// then hash of password is replaced with hash of auth key.
+// If second factor is enabled only restricted token is returned, full token is issued by VerifyTwoFactor.
+func (s *GophkeeperServiceImpl) Login(ctx context.Context, login, password, authKey string) (string, model.User, error) {
+	if login == "" || authKey == "" {
 		return "", model.User{}, errs.ErrorEmptyValue
 	}
 	user, err := s.userStorage.GetUserByLogin(ctx, login)
 	if err != nil {
+		// unknown login fails the same way as wrong password
 		if errors.Is(errs.ErrItemNotFound, err) {
-			return "", model.User{}, ErrUserNotFound
+			return "", model.User{}, errs.ErrorInvalidPassword
 		}
 		return "", model.User{}, err
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password))
+	if user.ZeroKnowledgeAuth {
+		err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(authKey))
+		if err != nil {
+			return "", model.User{}, errs.ErrorInvalidPassword
+		}
+	} else {
+		user, err = s.upgradeToAuthKey(ctx, user, password, authKey)
+		if err != nil {
+			return "", model.User{}, err
+		}
+	}
+
+	return s.issueToken(user)
+}
+
+// issueToken returns token of authenticated user. If second factor is enabled only restricted token is returned
+// together with user identity, full token and user are issued by VerifyTwoFactor.
+func (s *GophkeeperServiceImpl) issueToken(user model.User) (string, model.User, error) {
+	if user.TwoFactorEnabled {
+		token, err := s.tokenManager.GenerateRestrictedToken(user.ID)
+		if err != nil {
+			return "", model.User{}, fmt.Errorf("failed to generate token : %w", err)
+		}
+		return token, model.User{ID: user.ID, Login: user.Login, TwoFactorEnabled: true}, nil
+	}
+
+	token, err := s.tokenManager.GenerateToken(user.ID)
 	if err != nil {
-		return "", model.User{}, errs.ErrorInvalidPassword
+		return "", model.User{}, fmt.Errorf("failed to generate token : %w", err)
 	}
 
+	return token, user, nil
+}
+
+// VerifyTwoFactor completes login of user with enabled second factor,
+// code is either one-time password or one of recovery codes.
+func (s *GophkeeperServiceImpl) VerifyTwoFactor(ctx context.Context, userID int, code string) (string, model.User, error) {
+	if err := s.checkTwoFactorCode(ctx, userID, code); err != nil {
+		return "", model.User{}, err
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	token, err := s.tokenManager.GenerateToken(user.ID)
+	if err != nil {
+		return "", model.User{}, fmt.Errorf("failed to generate token : %w", err)
+	}
+	return token, user, nil
+}
+
+// EnableTwoFactor sets TOTP seed generated on client, code proves that authenticator application is configured,
+// returns recovery codes which are shown to user once.
+func (s *GophkeeperServiceImpl) EnableTwoFactor(ctx context.Context, userID int, secret, code string) ([]string, error) {
+	if secret == "" || code == "" {
+		return nil, errs.ErrorEmptyValue
+	}
+	state, err := s.getTwoFactorState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	otp, err := model.NewOTPSecretItem("", "", "", "", secret, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	counter, ok := otp.Verify(code, time.Now())
+	if !ok {
+		return nil, errs.ErrorInvalidTwoFactorCode
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		recoveryCode, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		hashes = append(hashes, hashRecoveryCode(recoveryCode))
+	}
+	err = s.userStorage.EnableTwoFactor(ctx, int64(userID), otp.Secret, counter, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor removes second factor of user, code is either one-time password or one of recovery codes.
+func (s *GophkeeperServiceImpl) DisableTwoFactor(ctx context.Context, userID int, code string) (model.User, error) {
+	if err := s.checkTwoFactorCode(ctx, userID, code); err != nil {
+		return model.User{}, err
+	}
+	if err := s.userStorage.DisableTwoFactor(ctx, int64(userID)); err != nil {
+		return model.User{}, err
+	}
+	return s.GetUser(ctx, userID)
+}
+
+// checkTwoFactorCode accepts each one-time password once and each recovery code once,
+// after maxTwoFactorAttempts wrong codes in a row only recovery codes are accepted.
+func (s *GophkeeperServiceImpl) checkTwoFactorCode(ctx context.Context, userID int, code string) error {
+	if code == "" {
+		return errs.ErrorEmptyValue
+	}
+	state, err := s.getTwoFactorState(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !state.IsEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if state.FailedAttempts < maxTwoFactorAttempts {
+		otp, err := model.NewOTPSecretItem("", "", "", "", state.Secret, "", 0, 0)
+		if err != nil {
+			return err
+		}
+		if counter, ok := otp.Verify(strings.TrimSpace(code), time.Now()); ok {
+			accepted, err := s.userStorage.AcceptTwoFactorCounter(ctx, int64(userID), counter)
+			if err != nil {
+				return err
+			}
+			if accepted {
+				return nil
+			}
+		}
+	}
+
+	used, err := s.userStorage.UseRecoveryCode(ctx, int64(userID), hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if used {
+		return s.userStorage.ResetTwoFactorFailures(ctx, int64(userID))
+	}
+
+	attempts, err := s.userStorage.RegisterTwoFactorFailure(ctx, int64(userID))
+	if err != nil {
+		return err
+	}
+	if attempts >= maxTwoFactorAttempts {
+		return ErrTooManyTwoFactorAttempts
+	}
+	return errs.ErrorInvalidTwoFactorCode
+}
+
+func (s *GophkeeperServiceImpl) getTwoFactorState(ctx context.Context, userID int) (model.TwoFactorState, error) {
+	state, err := s.userStorage.GetTwoFactorState(ctx, int64(userID))
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return model.TwoFactorState{}, ErrUserNotFound
+		}
+		return model.TwoFactorState{}, err
+	}
+	return state, nil
+}
+
+// newRecoveryCode generates random recovery code of two lowercase base32 groups.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 2*recoveryCodeHalfSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	return strings.ToLower(encoding.EncodeToString(buf[:recoveryCodeHalfSize]) + "-" + encoding.EncodeToString(buf[recoveryCodeHalfSize:])), nil
+}
+
+// hashRecoveryCode returns hash of recovery code, case and spaces are ignored.
+// Recovery codes are random, so fast hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, " ", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// upgradeToAuthKey checks legacy password and replaces its hash with hash of auth key.
+func (s *GophkeeperServiceImpl) upgradeToAuthKey(ctx context.Context, user model.User, password, authKey string) (model.User, error) {
+	if password == "" {
+		return model.User{}, errs.ErrorInvalidPassword
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password))
+	if err != nil {
+		return model.User{}, errs.ErrorInvalidPassword
+	}
+	hashedAuthKey, err := bcrypt.GenerateFromPassword([]byte(authKey), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, err
+	}
+	err = s.userStorage.UpdateAuthKeyHash(ctx, user.ID, string(hashedAuthKey))
+	if err != nil {
+		return model.User{}, err
+	}
+	user.HashedPassword = string(hashedAuthKey)
+	user.ZeroKnowledgeAuth = true
+	return user, nil
+}
+
+// Register register user, authKey is derived from password on client, password itself is never sent,
+// wrappedVaultKey is vault key encrypted on client side,
+// cipherSuite is used by client to encrypt secrets of user, recoveryKit is optional.
+func (s *GophkeeperServiceImpl) Register(ctx context.Context, login string, authKey string, kdf model.KDFParams, wrappedVaultKey []byte, cipherSuite string,
+	recoveryKit model.RecoveryKit) (string, model.User, error) {
+	if login == "" || authKey == "" {
+		return "", model.User{}, errs.ErrorEmptyValue
+	}
+	var recoveryKeyHash string
+	if !recoveryKit.IsEmpty() {
+		hash, err := hashRecoveryKit(recoveryKit)
+		if err != nil {
+			return "", model.User{}, err
+		}
+		recoveryKeyHash = hash
+	}
+	if err := kdf.Validate(); err != nil {
+		return "", model.User{}, err
+	}
+	if err := model.ValidateCipherSuite(cipherSuite); err != nil {
+		return "", model.User{}, err
+	}
+
+	hashedAuthKey, err := bcrypt.GenerateFromPassword([]byte(authKey), bcrypt.DefaultCost)
+	if err != nil {
+		return "", model.User{}, err
+	}
+
+	user, err := s.userStorage.NewUser(ctx, model.User{
+		Login:             login,
+		HashedPassword:    string(hashedAuthKey),
+		ZeroKnowledgeAuth: true,
+		KDF:               kdf,
+		WrappedVaultKey:   wrappedVaultKey,
+		CipherSuite:       cipherSuite,
+
+		RecoveryKeyHash:         recoveryKeyHash,
+		RecoveryWrappedVaultKey: recoveryKit.WrappedVaultKey,
+	})
+	if err != nil {
+		return "", model.User{}, err
+	}
 	token, err := s.tokenManager.GenerateToken(user.ID)
 	if err != nil {
 		return "", model.User{}, fmt.Errorf("failed to generate token : %w", err)
@@ -87,18 +442,44 @@ func (s *GophkeeperServiceImpl) Login(ctx context.Context, login string, passwor
 	return token, user, nil
 }
 
-// Register register user.
-func (s *GophkeeperServiceImpl) Register(ctx context.Context, login string, password string) (string, model.User, error) {
-	if login == "" || password == "" {
+// ChangePassword checks auth key derived from old password and replaces it with auth key derived from new one,
+// vault key re-wrapped with the new password is stored in the same operation.
+func (s *GophkeeperServiceImpl) ChangePassword(ctx context.Context, userID int, oldAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error) {
+	if oldAuthKey == "" || newAuthKey == "" || len(wrappedVaultKey) == 0 {
 		return "", model.User{}, errs.ErrorEmptyValue
 	}
+	if err := kdf.Validate(); err != nil {
+		return "", model.User{}, err
+	}
+	user, err := s.userStorage.GetUserByID(ctx, int64(userID))
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return "", model.User{}, ErrUserNotFound
+		}
+		return "", model.User{}, err
+	}
+	if !user.ZeroKnowledgeAuth {
+		// legacy user is upgraded on login, so password can not be changed before it
+		return "", model.User{}, errs.ErrorInvalidPassword
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(oldAuthKey))
+	if err != nil {
+		return "", model.User{}, errs.ErrorInvalidPassword
+	}
+	if user.IsKeyRotationInProgress() {
+		return "", model.User{}, ErrKeyRotationInProgress
+	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedAuthKey, err := bcrypt.GenerateFromPassword([]byte(newAuthKey), bcrypt.DefaultCost)
 	if err != nil {
 		return "", model.User{}, err
 	}
+	user.HashedPassword = string(hashedAuthKey)
+	user.ZeroKnowledgeAuth = true
+	user.KDF = kdf
+	user.WrappedVaultKey = wrappedVaultKey
 
-	user, err := s.userStorage.NewUser(ctx, login, string(hashedPassword))
+	user, err = s.userStorage.UpdateUserCredentials(ctx, user)
 	if err != nil {
 		return "", model.User{}, err
 	}
@@ -110,14 +491,121 @@ func (s *GophkeeperServiceImpl) Register(ctx context.Context, login string, pass
 	return token, user, nil
 }
 
+// SetRecoveryKit stores new recovery kit of user, previous recovery key stops working.
+func (s *GophkeeperServiceImpl) SetRecoveryKit(ctx context.Context, userID int, recoveryKit model.RecoveryKit) (model.User, error) {
+	recoveryKeyHash, err := hashRecoveryKit(recoveryKit)
+	if err != nil {
+		return model.User{}, err
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.IsKeyRotationInProgress() {
+		return model.User{}, ErrKeyRotationInProgress
+	}
+	return s.userStorage.UpdateRecoveryKit(ctx, int64(userID), recoveryKeyHash, recoveryKit.WrappedVaultKey)
+}
+
+// GetRecoveryKit returns vault key wrapped with recovery key if user proves possession of recovery key.
+func (s *GophkeeperServiceImpl) GetRecoveryKit(ctx context.Context, login, recoveryAuthKey string) (model.RecoveryKit, error) {
+	user, err := s.checkRecoveryKey(ctx, login, recoveryAuthKey)
+	if err != nil {
+		return model.RecoveryKit{}, err
+	}
+	return model.RecoveryKit{WrappedVaultKey: user.RecoveryWrappedVaultKey}, nil
+}
+
+// RecoverAccount sets auth key derived from new password of user who proved possession of recovery key,
+// vault key re-wrapped with the new password is stored in the same operation. If second factor is enabled
+// only restricted token is returned, as on Login.
+func (s *GophkeeperServiceImpl) RecoverAccount(ctx context.Context, login, recoveryAuthKey, newAuthKey string, kdf model.KDFParams,
+	wrappedVaultKey []byte) (string, model.User, error) {
+	if newAuthKey == "" || len(wrappedVaultKey) == 0 {
+		return "", model.User{}, errs.ErrorEmptyValue
+	}
+	if err := kdf.Validate(); err != nil {
+		return "", model.User{}, err
+	}
+	user, err := s.checkRecoveryKey(ctx, login, recoveryAuthKey)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	if user.IsKeyRotationInProgress() {
+		return "", model.User{}, ErrKeyRotationInProgress
+	}
+
+	hashedAuthKey, err := bcrypt.GenerateFromPassword([]byte(newAuthKey), bcrypt.DefaultCost)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	user.HashedPassword = string(hashedAuthKey)
+	user.ZeroKnowledgeAuth = true
+	user.KDF = kdf
+	user.WrappedVaultKey = wrappedVaultKey
+
+	user, err = s.userStorage.UpdateUserCredentials(ctx, user)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	// recovery key replaces password, not second factor
+	return s.issueToken(user)
+}
+
+// checkRecoveryKey returns user if recovery auth key matches stored hash.
+func (s *GophkeeperServiceImpl) checkRecoveryKey(ctx context.Context, login, recoveryAuthKey string) (model.User, error) {
+	if login == "" || recoveryAuthKey == "" {
+		return model.User{}, errs.ErrorEmptyValue
+	}
+	user, err := s.userStorage.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	if user.RecoveryKeyHash == "" {
+		return model.User{}, errs.ErrorInvalidRecoveryKey
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.RecoveryKeyHash), []byte(recoveryAuthKey))
+	if err != nil {
+		return model.User{}, errs.ErrorInvalidRecoveryKey
+	}
+	return user, nil
+}
+
+// hashRecoveryKit validates recovery kit and returns hash of its auth key.
+func hashRecoveryKit(recoveryKit model.RecoveryKit) (string, error) {
+	if recoveryKit.AuthKey == "" || len(recoveryKit.WrappedVaultKey) == 0 {
+		return "", errs.ErrorEmptyValue
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(recoveryKit.AuthKey), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// GetUser returns user by ID.
+func (s *GophkeeperServiceImpl) GetUser(ctx context.Context, userID int) (model.User, error) {
+	user, err := s.userStorage.GetUserByID(ctx, int64(userID))
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return user, nil
+}
+
 // GetSecretSyncMetaByUser returns metadata for secret synchronization.
 func (s *GophkeeperServiceImpl) GetSecretSyncMetaByUser(ctx context.Context, id int64) ([]dto.SecretSyncMetadata, error) {
 	return s.secretStorage.GetSecretSyncMetaByUser(ctx, id)
 }
 
-// GetSecretSyncMetaByOwnerAndName returns metadata for secret synchronization by owner and name.
-func (s *GophkeeperServiceImpl) GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, name string) (dto.SecretSyncMetadata, error) {
-	return s.secretStorage.GetSecretSyncMetaByOwnerAndName(ctx, userID, name)
+// GetSecretSyncMetaByOwnerAndName returns metadata for secret synchronization by owner and blind index of secret name.
+func (s *GophkeeperServiceImpl) GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, nameIndex string) (dto.SecretSyncMetadata, error) {
+	return s.secretStorage.GetSecretSyncMetaByOwnerAndName(ctx, userID, nameIndex)
 }
 
 // GetSecret returns EncodedSecret by ID
@@ -134,10 +622,186 @@ func (s *GophkeeperServiceImpl) SaveEncodedSecret(ctx context.Context, ownerID i
 	if int64(ownerID) != secret.Owner {
 		return ErrOwnerMissmatch
 	}
+	if err := s.checkWriteKeyVersion(ctx, ownerID, secret.KeyVersion); err != nil {
+		return err
+	}
 
 	return s.secretStorage.SaveEncodedSecret(ctx, secret)
 }
 
+// checkWriteKeyVersion checks that item encrypted with vault key of keyVersion can be saved by user.
+func (s *GophkeeperServiceImpl) checkWriteKeyVersion(ctx context.Context, ownerID int, keyVersion int64) error {
+	user, err := s.GetUser(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if keyVersion < user.WriteKeyVersion() {
+		if user.IsKeyRotationInProgress() {
+			return ErrKeyRotationInProgress
+		}
+		return ErrOutdatedVaultKey
+	}
+	return nil
+}
+
+// GetAttachmentsMeta returns attachments of user without encrypted content.
+func (s *GophkeeperServiceImpl) GetAttachmentsMeta(ctx context.Context, userID int) ([]model.EncodedAttachment, error) {
+	return s.secretStorage.GetAttachmentsMetaByUser(ctx, int64(userID))
+}
+
+// GetAttachment returns EncodedAttachment with encrypted content by ID.
+func (s *GophkeeperServiceImpl) GetAttachment(ctx context.Context, userID int, attachmentID string) (model.EncodedAttachment, error) {
+	return s.secretStorage.GetAttachmentByID(ctx, userID, attachmentID)
+}
+
+// SaveAttachment saves EncodedAttachment, secret it belongs to must be already saved.
+func (s *GophkeeperServiceImpl) SaveAttachment(ctx context.Context, ownerID int, attachment model.EncodedAttachment) error {
+	if int64(ownerID) != attachment.Owner {
+		return ErrOwnerMissmatch
+	}
+	if len(attachment.EncodedContent) == 0 {
+		return ErrAttachmentWithoutContent
+	}
+	if err := s.checkWriteKeyVersion(ctx, ownerID, attachment.KeyVersion); err != nil {
+		return err
+	}
+	if _, err := s.secretStorage.GetSecretByID(ctx, ownerID, attachment.SecretID); err != nil {
+		return err
+	}
+	return s.secretStorage.SaveAttachment(ctx, attachment)
+}
+
+// GetMissingChunks returns addresses of chunks which are not stored yet among specified ones, each address is returned once.
+func (s *GophkeeperServiceImpl) GetMissingChunks(ctx context.Context, ownerID int, hashes []string) ([]string, error) {
+	for _, hash := range hashes {
+		if !model.IsValidChunkHash(hash) {
+			return nil, model.ErrInvalidChunkHash
+		}
+	}
+	existing, err := s.secretStorage.GetExistingChunks(ctx, ownerID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool, len(hashes))
+	for _, hash := range existing {
+		skip[hash] = true
+	}
+	missing := make([]string, 0)
+	for _, hash := range hashes {
+		if !skip[hash] {
+			skip[hash] = true
+			missing = append(missing, hash)
+		}
+	}
+	return missing, nil
+}
+
+// SaveChunks saves chunks returned by next until it returns io.EOF, returns number of saved chunks.
+// Server can not check that chunk matches its address, client checks it on download.
+func (s *GophkeeperServiceImpl) SaveChunks(ctx context.Context, ownerID int, next func() (model.SecretChunk, error)) (int64, error) {
+	var count int64
+	for {
+		chunk, err := next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if !model.IsValidChunkHash(chunk.Hash) {
+			return count, model.ErrInvalidChunkHash
+		}
+		if len(chunk.Data) == 0 {
+			return count, errs.ErrorEmptyValue
+		}
+		err = s.secretStorage.SaveChunk(ctx, ownerID, chunk)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// SetSecretChunks sets chunks file of secret consists of, all chunks must be already uploaded.
+func (s *GophkeeperServiceImpl) SetSecretChunks(ctx context.Context, ownerID int, secretID string, hashes []string) error {
+	if len(hashes) == 0 {
+		return errs.ErrorEmptyValue
+	}
+	for _, hash := range hashes {
+		if !model.IsValidChunkHash(hash) {
+			return model.ErrInvalidChunkHash
+		}
+	}
+	if _, err := s.secretStorage.GetSecretByID(ctx, ownerID, secretID); err != nil {
+		return err
+	}
+	return s.secretStorage.SetSecretChunks(ctx, ownerID, secretID, hashes)
+}
+
+// GetSecretChunks passes chunks of secret starting from offset to send.
+func (s *GophkeeperServiceImpl) GetSecretChunks(ctx context.Context, ownerID int, secretID string, offset int64, send func(chunk model.SecretChunk) error) error {
+	if offset < 0 {
+		return model.ErrChunkOutOfOrder
+	}
+	if _, err := s.secretStorage.GetSecretByID(ctx, ownerID, secretID); err != nil {
+		return err
+	}
+	return s.secretStorage.GetSecretChunks(ctx, ownerID, secretID, offset, send)
+}
+
+// CollectUnreferencedChunks deletes chunks which are not referenced by any secret for longer than unreferencedChunkTTL.
+func (s *GophkeeperServiceImpl) CollectUnreferencedChunks(ctx context.Context) error {
+	_, err := s.secretStorage.DeleteUnreferencedChunks(ctx, time.Now().Add(-unreferencedChunkTTL))
+	return err
+}
+
+// DeleteAttachment deletes EncodedAttachment by ID.
+func (s *GophkeeperServiceImpl) DeleteAttachment(ctx context.Context, ownerID int, attachmentID string) error {
+	return s.secretStorage.DeleteAttachment(ctx, ownerID, attachmentID)
+}
+
+// StartKeyRotation starts vault key rotation, new vault key is wrapped on client side.
+// If rotation is already in progress pending key is kept, so interrupted rotation can be resumed.
+func (s *GophkeeperServiceImpl) StartKeyRotation(ctx context.Context, userID int, pendingWrappedVaultKey []byte) (model.User, error) {
+	if len(pendingWrappedVaultKey) == 0 {
+		return model.User{}, errs.ErrorEmptyValue
+	}
+	user, err := s.userStorage.StartKeyRotation(ctx, int64(userID), pendingWrappedVaultKey)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// FinishKeyRotation makes pending vault key current one, all secrets and attachments must be already re-encrypted with it.
+func (s *GophkeeperServiceImpl) FinishKeyRotation(ctx context.Context, userID int) (model.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	if !user.IsKeyRotationInProgress() {
+		return model.User{}, ErrKeyRotationNotStarted
+	}
+	outdated, err := s.secretStorage.CountSecretsWithOutdatedKey(ctx, userID, user.WriteKeyVersion())
+	if err != nil {
+		return model.User{}, err
+	}
+	if outdated > 0 {
+		return model.User{}, ErrKeyRotationIncomplete
+	}
+	outdated, err = s.secretStorage.CountAttachmentsWithOutdatedKey(ctx, userID, user.WriteKeyVersion())
+	if err != nil {
+		return model.User{}, err
+	}
+	if outdated > 0 {
+		return model.User{}, ErrKeyRotationIncomplete
+	}
+	return s.userStorage.FinishKeyRotation(ctx, int64(userID))
+}
+
 // DeleteSecret delete EncodedSecret by ID.
 func (s *GophkeeperServiceImpl) DeleteSecret(ctx context.Context, ownerID int, secretID string) error {
 	return s.secretStorage.DeleteEncodedSecret(ctx, ownerID, secretID)
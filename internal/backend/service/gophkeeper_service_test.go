@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	tokenManager "github.com/apolsh/yapr-gophkeeper/internal/backend/token_manager"
+	"github.com/apolsh/yapr-gophkeeper/internal/mocks"
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testOldAuthKey      = "old auth key"
+	testNewAuthKey      = "new auth key"
+	testRecoveryAuthKey = "recovery auth key"
+)
+
+var testKDF = model.NewArgon2idParams([]byte("0123456789abcdef"))
+
+func newTestService(t *testing.T) (*GophkeeperServiceImpl, *mocks.MockUserStorage, *tokenManager.JWTTokenManager) {
+	ctrl := gomock.NewController(t)
+	userStorage := mocks.NewMockUserStorage(ctrl)
+	manager := tokenManager.NewJWTTokenManager("secret")
+	return NewGophkeeperService(manager, userStorage, mocks.NewMockSecretStorage(ctrl), []byte("server secret")), userStorage, manager
+}
+
+func newTestUser(t *testing.T, twoFactorEnabled bool) model.User {
+	hashedAuthKey, err := bcrypt.GenerateFromPassword([]byte(testOldAuthKey), bcrypt.MinCost)
+	require.NoError(t, err)
+	recoveryKeyHash, err := bcrypt.GenerateFromPassword([]byte(testRecoveryAuthKey), bcrypt.MinCost)
+	require.NoError(t, err)
+	return model.User{
+		ID:                7,
+		Login:             "login",
+		HashedPassword:    string(hashedAuthKey),
+		ZeroKnowledgeAuth: true,
+		RecoveryKeyHash:   string(recoveryKeyHash),
+		TwoFactorEnabled:  twoFactorEnabled,
+	}
+}
+
+func updateCredentials(_ context.Context, user model.User) (model.User, error) {
+	return user, nil
+}
+
+func TestChangePasswordKeepsSession(t *testing.T) {
+	for _, twoFactorEnabled := range []bool{false, true} {
+		s, userStorage, manager := newTestService(t)
+		user := newTestUser(t, twoFactorEnabled)
+		userStorage.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil)
+		userStorage.EXPECT().UpdateUserCredentials(gomock.Any(), gomock.Any()).DoAndReturn(updateCredentials)
+
+		token, updated, err := s.ChangePassword(context.Background(), int(user.ID), testOldAuthKey, testNewAuthKey, testKDF, []byte("wrapped"))
+		require.NoError(t, err)
+		userID, err := manager.ParseToken(token)
+		require.NoError(t, err, "two-factor enabled: %v", twoFactorEnabled)
+		assert.Equal(t, user.ID, userID)
+		assert.Equal(t, []byte("wrapped"), updated.WrappedVaultKey)
+	}
+}
+
+func TestRecoverAccountRequiresSecondFactor(t *testing.T) {
+	s, userStorage, manager := newTestService(t)
+	user := newTestUser(t, true)
+	userStorage.EXPECT().GetUserByLogin(gomock.Any(), user.Login).Return(user, nil)
+	userStorage.EXPECT().UpdateUserCredentials(gomock.Any(), gomock.Any()).DoAndReturn(updateCredentials)
+
+	token, recovered, err := s.RecoverAccount(context.Background(), user.Login, testRecoveryAuthKey, testNewAuthKey, testKDF, []byte("wrapped"))
+	require.NoError(t, err)
+	_, err = manager.ParseToken(token)
+	assert.ErrorIs(t, err, tokenManager.ErrTokenScopeMismatch)
+	userID, err := manager.ParseRestrictedToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+	assert.True(t, recovered.TwoFactorEnabled)
+	assert.Empty(t, recovered.WrappedVaultKey)
+}
+
+func TestRecoverAccountWithoutSecondFactor(t *testing.T) {
+	s, userStorage, manager := newTestService(t)
+	user := newTestUser(t, false)
+	userStorage.EXPECT().GetUserByLogin(gomock.Any(), user.Login).Return(user, nil)
+	userStorage.EXPECT().UpdateUserCredentials(gomock.Any(), gomock.Any()).DoAndReturn(updateCredentials)
+
+	token, recovered, err := s.RecoverAccount(context.Background(), user.Login, testRecoveryAuthKey, testNewAuthKey, testKDF, []byte("wrapped"))
+	require.NoError(t, err)
+	userID, err := manager.ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+	assert.Equal(t, []byte("wrapped"), recovered.WrappedVaultKey)
+}
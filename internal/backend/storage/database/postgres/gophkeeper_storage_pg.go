@@ -23,6 +23,8 @@ var _ service.UserStorage = (*GophkeeperStoragePG)(nil)
 
 const (
 	constraintUniqUsername = "clients_username_key"
+	// codeForeignKeyViolation SQLSTATE of foreign key violation.
+	codeForeignKeyViolation = "23503"
 )
 
 //go:embed migrations/*.sql
@@ -55,11 +57,25 @@ func NewGophkeeperStoragePG(databaseDSN string) (*GophkeeperStoragePG, error) {
 }
 
 // NewUser saves new user.
-func (s *GophkeeperStoragePG) NewUser(ctx context.Context, login string, hashedPassword string) (model.User, error) {
-	var id int64
-	timestamp := time.Now().UTC().UnixMilli()
-	q := "INSERT INTO clients (username, password, date_last_modified) VALUES ($1, $2, $3)  RETURNING client_id"
-	err := s.db.QueryRow(ctx, q, login, hashedPassword, timestamp).Scan(&id)
+func (s *GophkeeperStoragePG) NewUser(ctx context.Context, user model.User) (model.User, error) {
+	user.Timestamp = time.Now().UTC().UnixMilli()
+	q := `INSERT INTO clients (username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite,
+		recovery_key_hash, recovery_wrapped_vault_key, zero_knowledge_auth, date_last_modified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING client_id`
+	err := s.db.QueryRow(ctx, q,
+		user.Login,
+		user.HashedPassword,
+		user.KDF.Algorithm,
+		user.KDF.Salt,
+		user.KDF.Time,
+		user.KDF.Memory,
+		user.KDF.Threads,
+		user.WrappedVaultKey,
+		user.CipherSuite,
+		user.RecoveryKeyHash,
+		user.RecoveryWrappedVaultKey,
+		user.ZeroKnowledgeAuth,
+		user.Timestamp).Scan(&user.ID)
 
 	var pgErr *pgconn.PgError
 	if err != nil {
@@ -71,14 +87,66 @@ func (s *GophkeeperStoragePG) NewUser(ctx context.Context, login string, hashedP
 		return model.User{}, errs.HandleUnknownDatabaseError(err)
 	}
 
-	return model.User{ID: id, Login: login, HashedPassword: hashedPassword, Timestamp: timestamp}, nil
+	return user, nil
 }
 
 // GetUserByLogin returns user by login.
 func (s *GophkeeperStoragePG) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
-	q := "SELECT client_id, username, password FROM clients WHERE username = $1"
+	q := `SELECT client_id, username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite, key_version,
+		pending_wrapped_vault_key, recovery_key_hash, recovery_wrapped_vault_key, zero_knowledge_auth, two_factor_secret <> '', date_last_modified
+		FROM clients WHERE username = $1`
+	var user model.User
+	err := s.db.QueryRow(ctx, q, login).Scan(
+		&user.ID,
+		&user.Login,
+		&user.HashedPassword,
+		&user.KDF.Algorithm,
+		&user.KDF.Salt,
+		&user.KDF.Time,
+		&user.KDF.Memory,
+		&user.KDF.Threads,
+		&user.WrappedVaultKey,
+		&user.CipherSuite,
+		&user.KeyVersion,
+		&user.PendingWrappedVaultKey,
+		&user.RecoveryKeyHash,
+		&user.RecoveryWrappedVaultKey,
+		&user.ZeroKnowledgeAuth,
+		&user.TwoFactorEnabled,
+		&user.Timestamp)
+	if err != nil {
+		if errors.Is(pgx.ErrNoRows, err) {
+			return model.User{}, errs.ErrItemNotFound
+		}
+		return model.User{}, errs.HandleUnknownDatabaseError(err)
+	}
+	return user, nil
+}
+
+// GetUserByID returns user by ID.
+func (s *GophkeeperStoragePG) GetUserByID(ctx context.Context, id int64) (model.User, error) {
+	q := `SELECT client_id, username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite, key_version,
+		pending_wrapped_vault_key, recovery_key_hash, recovery_wrapped_vault_key, zero_knowledge_auth, two_factor_secret <> '', date_last_modified
+		FROM clients WHERE client_id = $1`
 	var user model.User
-	err := s.db.QueryRow(ctx, q, login).Scan(&user.ID, &user.Login, &user.HashedPassword)
+	err := s.db.QueryRow(ctx, q, id).Scan(
+		&user.ID,
+		&user.Login,
+		&user.HashedPassword,
+		&user.KDF.Algorithm,
+		&user.KDF.Salt,
+		&user.KDF.Time,
+		&user.KDF.Memory,
+		&user.KDF.Threads,
+		&user.WrappedVaultKey,
+		&user.CipherSuite,
+		&user.KeyVersion,
+		&user.PendingWrappedVaultKey,
+		&user.RecoveryKeyHash,
+		&user.RecoveryWrappedVaultKey,
+		&user.ZeroKnowledgeAuth,
+		&user.TwoFactorEnabled,
+		&user.Timestamp)
 	if err != nil {
 		if errors.Is(pgx.ErrNoRows, err) {
 			return model.User{}, errs.ErrItemNotFound
@@ -88,6 +156,208 @@ func (s *GophkeeperStoragePG) GetUserByLogin(ctx context.Context, login string)
 	return user, nil
 }
 
+// UpdateUserCredentials atomically replaces user password hash, KDF parameters and wrapped vault key.
+func (s *GophkeeperStoragePG) UpdateUserCredentials(ctx context.Context, user model.User) (model.User, error) {
+	user.Timestamp = time.Now().UTC().UnixMilli()
+	q := `UPDATE clients SET password = $1, kdf_algorithm = $2, kdf_salt = $3, kdf_time = $4, kdf_memory = $5, kdf_threads = $6,
+		wrapped_vault_key = $7, zero_knowledge_auth = $8, date_last_modified = $9 WHERE client_id = $10`
+	tag, err := s.db.Exec(ctx, q,
+		user.HashedPassword,
+		user.KDF.Algorithm,
+		user.KDF.Salt,
+		user.KDF.Time,
+		user.KDF.Memory,
+		user.KDF.Threads,
+		user.WrappedVaultKey,
+		user.ZeroKnowledgeAuth,
+		user.Timestamp,
+		user.ID)
+	if err != nil {
+		return model.User{}, errs.HandleUnknownDatabaseError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.User{}, errs.ErrItemNotFound
+	}
+	return user, nil
+}
+
+// UpdateAuthKeyHash replaces hash of legacy password with hash of auth key derived on client,
+// encryption keys are not changed so timestamp of credentials stays the same.
+func (s *GophkeeperStoragePG) UpdateAuthKeyHash(ctx context.Context, userID int64, authKeyHash string) error {
+	q := "UPDATE clients SET password = $1, zero_knowledge_auth = TRUE WHERE client_id = $2"
+	tag, err := s.db.Exec(ctx, q, authKeyHash, userID)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrItemNotFound
+	}
+	return nil
+}
+
+// StartKeyRotation sets pending wrapped vault key if key rotation is not in progress yet, returns updated user.
+// Time of modification is updated, so other devices learn that rotation is started from change events.
+func (s *GophkeeperStoragePG) StartKeyRotation(ctx context.Context, userID int64, pendingWrappedVaultKey []byte) (model.User, error) {
+	q := `UPDATE clients SET pending_wrapped_vault_key = $1, date_last_modified = $2
+		WHERE client_id = $3 AND pending_wrapped_vault_key IS NULL`
+	_, err := s.db.Exec(ctx, q, pendingWrappedVaultKey, time.Now().UTC().UnixMilli(), userID)
+	if err != nil {
+		return model.User{}, errs.HandleUnknownDatabaseError(err)
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
+// inTx runs function in transaction, transaction is committed if function succeeds.
+func (s *GophkeeperStoragePG) inTx(ctx context.Context, f func(tx pgx.Tx) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// rollback of committed transaction is no-op
+		_ = tx.Rollback(ctx)
+	}()
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetTwoFactorState returns second factor of user.
+func (s *GophkeeperStoragePG) GetTwoFactorState(ctx context.Context, userID int64) (model.TwoFactorState, error) {
+	q := "SELECT two_factor_secret, two_factor_last_counter, two_factor_failed_attempts FROM clients WHERE client_id = $1"
+	var state model.TwoFactorState
+	err := s.db.QueryRow(ctx, q, userID).Scan(&state.Secret, &state.LastCounter, &state.FailedAttempts)
+	if err != nil {
+		if errors.Is(pgx.ErrNoRows, err) {
+			return model.TwoFactorState{}, errs.ErrItemNotFound
+		}
+		return model.TwoFactorState{}, errs.HandleUnknownDatabaseError(err)
+	}
+	return state, nil
+}
+
+// EnableTwoFactor sets TOTP seed of user and replaces recovery codes in the same transaction.
+func (s *GophkeeperStoragePG) EnableTwoFactor(ctx context.Context, userID int64, secret string, lastCounter int64, recoveryCodeHashes []string) error {
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		q := `UPDATE clients SET two_factor_secret = $1, two_factor_last_counter = $2, two_factor_failed_attempts = 0
+			WHERE client_id = $3`
+		tag, err := tx.Exec(ctx, q, secret, lastCounter, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errs.ErrItemNotFound
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM two_factor_recovery_codes WHERE client_id = $1", userID)
+		if err != nil {
+			return err
+		}
+		for _, hash := range recoveryCodeHashes {
+			_, err = tx.Exec(ctx, "INSERT INTO two_factor_recovery_codes (client_id, code_hash) VALUES ($1, $2)", userID, hash)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(errs.ErrItemNotFound, err) {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return err
+}
+
+// DisableTwoFactor removes TOTP seed and recovery codes of user.
+func (s *GophkeeperStoragePG) DisableTwoFactor(ctx context.Context, userID int64) error {
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		q := `UPDATE clients SET two_factor_secret = '', two_factor_last_counter = 0, two_factor_failed_attempts = 0
+			WHERE client_id = $1`
+		_, err := tx.Exec(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM two_factor_recovery_codes WHERE client_id = $1", userID)
+		return err
+	})
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return nil
+}
+
+// AcceptTwoFactorCounter stores time step of accepted code and resets failed attempts,
+// returns false if code of the same or later time step was already accepted.
+func (s *GophkeeperStoragePG) AcceptTwoFactorCounter(ctx context.Context, userID int64, counter int64) (bool, error) {
+	q := `UPDATE clients SET two_factor_last_counter = $1, two_factor_failed_attempts = 0
+		WHERE client_id = $2 AND two_factor_last_counter < $1`
+	tag, err := s.db.Exec(ctx, q, counter, userID)
+	if err != nil {
+		return false, errs.HandleUnknownDatabaseError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode removes recovery code of user, returns false if there is no such code.
+func (s *GophkeeperStoragePG) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	tag, err := s.db.Exec(ctx, "DELETE FROM two_factor_recovery_codes WHERE client_id = $1 AND code_hash = $2", userID, codeHash)
+	if err != nil {
+		return false, errs.HandleUnknownDatabaseError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RegisterTwoFactorFailure increments number of wrong codes and returns it.
+func (s *GophkeeperStoragePG) RegisterTwoFactorFailure(ctx context.Context, userID int64) (int, error) {
+	q := "UPDATE clients SET two_factor_failed_attempts = two_factor_failed_attempts + 1 WHERE client_id = $1 RETURNING two_factor_failed_attempts"
+	var attempts int
+	err := s.db.QueryRow(ctx, q, userID).Scan(&attempts)
+	if err != nil {
+		if errors.Is(pgx.ErrNoRows, err) {
+			return 0, errs.ErrItemNotFound
+		}
+		return 0, errs.HandleUnknownDatabaseError(err)
+	}
+	return attempts, nil
+}
+
+// ResetTwoFactorFailures resets number of wrong codes.
+func (s *GophkeeperStoragePG) ResetTwoFactorFailures(ctx context.Context, userID int64) error {
+	_, err := s.db.Exec(ctx, "UPDATE clients SET two_factor_failed_attempts = 0 WHERE client_id = $1", userID)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return nil
+}
+
+// UpdateRecoveryKit replaces hash of recovery key and vault key wrapped with recovery key.
+func (s *GophkeeperStoragePG) UpdateRecoveryKit(ctx context.Context, userID int64, recoveryKeyHash string, recoveryWrappedVaultKey []byte) (model.User, error) {
+	q := "UPDATE clients SET recovery_key_hash = $1, recovery_wrapped_vault_key = $2, date_last_modified = $3 WHERE client_id = $4"
+	tag, err := s.db.Exec(ctx, q, recoveryKeyHash, recoveryWrappedVaultKey, time.Now().UTC().UnixMilli(), userID)
+	if err != nil {
+		return model.User{}, errs.HandleUnknownDatabaseError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.User{}, errs.ErrItemNotFound
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
+// FinishKeyRotation replaces wrapped vault key with pending one and increments key version,
+// recovery kit wraps the old vault key, so it is removed.
+func (s *GophkeeperStoragePG) FinishKeyRotation(ctx context.Context, userID int64) (model.User, error) {
+	q := `UPDATE clients SET wrapped_vault_key = pending_wrapped_vault_key, pending_wrapped_vault_key = NULL,
+		key_version = key_version + 1, recovery_key_hash = '', recovery_wrapped_vault_key = NULL, date_last_modified = $1
+		WHERE client_id = $2 AND pending_wrapped_vault_key IS NOT NULL`
+	tag, err := s.db.Exec(ctx, q, time.Now().UTC().UnixMilli(), userID)
+	if err != nil {
+		return model.User{}, errs.HandleUnknownDatabaseError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.User{}, errs.ErrItemNotFound
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
 // GetSecretSyncMetaByUser returns metadata for secret synchronization.
 func (s *GophkeeperStoragePG) GetSecretSyncMetaByUser(ctx context.Context, userID int64) ([]dto.SecretSyncMetadata, error) {
 	q := "SELECT secret_id, hash, date_last_modified FROM secrets WHERE owner = $1"
@@ -110,11 +380,12 @@ func (s *GophkeeperStoragePG) GetSecretSyncMetaByUser(ctx context.Context, userI
 	return secretSyncMetas, nil
 }
 
-func (s *GophkeeperStoragePG) GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, name string) (dto.SecretSyncMetadata, error) {
-	q := "SELECT secret_id, hash, date_last_modified FROM secrets WHERE owner = $1 AND name = $2"
+// GetSecretSyncMetaByOwnerAndName returns metadata for secret synchronization by ownerID and blind index of secret name.
+func (s *GophkeeperStoragePG) GetSecretSyncMetaByOwnerAndName(ctx context.Context, userID int, nameIndex string) (dto.SecretSyncMetadata, error) {
+	q := "SELECT secret_id, hash, date_last_modified FROM secrets WHERE owner = $1 AND name_index = $2"
 
 	var secretSyncMeta dto.SecretSyncMetadata
-	err := s.db.QueryRow(ctx, q, userID, name).Scan(&secretSyncMeta.ID, &secretSyncMeta.Hash, &secretSyncMeta.Timestamp)
+	err := s.db.QueryRow(ctx, q, userID, nameIndex).Scan(&secretSyncMeta.ID, &secretSyncMeta.Hash, &secretSyncMeta.Timestamp)
 	if err != nil {
 		return secretSyncMeta, errs.HandleUnknownDatabaseError(err)
 	}
@@ -123,7 +394,9 @@ func (s *GophkeeperStoragePG) GetSecretSyncMetaByOwnerAndName(ctx context.Contex
 
 // GetSecretByID returns EncodedSecret by ID.
 func (s *GophkeeperStoragePG) GetSecretByID(ctx context.Context, userID int, secretID string) (model.EncodedSecret, error) {
-	q := "SELECT secret_id, owner, name, hash, description, enc_data, type, date_last_modified FROM secrets WHERE secret_id = $1 AND owner = $2"
+	q := `SELECT secret_id, owner, name, name_index, hash, description, enc_data, type, key_version, date_last_modified,
+		folder, folder_index, tags, tag_indexes
+		FROM secrets WHERE secret_id = $1 AND owner = $2`
 
 	var encSecret model.EncodedSecret
 
@@ -131,11 +404,17 @@ func (s *GophkeeperStoragePG) GetSecretByID(ctx context.Context, userID int, sec
 		&encSecret.ID,
 		&encSecret.Owner,
 		&encSecret.Name,
+		&encSecret.NameIndex,
 		&encSecret.Hash,
 		&encSecret.Description,
 		&encSecret.EncodedContent,
 		&encSecret.Type,
-		&encSecret.Timestamp)
+		&encSecret.KeyVersion,
+		&encSecret.Timestamp,
+		&encSecret.Folder,
+		&encSecret.FolderIndex,
+		&encSecret.Tags,
+		&encSecret.TagIndexes)
 
 	if err != nil {
 		if errors.Is(pgx.ErrNoRows, err) {
@@ -146,11 +425,22 @@ func (s *GophkeeperStoragePG) GetSecretByID(ctx context.Context, userID int, sec
 	return encSecret, nil
 }
 
-// SaveEncodedSecret saves new EncodedSecret.
+// SaveEncodedSecret saves new EncodedSecret or replaces existing one with the same ID.
 func (s *GophkeeperStoragePG) SaveEncodedSecret(ctx context.Context, secret model.EncodedSecret) error {
-	q := "INSERT INTO secrets (secret_id, owner, name, hash, description, enc_data, type, date_last_modified) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	q := `INSERT INTO secrets (secret_id, owner, name, name_index, hash, description, enc_data, type, key_version, date_last_modified,
+		folder, folder_index, tags, tag_indexes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (secret_id) DO UPDATE SET name = excluded.name, name_index = excluded.name_index, hash = excluded.hash, description = excluded.description,
+		enc_data = excluded.enc_data, type = excluded.type, key_version = excluded.key_version, date_last_modified = excluded.date_last_modified,
+		folder = excluded.folder, folder_index = excluded.folder_index, tags = excluded.tags, tag_indexes = excluded.tag_indexes
+		WHERE secrets.owner = excluded.owner`
 
-	_, err := s.db.Exec(ctx, q, secret.ID, secret.Owner, secret.Name, secret.Hash, secret.Description, secret.EncodedContent, secret.Type, secret.Timestamp)
+	tagIndexes := secret.TagIndexes
+	if tagIndexes == nil {
+		tagIndexes = []string{}
+	}
+	_, err := s.db.Exec(ctx, q, secret.ID, secret.Owner, secret.Name, secret.NameIndex, secret.Hash, secret.Description, secret.EncodedContent, secret.Type,
+		secret.KeyVersion, secret.Timestamp, secret.Folder, secret.FolderIndex, secret.Tags, tagIndexes)
 	if err != nil {
 		return errs.HandleUnknownDatabaseError(err)
 	}
@@ -169,6 +459,205 @@ func (s *GophkeeperStoragePG) DeleteEncodedSecret(ctx context.Context, ownerID i
 	return nil
 }
 
+// CountSecretsWithOutdatedKey returns number of user secrets encrypted with vault key older than keyVersion.
+func (s *GophkeeperStoragePG) CountSecretsWithOutdatedKey(ctx context.Context, ownerID int, keyVersion int64) (int, error) {
+	q := "SELECT COUNT(*) FROM secrets WHERE owner = $1 AND key_version < $2"
+	var count int
+	err := s.db.QueryRow(ctx, q, ownerID, keyVersion).Scan(&count)
+	if err != nil {
+		return 0, errs.HandleUnknownDatabaseError(err)
+	}
+	return count, nil
+}
+
+// GetAttachmentsMetaByUser returns attachments of user without encrypted content.
+func (s *GophkeeperStoragePG) GetAttachmentsMetaByUser(ctx context.Context, userID int64) ([]model.EncodedAttachment, error) {
+	q := `SELECT attachment_id, secret_id, owner, name, size, hash, key_version, date_last_modified
+		FROM attachments WHERE owner = $1`
+
+	rows, err := s.db.Query(ctx, q, userID)
+	if err != nil {
+		return nil, errs.HandleUnknownDatabaseError(err)
+	}
+	defer rows.Close()
+
+	attachments := make([]model.EncodedAttachment, 0)
+	var attachment model.EncodedAttachment
+	for rows.Next() {
+		err := rows.Scan(&attachment.ID, &attachment.SecretID, &attachment.Owner, &attachment.Name, &attachment.Size,
+			&attachment.Hash, &attachment.KeyVersion, &attachment.Timestamp)
+		if err != nil {
+			return nil, errs.HandleUnknownDatabaseError(err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentByID returns EncodedAttachment with encrypted content by ID.
+func (s *GophkeeperStoragePG) GetAttachmentByID(ctx context.Context, userID int, attachmentID string) (model.EncodedAttachment, error) {
+	q := `SELECT attachment_id, secret_id, owner, name, size, enc_data, hash, key_version, date_last_modified
+		FROM attachments WHERE attachment_id = $1 AND owner = $2`
+
+	var attachment model.EncodedAttachment
+	err := s.db.QueryRow(ctx, q, attachmentID, userID).Scan(&attachment.ID, &attachment.SecretID, &attachment.Owner, &attachment.Name,
+		&attachment.Size, &attachment.EncodedContent, &attachment.Hash, &attachment.KeyVersion, &attachment.Timestamp)
+	if err != nil {
+		if errors.Is(pgx.ErrNoRows, err) {
+			return model.EncodedAttachment{}, errs.ErrItemNotFound
+		}
+		return model.EncodedAttachment{}, errs.HandleUnknownDatabaseError(err)
+	}
+	return attachment, nil
+}
+
+// SaveAttachment saves new EncodedAttachment or replaces existing one with the same ID.
+func (s *GophkeeperStoragePG) SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error {
+	q := `INSERT INTO attachments (attachment_id, secret_id, owner, name, size, enc_data, hash, key_version, date_last_modified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (attachment_id) DO UPDATE SET name = excluded.name, size = excluded.size, enc_data = excluded.enc_data,
+		hash = excluded.hash, key_version = excluded.key_version, date_last_modified = excluded.date_last_modified
+		WHERE attachments.owner = excluded.owner AND attachments.secret_id = excluded.secret_id`
+
+	_, err := s.db.Exec(ctx, q, attachment.ID, attachment.SecretID, attachment.Owner, attachment.Name, attachment.Size,
+		attachment.EncodedContent, attachment.Hash, attachment.KeyVersion, attachment.Timestamp)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+
+	return nil
+}
+
+// DeleteAttachment deletes EncodedAttachment.
+func (s *GophkeeperStoragePG) DeleteAttachment(ctx context.Context, ownerID int, attachmentID string) error {
+	q := "DELETE FROM attachments WHERE attachment_id = $1 AND owner = $2"
+	_, err := s.db.Exec(ctx, q, attachmentID, ownerID)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+
+	return nil
+}
+
+// CountAttachmentsWithOutdatedKey returns number of user attachments encrypted with vault key older than keyVersion.
+func (s *GophkeeperStoragePG) CountAttachmentsWithOutdatedKey(ctx context.Context, ownerID int, keyVersion int64) (int, error) {
+	q := "SELECT COUNT(*) FROM attachments WHERE owner = $1 AND key_version < $2"
+	var count int
+	err := s.db.QueryRow(ctx, q, ownerID, keyVersion).Scan(&count)
+	if err != nil {
+		return 0, errs.HandleUnknownDatabaseError(err)
+	}
+	return count, nil
+}
+
+// GetExistingChunks returns addresses of stored chunks among specified ones, found chunks are marked as used,
+// so garbage collector does not remove them before secret references them.
+func (s *GophkeeperStoragePG) GetExistingChunks(ctx context.Context, ownerID int, hashes []string) ([]string, error) {
+	q := "UPDATE chunks SET date_last_used = now() WHERE owner = $1 AND chunk_hash = ANY($2) RETURNING chunk_hash"
+	rows, err := s.db.Query(ctx, q, ownerID, hashes)
+	if err != nil {
+		return nil, errs.HandleUnknownDatabaseError(err)
+	}
+	defer rows.Close()
+
+	existing := make([]string, 0, len(hashes))
+	for rows.Next() {
+		var hash string
+		err := rows.Scan(&hash)
+		if err != nil {
+			return nil, errs.HandleUnknownDatabaseError(err)
+		}
+		existing = append(existing, hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.HandleUnknownDatabaseError(err)
+	}
+	return existing, nil
+}
+
+// SaveChunk saves chunk, chunk which is already stored is kept and marked as used.
+func (s *GophkeeperStoragePG) SaveChunk(ctx context.Context, ownerID int, chunk model.SecretChunk) error {
+	q := `INSERT INTO chunks (owner, chunk_hash, data) VALUES ($1, $2, $3)
+		ON CONFLICT (owner, chunk_hash) DO UPDATE SET date_last_used = now()`
+	_, err := s.db.Exec(ctx, q, ownerID, chunk.Hash, chunk.Data)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return nil
+}
+
+// SetSecretChunks replaces references of secret to chunks, reference counters of chunks are updated by trigger.
+// Fixed-size chunks of file uploaded before content-defined chunking are replaced too.
+func (s *GophkeeperStoragePG) SetSecretChunks(ctx context.Context, ownerID int, secretID string, hashes []string) error {
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM secret_chunk_refs WHERE secret_id = $1", secretID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM legacy_secret_chunks WHERE secret_id = $1", secretID)
+		if err != nil {
+			return err
+		}
+		q := `INSERT INTO secret_chunk_refs (secret_id, chunk_index, owner, chunk_hash)
+			SELECT $1, refs.ord - 1, $2, refs.hash FROM unnest($3::VARCHAR[]) WITH ORDINALITY AS refs (hash, ord)`
+		_, err = tx.Exec(ctx, q, secretID, ownerID, hashes)
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == codeForeignKeyViolation {
+			return model.ErrMissingChunks
+		}
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return nil
+}
+
+// GetSecretChunks passes chunks of secret starting from offset to send in order of their indexes, chunks are not loaded into memory at once.
+// Fixed-size chunks of file uploaded before content-defined chunking are passed without address.
+func (s *GophkeeperStoragePG) GetSecretChunks(ctx context.Context, ownerID int, secretID string, offset int64, send func(chunk model.SecretChunk) error) error {
+	q := `SELECT r.chunk_index, r.chunk_hash, c.data FROM secret_chunk_refs r
+		JOIN chunks c ON c.owner = r.owner AND c.chunk_hash = r.chunk_hash
+		WHERE r.secret_id = $1 AND r.owner = $2 AND r.chunk_index >= $3
+		UNION ALL
+		SELECT chunk_index, '', data FROM legacy_secret_chunks
+		WHERE secret_id = $1 AND owner = $2 AND chunk_index >= $3
+		ORDER BY 1`
+	rows, err := s.db.Query(ctx, q, secretID, ownerID, offset)
+	if err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		chunk := model.SecretChunk{SecretID: secretID}
+		err := rows.Scan(&chunk.Index, &chunk.Hash, &chunk.Data)
+		if err != nil {
+			return errs.HandleUnknownDatabaseError(err)
+		}
+		err = send(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errs.HandleUnknownDatabaseError(err)
+	}
+	return nil
+}
+
+// DeleteUnreferencedChunks deletes chunks which are not referenced by any secret and not used since specified time,
+// returns number of deleted chunks.
+func (s *GophkeeperStoragePG) DeleteUnreferencedChunks(ctx context.Context, unusedSince time.Time) (int64, error) {
+	q := "DELETE FROM chunks WHERE ref_count = 0 AND date_last_used < $1"
+	tag, err := s.db.Exec(ctx, q, unusedSince)
+	if err != nil {
+		return 0, errs.HandleUnknownDatabaseError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
 // Close closes database connection.
 func (s *GophkeeperStoragePG) Close() {
 	s.db.Close()
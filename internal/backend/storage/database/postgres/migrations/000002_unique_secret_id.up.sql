@@ -0,0 +1,3 @@
+BEGIN;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_secret_id ON secrets (secret_id);
+COMMIT;
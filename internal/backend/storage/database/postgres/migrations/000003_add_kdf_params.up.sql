@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS kdf_algorithm VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS kdf_salt bytea;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS kdf_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS kdf_memory BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS kdf_threads SMALLINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS wrapped_vault_key bytea;
+COMMIT;
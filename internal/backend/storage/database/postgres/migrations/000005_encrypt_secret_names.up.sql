@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE secrets ALTER COLUMN name TYPE TEXT;
+ALTER TABLE secrets ALTER COLUMN description TYPE TEXT;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS name_index VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS secrets_owner_name_index ON secrets (owner, name_index);
+COMMIT;
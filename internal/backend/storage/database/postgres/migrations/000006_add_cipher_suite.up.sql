@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS cipher_suite VARCHAR(30) NOT NULL DEFAULT '';
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS key_version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS pending_wrapped_vault_key bytea;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS key_version BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS recovery_key_hash VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS recovery_wrapped_vault_key bytea;
+COMMIT;
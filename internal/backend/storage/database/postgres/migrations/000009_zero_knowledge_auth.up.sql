@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS zero_knowledge_auth BOOLEAN NOT NULL DEFAULT FALSE;
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS two_factor_last_counter BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS two_factor_failed_attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    client_id BIGINT NOT NULL REFERENCES clients (client_id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    PRIMARY KEY (client_id, code_hash)
+);
+COMMIT;
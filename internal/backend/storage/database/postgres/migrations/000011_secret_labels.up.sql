@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS folder TEXT NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS folder_index VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS tag_indexes TEXT[] NOT NULL DEFAULT '{}';
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS attachments (
+    attachment_id VARCHAR(36) PRIMARY KEY,
+    secret_id VARCHAR(36) NOT NULL REFERENCES secrets (secret_id) ON DELETE CASCADE,
+    owner BIGINT NOT NULL REFERENCES clients (client_id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    enc_data bytea NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    key_version BIGINT NOT NULL DEFAULT 0,
+    date_last_modified BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS attachments_owner ON attachments (owner);
+COMMIT;
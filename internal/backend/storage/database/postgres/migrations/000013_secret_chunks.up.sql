@@ -0,0 +1,9 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS secret_chunks (
+    secret_id VARCHAR(36) NOT NULL REFERENCES secrets (secret_id) ON DELETE CASCADE,
+    owner BIGINT NOT NULL REFERENCES clients (client_id) ON DELETE CASCADE,
+    chunk_index BIGINT NOT NULL,
+    data bytea NOT NULL,
+    PRIMARY KEY (secret_id, chunk_index)
+);
+COMMIT;
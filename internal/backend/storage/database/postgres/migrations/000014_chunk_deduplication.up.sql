@@ -0,0 +1,35 @@
+BEGIN;
+-- chunks are addressed by content now, file is stored as list of references to shared chunks.
+-- server can not address chunks of files uploaded before, so they are kept and served as they are
+ALTER TABLE IF EXISTS secret_chunks RENAME TO legacy_secret_chunks;
+CREATE TABLE IF NOT EXISTS chunks (
+    owner BIGINT NOT NULL REFERENCES clients (client_id) ON DELETE CASCADE,
+    chunk_hash VARCHAR(64) NOT NULL,
+    data bytea NOT NULL,
+    ref_count BIGINT NOT NULL DEFAULT 0,
+    date_last_used TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (owner, chunk_hash)
+);
+CREATE INDEX IF NOT EXISTS chunks_unreferenced ON chunks (date_last_used) WHERE ref_count = 0;
+CREATE TABLE IF NOT EXISTS secret_chunk_refs (
+    secret_id VARCHAR(36) NOT NULL REFERENCES secrets (secret_id) ON DELETE CASCADE,
+    chunk_index BIGINT NOT NULL,
+    owner BIGINT NOT NULL,
+    chunk_hash VARCHAR(64) NOT NULL,
+    PRIMARY KEY (secret_id, chunk_index),
+    FOREIGN KEY (owner, chunk_hash) REFERENCES chunks (owner, chunk_hash)
+);
+-- references are also removed by cascade delete of secrets, so counter is maintained by trigger
+CREATE OR REPLACE FUNCTION count_chunk_refs() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chunks SET ref_count = ref_count + 1 WHERE owner = NEW.owner AND chunk_hash = NEW.chunk_hash;
+        RETURN NEW;
+    END IF;
+    UPDATE chunks SET ref_count = ref_count - 1, date_last_used = now() WHERE owner = OLD.owner AND chunk_hash = OLD.chunk_hash;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER secret_chunk_refs_count AFTER INSERT OR DELETE ON secret_chunk_refs
+    FOR EACH ROW EXECUTE FUNCTION count_chunk_refs();
+COMMIT;
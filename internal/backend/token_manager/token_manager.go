@@ -11,11 +11,24 @@ import (
 type TokenManager interface {
 	GenerateToken(id int64) (string, error)
 	ParseToken(tokenString string) (int64, error)
+	GenerateRestrictedToken(id int64) (string, error)
+	ParseRestrictedToken(tokenString string) (int64, error)
 }
 
+const (
+	tokenTTL = 1 * time.Hour
+	// restrictedTokenTTL lifetime of token which only allows to pass second factor of login.
+	restrictedTokenTTL = 5 * time.Minute
+)
+
+// ErrTokenScopeMismatch appears when restricted token is used instead of full one or vice versa.
+var ErrTokenScopeMismatch = errors.New("token is not valid for this operation")
+
 type jwtTokenClaims struct {
 	jwt.RegisteredClaims
 	UserID int64 `json:"user_id"`
+	// Restricted token is issued after password step of two-factor login.
+	Restricted bool `json:"restricted,omitempty"`
 }
 
 // JWTTokenManager token manager jwt implementation.
@@ -30,21 +43,40 @@ func NewJWTTokenManager(secretKey string) *JWTTokenManager {
 
 // GenerateToken generates new token.
 func (s *JWTTokenManager) GenerateToken(id int64) (string, error) {
+	return s.generateToken(id, tokenTTL, false)
+}
+
+// GenerateRestrictedToken generates short-lived token which is accepted only by second step of two-factor login.
+func (s *JWTTokenManager) GenerateRestrictedToken(id int64) (string, error) {
+	return s.generateToken(id, restrictedTokenTTL, true)
+}
+
+// ParseToken parses generated token, restricted tokens are rejected.
+func (s *JWTTokenManager) ParseToken(tokenString string) (int64, error) {
+	return s.parseToken(tokenString, false)
+}
+
+// ParseRestrictedToken parses restricted token, full tokens are rejected.
+func (s *JWTTokenManager) ParseRestrictedToken(tokenString string) (int64, error) {
+	return s.parseToken(tokenString, true)
+}
+
+func (s *JWTTokenManager) generateToken(id int64, ttl time.Duration, restricted bool) (string, error) {
 	now := time.Now()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwtTokenClaims{
 		jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 		id,
+		restricted,
 	})
 
 	return token.SignedString([]byte(s.jwtSecretKey))
 }
 
-// ParseToken parses generated token.
-func (s *JWTTokenManager) ParseToken(tokenString string) (int64, error) {
+func (s *JWTTokenManager) parseToken(tokenString string, restricted bool) (int64, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwtTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
@@ -59,5 +91,8 @@ func (s *JWTTokenManager) ParseToken(tokenString string) (int64, error) {
 	if !ok {
 		return 0, errors.New("invalid token claims type")
 	}
+	if claims.Restricted != restricted {
+		return 0, ErrTokenScopeMismatch
+	}
 	return claims.UserID, nil
 }
@@ -3,6 +3,7 @@ package grpc_client
 import (
 	"context"
 	"errors"
+	"io"
 
 	"github.com/apolsh/yapr-gophkeeper/internal/client/controller"
 	pb "github.com/apolsh/yapr-gophkeeper/internal/grpc/proto"
@@ -69,9 +70,19 @@ func (c *GophkeeperGRPCClient) SetAuthTokenForRequests(token string) {
 	c.authConfig.token = token
 }
 
-// Login login user.
-func (c *GophkeeperGRPCClient) Login(ctx context.Context, login, password string) (string, model.User, error) {
-	authMeta, err := c.client.Login(ctx, &pb.Credentials{Login: login, Password: password})
+// GetAuthParams returns parameters for auth key derivation before login.
+func (c *GophkeeperGRPCClient) GetAuthParams(ctx context.Context, login string) (model.AuthParams, error) {
+	params, err := c.client.GetAuthParams(ctx, &pb.Name{Name: login})
+	if err != nil {
+		log.Error(err)
+		return model.AuthParams{}, handleStatusError(err)
+	}
+	return pb.AuthParamsFromProto(params), nil
+}
+
+// Login login user with auth key, password is sent only by legacy users to upgrade their account.
+func (c *GophkeeperGRPCClient) Login(ctx context.Context, login, password, authKey string) (string, model.User, error) {
+	authMeta, err := c.client.Login(ctx, &pb.Credentials{Login: login, Password: password, AuthKey: authKey})
 	if err != nil {
 		log.Error(err)
 		return "", model.User{}, handleStatusError(err)
@@ -80,9 +91,17 @@ func (c *GophkeeperGRPCClient) Login(ctx context.Context, login, password string
 	return authMeta.GetToken(), user, nil
 }
 
-// Register registers user.
-func (c *GophkeeperGRPCClient) Register(ctx context.Context, login, password string) (string, model.User, error) {
-	authMeta, err := c.client.Register(ctx, &pb.Credentials{Login: login, Password: password})
+// Register registers user with auth key derived from password, recoveryKit is optional.
+func (c *GophkeeperGRPCClient) Register(ctx context.Context, login, authKey string, kdf model.KDFParams, wrappedVaultKey []byte, cipherSuite string,
+	recoveryKit model.RecoveryKit) (string, model.User, error) {
+	authMeta, err := c.client.Register(ctx, &pb.Credentials{
+		Login:           login,
+		AuthKey:         authKey,
+		KdfParams:       pb.NewProtoKDFParams(kdf),
+		WrappedVaultKey: wrappedVaultKey,
+		CipherSuite:     cipherSuite,
+		RecoveryKit:     pb.NewProtoRecoveryKit(recoveryKit),
+	})
 	if err != nil {
 		log.Error(err)
 		return "", model.User{}, handleStatusError(err)
@@ -105,9 +124,9 @@ func (c *GophkeeperGRPCClient) GetSecretSyncMeta(ctx context.Context) ([]dto.Sec
 	return sycMetas, nil
 }
 
-// GetSecretSyncMetaByName returns metadata for synchronization metadata for current secret by its name.
-func (c *GophkeeperGRPCClient) GetSecretSyncMetaByName(ctx context.Context, name string) (dto.SecretSyncMetadata, error) {
-	res, err := c.client.GetSecretSyncMetaByName(ctx, &pb.Name{Name: name})
+// GetSecretSyncMetaByName returns metadata for synchronization metadata for current secret by blind index of its name.
+func (c *GophkeeperGRPCClient) GetSecretSyncMetaByName(ctx context.Context, nameIndex string) (dto.SecretSyncMetadata, error) {
+	res, err := c.client.GetSecretSyncMetaByName(ctx, &pb.Name{Name: nameIndex})
 	if err != nil {
 		log.Error(err)
 		return dto.SecretSyncMetadata{}, handleStatusError(err)
@@ -145,14 +164,262 @@ func (c *GophkeeperGRPCClient) DeleteSecret(ctx context.Context, id string) erro
 	return nil
 }
 
+// GetAttachmentsMeta returns attachments of user without encrypted content.
+func (c *GophkeeperGRPCClient) GetAttachmentsMeta(ctx context.Context) ([]model.EncodedAttachment, error) {
+	res, err := c.client.GetAttachmentsMeta(ctx, &emptypb.Empty{})
+	if err != nil {
+		log.Error(err)
+		return nil, handleStatusError(err)
+	}
+	attachments := make([]model.EncodedAttachment, 0, len(res.Items))
+	for _, protoAttachment := range res.Items {
+		attachments = append(attachments, pb.EncodedAttachmentFromProto(protoAttachment))
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns EncodedAttachment with encrypted content by ID.
+func (c *GophkeeperGRPCClient) GetAttachment(ctx context.Context, id string) (model.EncodedAttachment, error) {
+	attachment, err := c.client.GetAttachment(ctx, &pb.AttachmentID{AttachmentID: id})
+	if err != nil {
+		log.Error(err)
+		return model.EncodedAttachment{}, handleStatusError(err)
+	}
+	return pb.EncodedAttachmentFromProto(attachment), nil
+}
+
+// SaveAttachment saves EncodedAttachment.
+func (c *GophkeeperGRPCClient) SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error {
+	_, err := c.client.SaveAttachment(ctx, pb.NewProtoEncodedAttachment(attachment))
+	if err != nil {
+		log.Error(err)
+		return handleStatusError(err)
+	}
+	return nil
+}
+
+// DeleteAttachment deletes EncodedAttachment by ID.
+func (c *GophkeeperGRPCClient) DeleteAttachment(ctx context.Context, id string) error {
+	_, err := c.client.DeleteAttachment(ctx, &pb.AttachmentID{AttachmentID: id})
+	if err != nil {
+		log.Error(err)
+		return handleStatusError(err)
+	}
+	return nil
+}
+
+// GetMissingChunks returns addresses of chunks which are not uploaded yet among specified ones.
+func (c *GophkeeperGRPCClient) GetMissingChunks(ctx context.Context, hashes []string) ([]string, error) {
+	missing, err := c.client.GetMissingChunks(ctx, &pb.ChunkHashes{Hashes: hashes})
+	if err != nil {
+		log.Error(err)
+		return nil, handleStatusError(err)
+	}
+	return missing.GetHashes(), nil
+}
+
+// UploadChunks streams chunks returned by next until it returns io.EOF, returns number of chunks saved by server.
+func (c *GophkeeperGRPCClient) UploadChunks(ctx context.Context, next func() (model.SecretChunk, error)) (int64, error) {
+	stream, err := c.client.UploadChunks(ctx)
+	if err != nil {
+		log.Error(err)
+		return 0, handleStatusError(err)
+	}
+	for {
+		chunk, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = stream.CloseSend()
+			return 0, err
+		}
+		err = stream.Send(pb.NewProtoSecretChunk(chunk))
+		if errors.Is(err, io.EOF) {
+			// server closed stream, its error is returned by CloseAndRecv
+			break
+		}
+		if err != nil {
+			log.Error(err)
+			return 0, handleStatusError(err)
+		}
+	}
+	uploaded, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Error(err)
+		return 0, handleStatusError(err)
+	}
+	return uploaded.GetCount(), nil
+}
+
+// SetSecretChunks sets chunks file of secret consists of, chunks must be already uploaded.
+func (c *GophkeeperGRPCClient) SetSecretChunks(ctx context.Context, secretID string, hashes []string) error {
+	_, err := c.client.SetSecretChunks(ctx, &pb.SecretChunkRefs{SecretID: secretID, Hashes: hashes})
+	if err != nil {
+		log.Error(err)
+		return handleStatusError(err)
+	}
+	return nil
+}
+
+// DownloadSecretChunks streams chunks of secret starting from offset and passes them to write.
+func (c *GophkeeperGRPCClient) DownloadSecretChunks(ctx context.Context, secretID string, offset int64, write func(chunk model.SecretChunk) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.client.DownloadSecretChunks(ctx, &pb.ChunkRequest{SecretID: secretID, Offset: offset})
+	if err != nil {
+		log.Error(err)
+		return handleStatusError(err)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			log.Error(err)
+			return handleStatusError(err)
+		}
+		err = write(pb.SecretChunkFromProto(chunk))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ChangePassword replaces auth key of user and stores vault key re-wrapped with the new password.
+func (c *GophkeeperGRPCClient) ChangePassword(ctx context.Context, oldAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error) {
+	authMeta, err := c.client.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		OldAuthKey:      oldAuthKey,
+		NewAuthKey:      newAuthKey,
+		KdfParams:       pb.NewProtoKDFParams(kdf),
+		WrappedVaultKey: wrappedVaultKey,
+	})
+	if err != nil {
+		log.Error(err)
+		return "", model.User{}, handleStatusError(err)
+	}
+	user := pb.NewUserFromProtoUser(authMeta.GetUser())
+	return authMeta.GetToken(), user, nil
+}
+
+// GetPasswordChangeEvent returns user if password was changed after specified timestamp.
+func (c *GophkeeperGRPCClient) GetPasswordChangeEvent(ctx context.Context, since int64) (model.User, bool, error) {
+	res, err := c.client.GetChangeEvents(ctx, &pb.ChangeEventsRequest{Since: since})
+	if err != nil {
+		log.Error(err)
+		return model.User{}, false, handleStatusError(err)
+	}
+	for _, event := range res.GetEvents() {
+		if event.GetType() == pb.EVENT_TYPE_PASSWORD_CHANGE {
+			return pb.NewUserFromProtoUser(event.GetUser()), true, nil
+		}
+	}
+	return model.User{}, false, nil
+}
+
+// StartKeyRotation starts vault key rotation, returns user with pending wrapped vault key.
+func (c *GophkeeperGRPCClient) StartKeyRotation(ctx context.Context, wrappedVaultKey []byte) (model.User, error) {
+	user, err := c.client.StartKeyRotation(ctx, &pb.KeyRotationRequest{WrappedVaultKey: wrappedVaultKey})
+	if err != nil {
+		log.Error(err)
+		return model.User{}, handleStatusError(err)
+	}
+	return pb.NewUserFromProtoUser(user), nil
+}
+
+// FinishKeyRotation makes pending vault key current one, returns updated user.
+func (c *GophkeeperGRPCClient) FinishKeyRotation(ctx context.Context) (model.User, error) {
+	user, err := c.client.FinishKeyRotation(ctx, &emptypb.Empty{})
+	if err != nil {
+		log.Error(err)
+		return model.User{}, handleStatusError(err)
+	}
+	return pb.NewUserFromProtoUser(user), nil
+}
+
+// SetRecoveryKit stores new recovery kit of user, returns updated user.
+func (c *GophkeeperGRPCClient) SetRecoveryKit(ctx context.Context, recoveryKit model.RecoveryKit) (model.User, error) {
+	user, err := c.client.SetRecoveryKit(ctx, pb.NewProtoRecoveryKit(recoveryKit))
+	if err != nil {
+		log.Error(err)
+		return model.User{}, handleStatusError(err)
+	}
+	return pb.NewUserFromProtoUser(user), nil
+}
+
+// GetRecoveryKit returns vault key wrapped with recovery key.
+func (c *GophkeeperGRPCClient) GetRecoveryKit(ctx context.Context, login, recoveryAuthKey string) ([]byte, error) {
+	recoveryKit, err := c.client.GetRecoveryKit(ctx, &pb.RecoveryRequest{Login: login, RecoveryAuthKey: recoveryAuthKey})
+	if err != nil {
+		log.Error(err)
+		return nil, handleStatusError(err)
+	}
+	return recoveryKit.GetWrappedVaultKey(), nil
+}
+
+// RecoverAccount sets auth key derived from new password and stores vault key re-wrapped with it,
+// recovery auth key proves possession of recovery key.
+func (c *GophkeeperGRPCClient) RecoverAccount(ctx context.Context, login, recoveryAuthKey, newAuthKey string, kdf model.KDFParams,
+	wrappedVaultKey []byte) (string, model.User, error) {
+	authMeta, err := c.client.RecoverAccount(ctx, &pb.RecoverAccountRequest{
+		Login:           login,
+		RecoveryAuthKey: recoveryAuthKey,
+		NewAuthKey:      newAuthKey,
+		KdfParams:       pb.NewProtoKDFParams(kdf),
+		WrappedVaultKey: wrappedVaultKey,
+	})
+	if err != nil {
+		log.Error(err)
+		return "", model.User{}, handleStatusError(err)
+	}
+	user := pb.NewUserFromProtoUser(authMeta.GetUser())
+	return authMeta.GetToken(), user, nil
+}
+
 func handleStatusError(err error) error {
 	if s, ok := status.FromError(err); ok {
-		if s.Code() == codes.Unauthenticated || s.Code() == codes.AlreadyExists {
+		if s.Code() == codes.Unauthenticated || s.Code() == codes.AlreadyExists || s.Code() == codes.InvalidArgument ||
+			s.Code() == codes.FailedPrecondition {
 			return errors.New(s.Message())
 		}
 		if s.Code() == codes.Unavailable {
 			return errs.ErrServerIsNotAvailable
 		}
+		if s.Code() == codes.NotFound {
+			return errs.ErrItemNotFound
+		}
 	}
 	return err
 }
+
+// VerifyTwoFactor completes two-factor login with one-time password or recovery code, returns full token.
+func (c *GophkeeperGRPCClient) VerifyTwoFactor(ctx context.Context, code string) (string, model.User, error) {
+	authMeta, err := c.client.VerifyTwoFactor(ctx, &pb.TwoFactorCode{Code: code})
+	if err != nil {
+		log.Error(err)
+		return "", model.User{}, handleStatusError(err)
+	}
+	user := pb.NewUserFromProtoUser(authMeta.GetUser())
+	return authMeta.GetToken(), user, nil
+}
+
+// EnableTwoFactor enables second factor with TOTP seed generated on client, returns recovery codes.
+func (c *GophkeeperGRPCClient) EnableTwoFactor(ctx context.Context, secret, code string) ([]string, error) {
+	recoveryCodes, err := c.client.EnableTwoFactor(ctx, &pb.TwoFactorSetup{Secret: secret, Code: code})
+	if err != nil {
+		log.Error(err)
+		return nil, handleStatusError(err)
+	}
+	return recoveryCodes.GetCodes(), nil
+}
+
+// DisableTwoFactor disables second factor, returns updated user.
+func (c *GophkeeperGRPCClient) DisableTwoFactor(ctx context.Context, code string) (model.User, error) {
+	user, err := c.client.DisableTwoFactor(ctx, &pb.TwoFactorCode{Code: code})
+	if err != nil {
+		log.Error(err)
+		return model.User{}, handleStatusError(err)
+	}
+	return pb.NewUserFromProtoUser(user), nil
+}
@@ -2,10 +2,16 @@ package controller
 
 import (
 	"context"
+	"crypto/hmac"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 
+	"github.com/apolsh/yapr-gophkeeper/internal/client/sshagent"
 	"github.com/apolsh/yapr-gophkeeper/internal/model"
 	errs "github.com/apolsh/yapr-gophkeeper/internal/model/app_errors"
 	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
@@ -23,8 +29,38 @@ type GophkeeperView interface {
 	ViewSecretsInfoList(secretInfos []dto.SecretItemInfo)
 	// ShowSecretItem shows secret item.
 	ShowSecretItem(item model.SecretItem)
+	// ShowRecoveryKey shows recovery key, it is shown only once and should be printed or written down.
+	ShowRecoveryKey(recoveryKey string)
 	// GetStringInput gets input.
 	GetStringInput(ctx context.Context, inputText string) string
+	// GetPasswordInput gets hidden password input.
+	GetPasswordInput(ctx context.Context, inputText string) string
+	// GetPinInput gets hidden PIN input.
+	GetPinInput(ctx context.Context, inputText string) string
+	// GetTwoFactorCodeInput gets one-time password or two-factor recovery code.
+	GetTwoFactorCodeInput(ctx context.Context, inputText string) string
+	// ShowTwoFactorSetup shows TOTP seed and otpauth URI to import into authenticator application.
+	ShowTwoFactorSetup(secret, uri string)
+	// ShowRecoveryCodes shows two-factor recovery codes, they are shown only once.
+	ShowRecoveryCodes(recoveryCodes []string)
+	// ShowSSHAgentStarted shows socket of started ssh-agent and number of served keys.
+	ShowSSHAgentStarted(socketPath string, keysCount int)
+	// GetConfirmation asks yes or no question.
+	GetConfirmation(ctx context.Context, inputText string) bool
+	// ShowWarning shows warning.
+	ShowWarning(message string)
+	// EditCustomFields lets user add, change, reorder and remove custom fields, returns edited fields.
+	EditCustomFields(ctx context.Context, fields []model.CustomField) []model.CustomField
+	// EditSecretLabels lets user change folder and tags of secret, returns edited labels.
+	EditSecretLabels(ctx context.Context, labels dto.SecretLabels) dto.SecretLabels
+	// SelectFolder lets user navigate folders and choose one, empty folder is root folder.
+	SelectFolder(ctx context.Context, folders []string) (string, bool)
+	// SelectTag lets user choose one of tags.
+	SelectTag(ctx context.Context, tags []string) (string, bool)
+	// ShowAttachments shows files attached to secret.
+	ShowAttachments(attachments []dto.AttachmentInfo)
+	// SelectAttachment lets user choose one of attachments, returns its ID.
+	SelectAttachment(ctx context.Context, attachments []dto.AttachmentInfo) (string, bool)
 	// ShowError shows error.
 	ShowError(err error)
 }
@@ -37,6 +73,32 @@ type LocalStorage interface {
 	UpdateUser(ctx context.Context, user model.User) error
 	// GetUserByID returns user by ID.
 	GetUserByID(ctx context.Context, userID int64) (model.User, error)
+	// GetUserByLogin returns user by login.
+	GetUserByLogin(ctx context.Context, login string) (model.User, error)
+	// SaveLocalVerifier saves value which allows to check password without server.
+	SaveLocalVerifier(ctx context.Context, userID int64, verifier []byte) error
+	// GetLocalVerifier returns value which allows to check password without server.
+	GetLocalVerifier(ctx context.Context, userID int64) ([]byte, error)
+	// SetVaultUpgraded marks that every secret of user is encoded with associated data.
+	SetVaultUpgraded(ctx context.Context, userID int64) error
+	// IsVaultUpgraded reports whether every secret of user is encoded with associated data.
+	IsVaultUpgraded(ctx context.Context, userID int64) (bool, error)
+	// AddPendingChange queues change of secret which is not sent to server yet.
+	AddPendingChange(ctx context.Context, change dto.PendingChange) error
+	// GetPendingChanges returns queued changes of user secrets in order they were made.
+	GetPendingChanges(ctx context.Context, ownerID int64) ([]dto.PendingChange, error)
+	// DeletePendingChange removes change which is sent to server, later changes of the same secret stay queued.
+	DeletePendingChange(ctx context.Context, changeID int64) error
+	// SavePinUnlock saves vault key wrapped with PIN, resets failed attempts.
+	SavePinUnlock(ctx context.Context, pin model.PinUnlock) error
+	// GetPinUnlock returns vault key wrapped with PIN.
+	GetPinUnlock(ctx context.Context, userID int64) (model.PinUnlock, error)
+	// RegisterFailedPinAttempt increments number of wrong PINs and returns it.
+	RegisterFailedPinAttempt(ctx context.Context, userID int64) (int, error)
+	// ResetPinAttempts resets number of wrong PINs.
+	ResetPinAttempts(ctx context.Context, userID int64) error
+	// DeletePinUnlock wipes vault key wrapped with PIN.
+	DeletePinUnlock(ctx context.Context, userID int64) error
 	// GetSecretSyncMetaByID returns metadata for one secret synchronization.
 	GetSecretSyncMetaByID(ctx context.Context, id string) (dto.SecretSyncMetadata, error)
 	// GetSecretSyncMetaByOwnerID returns metadata for all secrets synchronization by user.
@@ -45,49 +107,158 @@ type LocalStorage interface {
 	SaveEncodedSecret(ctx context.Context, encSecret model.EncodedSecret) error
 	// GetSecretByID returns EncodedSecret by ID.
 	GetSecretByID(ctx context.Context, id string) (model.EncodedSecret, error)
-	// GetAllSecretsItemInfoByUserID returns all secret item info by user id.
-	GetAllSecretsItemInfoByUserID(ctx context.Context, ownerID int64) ([]dto.SecretItemInfo, error)
-	// GetSecretByName returns secret by it name.
-	GetSecretByName(ctx context.Context, name string) (model.EncodedSecret, error)
-	// DeleteSecretByName delete secret by it name.
-	DeleteSecretByName(ctx context.Context, name string) (string, error)
+	// GetAllSecretsItemInfoByUserID returns encoded info of all secrets by user id, content of secrets is not loaded.
+	GetAllSecretsItemInfoByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error)
+	// GetSecretsItemInfoByFolder returns encoded info of secrets placed directly in folder with specified blind index.
+	GetSecretsItemInfoByFolder(ctx context.Context, ownerID int64, folderIndex string) ([]model.EncodedSecret, error)
+	// GetSecretsItemInfoByTag returns encoded info of secrets marked with tag with specified blind index.
+	GetSecretsItemInfoByTag(ctx context.Context, ownerID int64, tagIndex string) ([]model.EncodedSecret, error)
+	// GetFoldersByUserID returns encoded info of one secret of every folder.
+	GetFoldersByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error)
+	// GetTagsByUserID returns encoded info of one secret of every tag.
+	GetTagsByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error)
+	// GetSecretByName returns secret by blind index of it name.
+	GetSecretByName(ctx context.Context, nameIndex string) (model.EncodedSecret, error)
+	// DeleteSecretByName delete secret by blind index of it name, attachments of secret are deleted too.
+	DeleteSecretByName(ctx context.Context, nameIndex string) (string, error)
+	// SaveAttachment saves EncodedAttachment, nil content marks attachment which is not downloaded.
+	SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error
+	// GetAttachmentByID returns EncodedAttachment by ID with content if it is downloaded.
+	GetAttachmentByID(ctx context.Context, id string) (model.EncodedAttachment, error)
+	// GetAttachmentsBySecretID returns attachments of secret, content of attachments is not loaded.
+	GetAttachmentsBySecretID(ctx context.Context, secretID string) ([]model.EncodedAttachment, error)
+	// GetAttachmentsByOwnerID returns attachments of user, content of attachments is not loaded.
+	GetAttachmentsByOwnerID(ctx context.Context, ownerID int64) ([]model.EncodedAttachment, error)
+	// DeleteAttachment deletes attachment by ID.
+	DeleteAttachment(ctx context.Context, id string) error
 }
 
 // Encoder for decode and encode bytes.
-// should set secret key via SetSecretKey before use.
+// should set vault key via SetVaultKey (or secret key via SetSecretKey for accounts without vault key) before use.
 type Encoder interface {
-	// Encode encodes bytes
-	Encode(byteToEncode []byte) ([]byte, error)
-	// Decode decodes bytes
-	Decode(byteToDecode []byte) ([]byte, error)
-	// SetSecretKey sets secret key for encoder
-	SetSecretKey(secretKey string) error
+	// Encode encodes bytes, ciphertext is authenticated against associated data
+	Encode(byteToEncode, associatedData []byte) ([]byte, error)
+	// Decode decodes bytes, fails if associated data differs from used for encoding
+	Decode(byteToDecode, associatedData []byte) ([]byte, error)
+	// IsOutdated reports whether bytes were encoded in outdated format and should be re-encoded
+	IsOutdated(encoded []byte) bool
+	// RejectOutdatedEnvelopes makes Decode fail for formats encoded without associated data
+	RejectOutdatedEnvelopes()
+	// NameIndex returns keyed blind index of secret name
+	NameIndex(name string) (string, error)
+	// NewKDFParams generates key derivation parameters with random salt for new user
+	NewKDFParams() (model.KDFParams, error)
+	// NewVaultKey generates random vault key
+	NewVaultKey() ([]byte, error)
+	// AuthKey derives key which proves knowledge of password to server, password itself is never sent
+	AuthKey(password string, params model.KDFParams) (string, error)
+	// WrapKey encrypts vault key with key derived from password
+	WrapKey(password string, params model.KDFParams, vaultKey []byte) ([]byte, error)
+	// UnwrapKey decrypts vault key wrapped with key derived from password
+	UnwrapKey(password string, params model.KDFParams, wrappedKey []byte) ([]byte, error)
+	// SetVaultKey sets vault key for encoder, new data is encoded with specified cipher suite
+	SetVaultKey(vaultKey []byte, cipherSuite string) error
+	// SetSecretKey derives encoder key from secret key with specified KDF parameters
+	SetSecretKey(secretKey string, params model.KDFParams) error
+	// WithVaultKey returns new encoder with specified vault key, current encoder is not changed
+	WithVaultKey(vaultKey []byte, cipherSuite string) (Encoder, error)
+	// NewRecoveryKey generates random recovery key in printable form
+	NewRecoveryKey() (string, error)
+	// NewRecoveryKit wraps vault key with recovery key and derives key proving possession of recovery key
+	NewRecoveryKit(recoveryKey string, vaultKey []byte) (model.RecoveryKit, error)
+	// RecoveryAuthKey returns key proving possession of recovery key
+	RecoveryAuthKey(recoveryKey string) (string, error)
+	// UnwrapKeyWithRecoveryKey decrypts vault key wrapped with recovery key
+	UnwrapKeyWithRecoveryKey(recoveryKey string, wrappedKey []byte) ([]byte, error)
+	// Lock drops keys, encoder must be initialized again before use
+	Lock()
+	// Verifier returns value derived from encoder key, allows to check password without server
+	Verifier() ([]byte, error)
+	// ChunkKey returns key derived from encoder key, chunks of files are addressed and encrypted with it
+	ChunkKey() ([]byte, error)
 }
 
 // BackendClient  client for interactions with backend.
 type BackendClient interface {
-	// Login login user.
-	Login(ctx context.Context, login, password string) (string, model.User, error)
-	// Register registers user.
-	Register(ctx context.Context, login, password string) (string, model.User, error)
+	// GetAuthParams returns parameters for auth key derivation before login.
+	GetAuthParams(ctx context.Context, login string) (model.AuthParams, error)
+	// Login login user with auth key, password is sent only by legacy users to upgrade their account.
+	Login(ctx context.Context, login, password, authKey string) (string, model.User, error)
+	// Register registers user with auth key, KDF parameters, wrapped vault key, cipher suite for secrets and optional recovery kit.
+	Register(ctx context.Context, login, authKey string, kdf model.KDFParams, wrappedVaultKey []byte, cipherSuite string,
+		recoveryKit model.RecoveryKit) (string, model.User, error)
 	// SetAuthTokenForRequests sets authorization token for this client (add it to every required auth request).
 	SetAuthTokenForRequests(token string)
 	// GetSecretSyncMeta returns metadata for synchronization metadata.
 	GetSecretSyncMeta(ctx context.Context) ([]dto.SecretSyncMetadata, error)
-	// GetSecretSyncMetaByName returns metadata for synchronization metadata for current secret by its name.
-	GetSecretSyncMetaByName(ctx context.Context, name string) (dto.SecretSyncMetadata, error)
+	// GetSecretSyncMetaByName returns metadata for synchronization metadata for current secret by blind index of its name.
+	GetSecretSyncMetaByName(ctx context.Context, nameIndex string) (dto.SecretSyncMetadata, error)
 	// GetSecretByID returns EncodedSecret by ID.
 	GetSecretByID(ctx context.Context, id string) (model.EncodedSecret, error)
 	// SaveEncodedSecret  saves EncodedSecret.
 	SaveEncodedSecret(ctx context.Context, encSecret model.EncodedSecret) error
 	// DeleteSecret delete EncodedSecret by ID.
 	DeleteSecret(ctx context.Context, id string) error
+	// ChangePassword replaces auth key of user and stores vault key re-wrapped with the new password.
+	ChangePassword(ctx context.Context, oldAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error)
+	// GetPasswordChangeEvent returns user if password was changed after specified timestamp.
+	GetPasswordChangeEvent(ctx context.Context, since int64) (model.User, bool, error)
+	// StartKeyRotation starts vault key rotation, returns user with pending wrapped vault key.
+	StartKeyRotation(ctx context.Context, wrappedVaultKey []byte) (model.User, error)
+	// FinishKeyRotation makes pending vault key current one, returns updated user.
+	FinishKeyRotation(ctx context.Context) (model.User, error)
+	// SetRecoveryKit stores new recovery kit of user, returns updated user.
+	SetRecoveryKit(ctx context.Context, recoveryKit model.RecoveryKit) (model.User, error)
+	// GetRecoveryKit returns vault key wrapped with recovery key.
+	GetRecoveryKit(ctx context.Context, login, recoveryAuthKey string) ([]byte, error)
+	// RecoverAccount sets auth key derived from new password and stores vault key re-wrapped with it.
+	RecoverAccount(ctx context.Context, login, recoveryAuthKey, newAuthKey string, kdf model.KDFParams, wrappedVaultKey []byte) (string, model.User, error)
+	// VerifyTwoFactor completes two-factor login with one-time password or recovery code, returns full token.
+	VerifyTwoFactor(ctx context.Context, code string) (string, model.User, error)
+	// EnableTwoFactor enables second factor with TOTP seed generated on client, returns recovery codes.
+	EnableTwoFactor(ctx context.Context, secret, code string) ([]string, error)
+	// DisableTwoFactor disables second factor, returns updated user.
+	DisableTwoFactor(ctx context.Context, code string) (model.User, error)
+	// GetAttachmentsMeta returns attachments of user without encrypted content.
+	GetAttachmentsMeta(ctx context.Context) ([]model.EncodedAttachment, error)
+	// GetAttachment returns EncodedAttachment with encrypted content by ID.
+	GetAttachment(ctx context.Context, id string) (model.EncodedAttachment, error)
+	// SaveAttachment saves EncodedAttachment, secret it belongs to must be already saved.
+	SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error
+	// DeleteAttachment deletes EncodedAttachment by ID.
+	DeleteAttachment(ctx context.Context, id string) error
+	// GetMissingChunks returns addresses of chunks which are not uploaded yet among specified ones.
+	GetMissingChunks(ctx context.Context, hashes []string) ([]string, error)
+	// UploadChunks streams chunks returned by next until it returns io.EOF, returns number of chunks saved by server.
+	UploadChunks(ctx context.Context, next func() (model.SecretChunk, error)) (int64, error)
+	// SetSecretChunks sets chunks file of secret consists of, chunks must be already uploaded.
+	SetSecretChunks(ctx context.Context, secretID string, hashes []string) error
+	// DownloadSecretChunks streams chunks of secret starting from offset and passes them to write.
+	DownloadSecretChunks(ctx context.Context, secretID string, offset int64, write func(chunk model.SecretChunk) error) error
 }
 
+// chunkTransferAttempts number of attempts to transfer chunks of file, every attempt skips already transferred chunks.
+const chunkTransferAttempts = 3
+
+// rotationBatchSize number of secrets re-encoded between cancellation checks during vault key rotation.
+const rotationBatchSize = 20
+
+// twoFactorIssuer issuer shown by authenticator application for one-time passwords of gophkeeper account.
+const twoFactorIssuer = "gophkeeper"
+
+const (
+	// maxPinAttempts number of wrong PINs in a row after which local copy of vault key is wiped.
+	maxPinAttempts = 5
+	// minPinLength minimal number of digits in PIN.
+	minPinLength = 4
+)
+
 type authorizationMeta struct {
 	id       int64
 	login    string
 	password string
+	// keyVersion version of vault key new secrets are encoded with.
+	keyVersion int64
 }
 
 // GophkeeperController core control for client gophkeeper application.
@@ -97,6 +268,26 @@ type GophkeeperController struct {
 	authMeta      authorizationMeta
 	localStorage  LocalStorage
 	encoder       Encoder
+	// passwordChanged is set when synchronization detects password change made on another device.
+	passwordChanged atomic.Bool
+	// idleTimeout inactivity period after which vault is locked, zero disables auto-lock.
+	idleTimeout time.Duration
+	idleTimer   *time.Timer
+	// vaultMu serializes secret operations and auto-lock.
+	vaultMu sync.Mutex
+	// locked is set when vault key is wiped due to inactivity.
+	locked atomic.Bool
+	// offline is set when vault is unlocked without server, changes are queued until login succeeds.
+	offline atomic.Bool
+	// twoFactorRequired is set when background login of offline session needs second factor, it is not retried
+	// until user logs in again.
+	twoFactorRequired atomic.Bool
+	// twoFactorNotice is set when user is not told yet that background login needs second factor.
+	twoFactorNotice atomic.Bool
+	// sshAgentSocketPath path of Unix socket ssh-agent listens.
+	sshAgentSocketPath string
+	// sshAgent serves unlocked SSH keys, nil if not started.
+	sshAgent *sshagent.SSHAgent
 }
 
 // NewGophkeeperController GophkeeperController constructor.
@@ -115,234 +306,2087 @@ func NewGophkeeperController(
 
 	view.SetController(&c)
 
-	return &c
+	return &c
+}
+
+// SetIdleTimeout sets inactivity period after which vault key is wiped and password is asked again, zero disables auto-lock.
+func (c *GophkeeperController) SetIdleTimeout(timeout time.Duration) {
+	c.idleTimeout = timeout
+}
+
+// SetSSHAgentSocketPath sets path of Unix socket ssh-agent listens.
+func (c *GophkeeperController) SetSSHAgentSocketPath(socketPath string) {
+	c.sshAgentSocketPath = socketPath
+}
+
+// Lock wipes vault key and password from memory, password is asked again before the next secret operation.
+func (c *GophkeeperController) Lock() {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	if c.authMeta.id == 0 || c.locked.Load() {
+		return
+	}
+	c.encoder.Lock()
+	c.stopSSHAgent()
+	c.authMeta.password = ""
+	c.locked.Store(true)
+}
+
+// Login logins user, if server is not available vault is unlocked with locally stored user.
+func (c *GophkeeperController) Login(ctx context.Context, login, password string) {
+	token, user, err := c.remoteLogin(ctx, login, password)
+	if err != nil {
+		if errors.Is(errs.ErrServerIsNotAvailable, err) {
+			c.loginOffline(ctx, login, password)
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to login user: %w", err))
+		return
+	}
+	if user.TwoFactorEnabled {
+		token, user, err = c.verifyTwoFactor(ctx, token)
+		if err != nil {
+			c.remoteStorage.SetAuthTokenForRequests("")
+			c.view.ShowError(fmt.Errorf("failed to login user: %w", err))
+			return
+		}
+	}
+	c.startSession(ctx, login, password, token, user)
+}
+
+// remoteLogin derives auth key with KDF parameters of user and logins on server,
+// password is sent only once by legacy users whose account is not upgraded yet.
+// If second factor is enabled, returned token is restricted, see verifyTwoFactor.
+func (c *GophkeeperController) remoteLogin(ctx context.Context, login, password string) (string, model.User, error) {
+	params, err := c.remoteStorage.GetAuthParams(ctx, login)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	authKey, err := c.encoder.AuthKey(password, params.KDF)
+	if err != nil {
+		return "", model.User{}, err
+	}
+	var legacyPassword string
+	if !params.ZeroKnowledge {
+		legacyPassword = password
+	}
+	return c.remoteStorage.Login(ctx, login, legacyPassword, authKey)
+}
+
+// verifyTwoFactor asks one-time password and exchanges it together with restricted token for full token.
+func (c *GophkeeperController) verifyTwoFactor(ctx context.Context, restrictedToken string) (string, model.User, error) {
+	c.remoteStorage.SetAuthTokenForRequests(restrictedToken)
+	code := c.view.GetTwoFactorCodeInput(ctx, "enter one-time password or recovery code:")
+	return c.remoteStorage.VerifyTwoFactor(ctx, code)
+}
+
+// loginOffline unlocks vault with locally stored user, secrets are read from local storage
+// and changes are queued until login on server succeeds.
+func (c *GophkeeperController) loginOffline(ctx context.Context, login, password string) {
+	user, err := c.localStorage.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("failed to login user: %w", errs.ErrServerIsNotAvailable))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to get user: %w", err))
+		return
+	}
+	err = c.unlockVaultLocally(ctx, password, user)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to login user: %w", err))
+		return
+	}
+	c.startOfflineSession(login, password, user)
+	c.view.ShowWarning("server is not available, working offline, changes will be synchronized when connection is restored")
+}
+
+// startOfflineSession authorizes user whose vault is unlocked without server.
+func (c *GophkeeperController) startOfflineSession(login, password string, user model.User) {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	c.authMeta = authorizationMeta{login: login, password: password, id: user.ID, keyVersion: user.KeyVersion}
+	c.offline.Store(true)
+	c.twoFactorRequired.Store(false)
+	c.twoFactorNotice.Store(false)
+	c.view.SetAuthorized(true)
+	c.resetIdleTimer()
+}
+
+// SetPin stores local copy of vault key wrapped with key derived from PIN, so vault can be unlocked with PIN next time.
+func (c *GophkeeperController) SetPin(ctx context.Context, password, pin, repeatedPin string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	err := pinValidation(pin, repeatedPin)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get user: %w", err))
+		return
+	}
+	if len(user.WrappedVaultKey) == 0 {
+		c.view.ShowError(errors.New("PIN unlock is not supported for accounts without vault key, rotate vault key first"))
+		return
+	}
+	vaultKey, err := c.encoder.UnwrapKey(password, user.KDF, user.WrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock vault: %w", err))
+		return
+	}
+	params, err := c.encoder.NewKDFParams()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	wrappedKey, err := c.encoder.WrapKey(pin, params, vaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wrap vault key: %w", err))
+		return
+	}
+	err = c.localStorage.SavePinUnlock(ctx, model.PinUnlock{Owner: user.ID, WrappedVaultKey: wrappedKey, KDF: params})
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to save PIN: %w", err))
+	}
+}
+
+// UnlockWithPin unlocks vault with PIN set after previous full login, local copy of vault key is wiped
+// after maxPinAttempts wrong PINs. Password is not known, so secrets are synchronized after next login with password.
+func (c *GophkeeperController) UnlockWithPin(ctx context.Context, login string) {
+	user, err := c.localStorage.GetUserByLogin(ctx, login)
+	var pinUnlock model.PinUnlock
+	if err == nil {
+		pinUnlock, err = c.localStorage.GetPinUnlock(ctx, user.ID)
+	}
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(errors.New("PIN is not set for this user, login with password"))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to get PIN: %w", err))
+		return
+	}
+	pin := c.view.GetPinInput(ctx, fmt.Sprintf("enter PIN (attempts left: %d):", maxPinAttempts-pinUnlock.FailedAttempts))
+	vaultKey, err := c.encoder.UnwrapKey(pin, pinUnlock.KDF, pinUnlock.WrappedVaultKey)
+	if err != nil {
+		c.registerFailedPinAttempt(ctx, user.ID)
+		return
+	}
+	err = c.localStorage.ResetPinAttempts(ctx, user.ID)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to reset PIN attempts: %w", err))
+		return
+	}
+	err = c.encoder.SetVaultKey(vaultKey, user.CipherSuite)
+	if err == nil {
+		err = c.rejectOutdatedEnvelopes(ctx, c.encoder, user.ID)
+	}
+	if err != nil {
+		c.encoder.Lock()
+		c.view.ShowError(err)
+		return
+	}
+	stored, err := c.localStorage.GetLocalVerifier(ctx, user.ID)
+	if err != nil {
+		c.encoder.Lock()
+		c.view.ShowError(err)
+		return
+	}
+	verifier, err := c.encoder.Verifier()
+	if err != nil || !hmac.Equal(stored, verifier) {
+		// vault key was rotated since PIN was set
+		c.encoder.Lock()
+		c.wipePin(ctx, user.ID)
+		c.view.ShowError(errors.New("vault key was changed, PIN unlock is disabled, login with password"))
+		return
+	}
+	c.startOfflineSession(login, "", user)
+	c.view.ShowWarning("vault is unlocked with PIN, login with password to synchronize secrets")
+}
+
+// registerFailedPinAttempt counts wrong PIN, local copy of vault key is wiped when attempts are exhausted.
+func (c *GophkeeperController) registerFailedPinAttempt(ctx context.Context, userID int64) {
+	attempts, err := c.localStorage.RegisterFailedPinAttempt(ctx, userID)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to register PIN attempt: %w", err))
+		return
+	}
+	if attempts >= maxPinAttempts {
+		c.wipePin(ctx, userID)
+		c.view.ShowError(errors.New("too many wrong PINs, PIN unlock is disabled, login with password"))
+		return
+	}
+	c.view.ShowWarning(fmt.Sprintf("wrong PIN, attempts left: %d", maxPinAttempts-attempts))
+}
+
+// wipePin removes local copy of vault key wrapped with PIN.
+func (c *GophkeeperController) wipePin(ctx context.Context, userID int64) {
+	err := c.localStorage.DeletePinUnlock(ctx, userID)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wipe PIN: %w", err))
+	}
+}
+
+// EnableTwoFactor generates TOTP seed, shows it to import into authenticator application
+// and enables second factor once user confirms it with one-time password.
+func (c *GophkeeperController) EnableTwoFactor(ctx context.Context) {
+	if !c.requireOnline() {
+		return
+	}
+	secret, err := model.NewOTPSecret()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	otp, err := model.NewOTPSecretItem("", "", twoFactorIssuer, c.authMeta.login, secret, "", 0, 0)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	c.view.ShowTwoFactorSetup(otp.Secret, otp.OTPAuthURI())
+	code := c.view.GetTwoFactorCodeInput(ctx, "enter one-time password from authenticator application:")
+	recoveryCodes, err := c.remoteStorage.EnableTwoFactor(ctx, otp.Secret, code)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to enable two-factor authentication: %w", err))
+		return
+	}
+	c.view.ShowRecoveryCodes(recoveryCodes)
+}
+
+// DisableTwoFactor disables second factor, code is one-time password or recovery code.
+func (c *GophkeeperController) DisableTwoFactor(ctx context.Context, code string) {
+	if !c.requireOnline() {
+		return
+	}
+	_, err := c.remoteStorage.DisableTwoFactor(ctx, code)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to disable two-factor authentication: %w", err))
+	}
+}
+
+// RecoverAccount restores access to account with forgotten password using recovery key,
+// vault key is re-wrapped with the new password, secrets stay the same.
+func (c *GophkeeperController) RecoverAccount(ctx context.Context, login, recoveryKey, newPassword, repeatedPassword string) {
+	err := passwordValidation(newPassword, repeatedPassword)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	authKey, err := c.encoder.RecoveryAuthKey(recoveryKey)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	recoveryWrappedVaultKey, err := c.remoteStorage.GetRecoveryKit(ctx, login, authKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to recover account: %w", err))
+		return
+	}
+	vaultKey, err := c.encoder.UnwrapKeyWithRecoveryKey(recoveryKey, recoveryWrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock vault: %w", err))
+		return
+	}
+	kdf, err := c.encoder.NewKDFParams()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	wrappedVaultKey, err := c.encoder.WrapKey(newPassword, kdf, vaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wrap vault key: %w", err))
+		return
+	}
+	newAuthKey, err := c.encoder.AuthKey(newPassword, kdf)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	token, user, err := c.remoteStorage.RecoverAccount(ctx, login, authKey, newAuthKey, kdf, wrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to recover account: %w", err))
+		return
+	}
+	if user.TwoFactorEnabled {
+		token, user, err = c.verifyTwoFactor(ctx, token)
+		if err != nil {
+			c.remoteStorage.SetAuthTokenForRequests("")
+			c.view.ShowError(fmt.Errorf("password is changed, but login failed: %w", err))
+			return
+		}
+	}
+	c.startSession(ctx, login, newPassword, token, user)
+}
+
+// CreateRecoveryKey generates new recovery key for user, previous recovery key stops working.
+func (c *GophkeeperController) CreateRecoveryKey(ctx context.Context, password string) {
+	if !c.requireOnline() {
+		return
+	}
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get user: %w", err))
+		return
+	}
+	if len(user.WrappedVaultKey) == 0 {
+		c.view.ShowError(errors.New("recovery key is not supported for accounts without vault key"))
+		return
+	}
+	vaultKey, err := c.encoder.UnwrapKey(password, user.KDF, user.WrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock vault: %w", err))
+		return
+	}
+	recoveryKey, recoveryKit, err := c.newRecoveryKit(vaultKey)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	user, err = c.remoteStorage.SetRecoveryKit(ctx, recoveryKit)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to save recovery key: %w", err))
+		return
+	}
+	err = c.localStorage.UpdateUser(ctx, user)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to localy save user: %w", err))
+	}
+	c.view.ShowRecoveryKey(recoveryKey)
+}
+
+// startSession stores authenticated user, unlocks vault and synchronizes secrets.
+func (c *GophkeeperController) startSession(ctx context.Context, login, password, token string, user model.User) {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	c.authMeta = authorizationMeta{login: login, password: password, id: user.ID}
+	c.twoFactorRequired.Store(false)
+	c.twoFactorNotice.Store(false)
+	c.unlockStoredVault(ctx, password, user)
+	err := c.synchronizeAuthMeta(ctx, user)
+	c.remoteStorage.SetAuthTokenForRequests(token)
+	if err == nil {
+		err = c.unlockVault(ctx, password, user)
+	}
+	if err == nil {
+		err = c.synchronizeSecretItems(ctx)
+	}
+	if err != nil {
+		c.view.ShowError(err)
+		c.encoder.Lock()
+		c.authMeta = authorizationMeta{}
+		return
+	}
+	c.storeLocalVerifier(ctx)
+	err = c.upgradeLegacySecrets(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to upgrade secrets encoding: %w", err))
+	}
+	if user.IsKeyRotationInProgress() {
+		c.view.ShowError(errors.New("vault key rotation was interrupted, rotate vault key again to resume it"))
+	}
+	c.warnAboutExpiringCards(ctx)
+	c.view.SetAuthorized(true)
+	c.resetIdleTimer()
+}
+
+// Register registers user, secrets of user are encrypted with specified cipher suite,
+// if withRecoveryKey is set recovery key is generated and shown once.
+func (c *GophkeeperController) Register(ctx context.Context, login, password, repeatedPassword, cipherSuite string, withRecoveryKey bool) {
+	err := passwordValidation(password, repeatedPassword)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	err = model.ValidateCipherSuite(cipherSuite)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	kdf, err := c.encoder.NewKDFParams()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	vaultKey, err := c.encoder.NewVaultKey()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	wrappedVaultKey, err := c.encoder.WrapKey(password, kdf, vaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wrap vault key: %w", err))
+		return
+	}
+	var recoveryKey string
+	var recoveryKit model.RecoveryKit
+	if withRecoveryKey {
+		recoveryKey, recoveryKit, err = c.newRecoveryKit(vaultKey)
+		if err != nil {
+			c.view.ShowError(err)
+			return
+		}
+	}
+	authKey, err := c.encoder.AuthKey(password, kdf)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	token, user, err := c.remoteStorage.Register(ctx, login, authKey, kdf, wrappedVaultKey, cipherSuite, recoveryKit)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to register user: %w", err))
+		return
+	}
+	err = c.localStorage.SaveUser(ctx, user)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to localy save user: %w", err))
+		return
+	}
+	c.remoteStorage.SetAuthTokenForRequests(token)
+	c.authMeta = authorizationMeta{login: login, password: password, id: user.ID}
+	c.view.SetAuthorized(true)
+	err = c.encoder.SetVaultKey(vaultKey, user.CipherSuite)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	c.resetIdleTimer()
+	if withRecoveryKey {
+		c.view.ShowRecoveryKey(recoveryKey)
+	}
+}
+
+// ChangePassword changes user password, vault key stays the same and is re-wrapped with the new password.
+func (c *GophkeeperController) ChangePassword(ctx context.Context, oldPassword, newPassword, repeatedPassword string) {
+	if !c.requireOnline() {
+		return
+	}
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	err := passwordValidation(newPassword, repeatedPassword)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get user: %w", err))
+		return
+	}
+	if len(user.WrappedVaultKey) == 0 {
+		c.view.ShowError(errors.New("password change is not supported for accounts without vault key"))
+		return
+	}
+	vaultKey, err := c.encoder.UnwrapKey(oldPassword, user.KDF, user.WrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock vault: %w", err))
+		return
+	}
+	kdf, err := c.encoder.NewKDFParams()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	wrappedVaultKey, err := c.encoder.WrapKey(newPassword, kdf, vaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wrap vault key: %w", err))
+		return
+	}
+	oldAuthKey, err := c.encoder.AuthKey(oldPassword, user.KDF)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	newAuthKey, err := c.encoder.AuthKey(newPassword, kdf)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	token, user, err := c.remoteStorage.ChangePassword(ctx, oldAuthKey, newAuthKey, kdf, wrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to change password: %w", err))
+		return
+	}
+	c.remoteStorage.SetAuthTokenForRequests(token)
+	c.authMeta.password = newPassword
+	err = c.localStorage.UpdateUser(ctx, user)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to localy save user: %w", err))
+		return
+	}
+}
+
+// RotateVaultKey replaces vault key with the new one and re-encodes all secrets of user.
+// Other devices can not save secrets until rotation is finished, interrupted rotation is resumed by the next call.
+func (c *GophkeeperController) RotateVaultKey(ctx context.Context, password string) {
+	if !c.requireOnline() {
+		return
+	}
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	err := c.synchronizeSecretItems(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get user: %w", err))
+		return
+	}
+	if len(user.WrappedVaultKey) == 0 {
+		c.view.ShowError(errors.New("vault key rotation is not supported for accounts without vault key"))
+		return
+	}
+	_, err = c.encoder.UnwrapKey(password, user.KDF, user.WrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock vault: %w", err))
+		return
+	}
+	vaultKey, err := c.encoder.NewVaultKey()
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	wrappedVaultKey, err := c.encoder.WrapKey(password, user.KDF, vaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to wrap vault key: %w", err))
+		return
+	}
+	user, err = c.remoteStorage.StartKeyRotation(ctx, wrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to start vault key rotation: %w", err))
+		return
+	}
+	// rotation started earlier is resumed with its pending key
+	vaultKey, err = c.encoder.UnwrapKey(password, user.KDF, user.PendingWrappedVaultKey)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to unlock pending vault key: %w", err))
+		return
+	}
+	newEncoder, err := c.encoder.WithVaultKey(vaultKey, user.CipherSuite)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	err = c.reEncodeSecrets(ctx, newEncoder, user.WriteKeyVersion())
+	if err == nil {
+		err = c.reEncodeAttachments(ctx, newEncoder, user.WriteKeyVersion())
+	}
+	if err != nil {
+		newEncoder.Lock()
+		c.view.ShowError(fmt.Errorf("vault key rotation is interrupted: %w", err))
+		return
+	}
+	hadRecoveryKey := user.HasRecoveryKey()
+	user, err = c.remoteStorage.FinishKeyRotation(ctx)
+	if err != nil {
+		newEncoder.Lock()
+		c.view.ShowError(fmt.Errorf("failed to finish vault key rotation: %w", err))
+		return
+	}
+	if hadRecoveryKey {
+		c.view.ShowError(errors.New("recovery key does not work with the new vault key, create a new recovery key"))
+	}
+	err = c.localStorage.UpdateUser(ctx, user)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to localy save user: %w", err))
+	}
+	// old vault key must not stay in memory once rotation is finished
+	c.encoder.Lock()
+	c.encoder = newEncoder
+	c.authMeta.keyVersion = user.KeyVersion
+	c.storeLocalVerifier(ctx)
+	// every secret is re-encoded in current format
+	err = c.markVaultUpgraded(ctx, c.encoder)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to mark vault upgraded: %w", err))
+	}
+	// local copy of the old vault key must not outlive rotation
+	c.wipePin(ctx, c.authMeta.id)
+}
+
+// SaveSecret saves secret item into folder and with tags of labels.
+func (c *GophkeeperController) SaveSecret(ctx context.Context, item model.SecretItem, labels dto.SecretLabels) {
+	binarySecret, chunked := item.(*model.BinarySecretItem)
+	if chunked {
+		// temporary archive of directory is not needed once secret is saved or saving failed
+		defer c.closeBinarySecret(binarySecret)
+	}
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	chunked = chunked && binarySecret.NeedsChunking()
+	if chunked {
+		// chunks are stored only on server
+		if !c.requireOnline() {
+			return
+		}
+		err := c.indexChunks(ctx, binarySecret)
+		if err != nil {
+			c.view.ShowError(err)
+			return
+		}
+		// chunks are uploaded before secret, so secret never references file which failed to upload
+		err = c.uploadChunks(ctx, binarySecret)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to upload file: %w", err))
+			return
+		}
+	}
+	encodedSecret, err := c.encodeSecretItem(c.encoder, item, labels, c.authMeta.id, model.NewSecretID(), c.authMeta.keyVersion)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to encode secret: %w", err))
+		return
+	}
+	err = c.localStorage.SaveEncodedSecret(ctx, encodedSecret)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to locally store secret: %w", err))
+		return
+	}
+	err = c.pushSecretChange(ctx, encodedSecret.ID, dto.PendingChangeCreate, func() error {
+		return c.remoteStorage.SaveEncodedSecret(ctx, encodedSecret)
+	})
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+		return
+	}
+	if chunked {
+		err = c.remoteStorage.SetSecretChunks(ctx, encodedSecret.ID, binarySecret.ChunkHashes())
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to upload file: %w", err))
+		}
+	}
+}
+
+// ListSecret shows all stored secrets of user.
+func (c *GophkeeperController) ListSecret(ctx context.Context) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	err := c.synchronizeSecretItems(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	encodedInfos, err := c.localStorage.GetAllSecretsItemInfoByUserID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get secrets info: %w", err))
+		return
+	}
+	c.view.ViewSecretsInfoList(c.decodeSecretInfos(encodedInfos))
+	c.warnAboutExpiringCards(ctx)
+}
+
+// BrowseFolders lets user navigate folders and shows secrets of chosen folder.
+func (c *GophkeeperController) BrowseFolders(ctx context.Context) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	err := c.synchronizeSecretItems(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	encodedInfos, err := c.localStorage.GetFoldersByUserID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get folders: %w", err))
+		return
+	}
+	folders := make([]string, 0, len(encodedInfos))
+	for _, info := range c.decodeSecretInfos(encodedInfos) {
+		folders = append(folders, info.Folder)
+	}
+	folder, ok := c.view.SelectFolder(ctx, folders)
+	if !ok {
+		return
+	}
+	folderIndex, err := model.FolderIndex(c.encoder.NameIndex, folder)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	encodedInfos, err = c.localStorage.GetSecretsItemInfoByFolder(ctx, c.authMeta.id, folderIndex)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get secrets info: %w", err))
+		return
+	}
+	c.view.ViewSecretsInfoList(c.decodeSecretInfos(encodedInfos))
+}
+
+// BrowseTags lets user choose tag and shows secrets marked with it.
+func (c *GophkeeperController) BrowseTags(ctx context.Context) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	err := c.synchronizeSecretItems(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	encodedInfos, err := c.localStorage.GetTagsByUserID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get tags: %w", err))
+		return
+	}
+	var tags []string
+	for _, info := range c.decodeSecretInfos(encodedInfos) {
+		tags = append(tags, info.Tags...)
+	}
+	tags = model.NormalizeTags(tags)
+	if len(tags) == 0 {
+		c.view.ShowError(errors.New("there are no tagged secrets"))
+		return
+	}
+	sort.Strings(tags)
+	tag, ok := c.view.SelectTag(ctx, tags)
+	if !ok {
+		return
+	}
+	tagIndex, err := model.TagIndex(c.encoder.NameIndex, tag)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	encodedInfos, err = c.localStorage.GetSecretsItemInfoByTag(ctx, c.authMeta.id, tagIndex)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get secrets info: %w", err))
+		return
+	}
+	c.view.ViewSecretsInfoList(c.decodeSecretInfos(encodedInfos))
+}
+
+// decodeSecretInfos decodes name, description and labels of secrets, secrets which fail to decode are reported and skipped.
+func (c *GophkeeperController) decodeSecretInfos(encodedInfos []model.EncodedSecret) []dto.SecretItemInfo {
+	secretItemsInfo := make([]dto.SecretItemInfo, 0, len(encodedInfos))
+	for _, encodedInfo := range encodedInfos {
+		info, err := encodedInfo.DecodeInfo(c.encoder.Decode)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to decode secret info: %w", err))
+			continue
+		}
+		secretItemsInfo = append(secretItemsInfo, info)
+	}
+	return secretItemsInfo
+}
+
+// warnAboutExpiringCards warns about cards which are expired or expire within model.CardExpiryWarningPeriod.
+func (c *GophkeeperController) warnAboutExpiringCards(ctx context.Context) {
+	encodedInfos, err := c.localStorage.GetAllSecretsItemInfoByUserID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get secrets info: %w", err))
+		return
+	}
+	now := time.Now()
+	for _, encodedInfo := range encodedInfos {
+		if encodedInfo.Type != model.Card {
+			continue
+		}
+		encodedSecret, err := c.localStorage.GetSecretByID(ctx, encodedInfo.ID)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to get secret: %w", err))
+			continue
+		}
+		item, err := encodedSecret.Decode(c.encoder.Decode)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
+			continue
+		}
+		card, ok := item.(*model.CardSecretItem)
+		if !ok {
+			continue
+		}
+		if card.IsExpired(now) {
+			c.view.ShowWarning(fmt.Sprintf("card \"%s\" (%s) expired %s", card.Name, model.MaskCardNumber(card.Number), card.Expiry()))
+		} else if card.ExpiresSoon(now) {
+			c.view.ShowWarning(fmt.Sprintf("card \"%s\" (%s) expires at the end of %s", card.Name, model.MaskCardNumber(card.Number), card.Expiry()))
+		}
+	}
+}
+
+// GetSecret get decoded secret item by name.
+func (c *GophkeeperController) GetSecret(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	nameIndex, err := c.encoder.NameIndex(name)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	var remoteSyncData dto.SecretSyncMetadata
+	syncErr := errs.ErrServerIsNotAvailable
+	if !c.offline.Load() {
+		remoteSyncData, syncErr = c.remoteStorage.GetSecretSyncMetaByName(ctx, nameIndex)
+		if syncErr != nil {
+			c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
+		}
+	}
+
+	localEncSecret, err := c.localStorage.GetSecretByName(ctx, nameIndex)
+	if err != nil && syncErr != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to find secret %s: %w", name, err))
+		return
+	}
+
+	//synchronization secret
+	if syncErr == nil && remoteSyncData.Hash != localEncSecret.Hash {
+		remoteSecret, err := c.remoteStorage.GetSecretByID(ctx, localEncSecret.ID)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
+		} else {
+			err = c.localStorage.SaveEncodedSecret(ctx, remoteSecret)
+			if err != nil {
+				c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
+			} else {
+				localEncSecret = remoteSecret
+			}
+		}
+	}
+
+	secretItem, err := localEncSecret.Decode(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
+		return
+	}
+	if c.encoder.IsOutdated(localEncSecret.EncodedContent) || !localEncSecret.IsInfoEncoded() || localEncSecret.IsHashOutdated() {
+		err = c.upgradeEncodedSecret(ctx, localEncSecret, secretItem)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to upgrade secret encoding: %w", err))
+		}
+	}
+	if secretItem.GetType() == model.Binary {
+		path := c.view.GetStringInput(ctx, "please enter the path where the decoded file will be saved:")
+		binarySecret, ok := secretItem.(*model.BinarySecretItem)
+		if !ok {
+			c.view.ShowError(fmt.Errorf("internal error: failed to complete type assertion"))
+			return
+		}
+		err := binarySecret.SetOutputPath(path)
+		if err != nil {
+			c.view.ShowError(err)
+			return
+		}
+		if binarySecret.IsChunked() {
+			if !c.requireOnline() {
+				return
+			}
+			err = c.downloadSecretChunks(ctx, localEncSecret.ID, binarySecret)
+			if err != nil {
+				c.view.ShowError(fmt.Errorf("failed to download file: %w", err))
+				return
+			}
+		}
+	}
+
+	if card, ok := secretItem.(*model.CardSecretItem); ok {
+		if c.view.GetConfirmation(ctx, "show full card number, CVV and PIN?") {
+			card.Reveal()
+		}
+	}
+
+	c.view.ShowSecretItem(secretItem)
+
+	attachments, err := c.listAttachments(ctx, localEncSecret.ID)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	if len(attachments) > 0 {
+		c.view.ShowAttachments(attachments)
+	}
+}
+
+// EditSecretCustomFields edits custom fields of secret item found by name, secret keeps its ID.
+func (c *GophkeeperController) EditSecretCustomFields(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	nameIndex, err := c.encoder.NameIndex(name)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	encodedSecret, err := c.localStorage.GetSecretByName(ctx, nameIndex)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to find secret %s: %w", name, err))
+		return
+	}
+	secretItem, err := encodedSecret.Decode(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
+		return
+	}
+	if _, ok := secretItem.(*model.OpaqueSecretItem); ok {
+		c.view.ShowError(model.ErrOpaqueSecretReadOnly)
+		return
+	}
+	fields := c.view.EditCustomFields(ctx, secretItem.GetCustomFields())
+	err = secretItem.SetCustomFields(fields)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	err = c.upgradeEncodedSecret(ctx, encodedSecret, secretItem)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to save secret: %w", err))
+	}
+}
+
+// EditSecretLabels moves secret item found by name to another folder and changes its tags, secret keeps its ID.
+func (c *GophkeeperController) EditSecretLabels(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	nameIndex, err := c.encoder.NameIndex(name)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	encodedSecret, err := c.localStorage.GetSecretByName(ctx, nameIndex)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to find secret %s: %w", name, err))
+		return
+	}
+	info, err := encodedSecret.DecodeInfo(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to decode secret info: %w", err))
+		return
+	}
+	secretItem, err := encodedSecret.Decode(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
+		return
+	}
+	labels := c.view.EditSecretLabels(ctx, info.SecretLabels)
+	err = c.replaceEncodedSecret(ctx, encodedSecret, secretItem, labels)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to save secret: %w", err))
+	}
+}
+
+// DeleteSecret deletes secret item.
+func (c *GophkeeperController) DeleteSecret(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	nameIndex, err := c.encoder.NameIndex(name)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	id, err := c.localStorage.DeleteSecretByName(ctx, nameIndex)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
+			return
+		}
+		c.view.ShowError(fmt.Errorf("failed to delete secret: %w", err))
+		return
+	}
+	err = c.pushSecretChange(ctx, id, dto.PendingChangeDelete, func() error {
+		return c.remoteStorage.DeleteSecret(ctx, id)
+	})
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+}
+
+// AddAttachment encrypts file specified by path and attaches it to secret item found by name.
+func (c *GophkeeperController) AddAttachment(ctx context.Context, name, path string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	encodedSecret, ok := c.findSecretByName(ctx, name)
+	if !ok {
+		return
+	}
+	fileName, content, err := model.ReadAttachmentFile(path)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	attachment, err := model.NewEncodedAttachment(c.encoder.Encode, c.authMeta.id, encodedSecret.ID, model.NewSecretID(), fileName, content)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	attachment.KeyVersion = c.authMeta.keyVersion
+	err = c.localStorage.SaveAttachment(ctx, attachment)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to locally store attachment: %w", err))
+		return
+	}
+	err = c.pushSecretChange(ctx, attachment.ID, dto.PendingChangeSaveAttachment, func() error {
+		return c.remoteStorage.SaveAttachment(ctx, attachment)
+	})
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+}
+
+// DownloadAttachment decodes chosen attachment of secret item found by name and saves it into directory,
+// attachment is downloaded from server on first use and kept locally afterwards.
+func (c *GophkeeperController) DownloadAttachment(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	attachmentID, ok := c.selectAttachment(ctx, name)
+	if !ok {
+		return
+	}
+	attachment, err := c.loadAttachment(ctx, attachmentID)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to download attachment: %w", err))
+		return
+	}
+	fileName, err := attachment.DecodeName(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	content, err := attachment.DecodeContent(c.encoder.Decode)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	dir := c.view.GetStringInput(ctx, "please enter the path where the decoded file will be saved:")
+	_, err = model.WriteAttachmentFile(dir, fileName, content)
+	if err != nil {
+		c.view.ShowError(err)
+	}
+}
+
+// DeleteAttachment deletes chosen attachment of secret item found by name.
+func (c *GophkeeperController) DeleteAttachment(ctx context.Context, name string) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	attachmentID, ok := c.selectAttachment(ctx, name)
+	if !ok {
+		return
+	}
+	err := c.localStorage.DeleteAttachment(ctx, attachmentID)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to delete attachment: %w", err))
+		return
+	}
+	err = c.pushSecretChange(ctx, attachmentID, dto.PendingChangeDeleteAttachment, func() error {
+		return c.remoteStorage.DeleteAttachment(ctx, attachmentID)
+	})
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	}
+}
+
+// findSecretByName returns locally stored secret by name, shows error if secret is not found.
+func (c *GophkeeperController) findSecretByName(ctx context.Context, name string) (model.EncodedSecret, bool) {
+	nameIndex, err := c.encoder.NameIndex(name)
+	if err != nil {
+		c.view.ShowError(err)
+		return model.EncodedSecret{}, false
+	}
+	encodedSecret, err := c.localStorage.GetSecretByName(ctx, nameIndex)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
+			return model.EncodedSecret{}, false
+		}
+		c.view.ShowError(fmt.Errorf("failed to find secret %s: %w", name, err))
+		return model.EncodedSecret{}, false
+	}
+	return encodedSecret, true
+}
+
+// selectAttachment lets user choose attachment of secret item found by name, returns attachment ID.
+func (c *GophkeeperController) selectAttachment(ctx context.Context, name string) (string, bool) {
+	encodedSecret, ok := c.findSecretByName(ctx, name)
+	if !ok {
+		return "", false
+	}
+	attachments, err := c.listAttachments(ctx, encodedSecret.ID)
+	if err != nil {
+		c.view.ShowError(err)
+		return "", false
+	}
+	if len(attachments) == 0 {
+		c.view.ShowError(fmt.Errorf("secret \"%s\" has no attachments", name))
+		return "", false
+	}
+	return c.view.SelectAttachment(ctx, attachments)
+}
+
+// listAttachments returns decoded information about attachments of secret, content is not loaded.
+func (c *GophkeeperController) listAttachments(ctx context.Context, secretID string) ([]dto.AttachmentInfo, error) {
+	attachments, err := c.localStorage.GetAttachmentsBySecretID(ctx, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	infos := make([]dto.AttachmentInfo, 0, len(attachments))
+	for _, attachment := range attachments {
+		name, err := attachment.DecodeName(c.encoder.Decode)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, dto.AttachmentInfo{ID: attachment.ID, Name: name, Size: attachment.Size})
+	}
+	return infos, nil
+}
+
+// loadAttachment returns attachment with content, content is downloaded from server if it is not stored locally.
+func (c *GophkeeperController) loadAttachment(ctx context.Context, id string) (model.EncodedAttachment, error) {
+	attachment, err := c.localStorage.GetAttachmentByID(ctx, id)
+	if err != nil {
+		return model.EncodedAttachment{}, err
+	}
+	if attachment.IsDownloaded() {
+		return attachment, nil
+	}
+	if c.offline.Load() {
+		return model.EncodedAttachment{}, errs.ErrServerIsNotAvailable
+	}
+	remote, err := c.remoteStorage.GetAttachment(ctx, id)
+	if err != nil {
+		return model.EncodedAttachment{}, err
+	}
+	if remote.SecretID != attachment.SecretID || !remote.IsDownloaded() {
+		return model.EncodedAttachment{}, errors.New("server returned attachment which does not match requested one")
+	}
+	err = c.localStorage.SaveAttachment(ctx, remote)
+	if err != nil {
+		return model.EncodedAttachment{}, err
+	}
+	return remote, nil
+}
+
+// StartSSHAgent serves SSH keys of user over Unix socket with ssh-agent protocol, so ssh uses them
+// without keys being written to disk. Agent is stopped when vault is locked or user logs out.
+func (c *GophkeeperController) StartSSHAgent(ctx context.Context) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	if !c.confirmPasswordChange(ctx) {
+		return
+	}
+	c.stopSSHAgent()
+	encodedInfos, err := c.localStorage.GetAllSecretsItemInfoByUserID(ctx, c.authMeta.id)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to get secrets: %w", err))
+		return
+	}
+	sshAgent := sshagent.NewSSHAgent(c.sshAgentSocketPath)
+	keysCount := 0
+	for _, encodedInfo := range encodedInfos {
+		if encodedInfo.Type != model.SSHKey {
+			continue
+		}
+		encodedSecret, err := c.localStorage.GetSecretByID(ctx, encodedInfo.ID)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to get secret: %w", err))
+			continue
+		}
+		item, err := encodedSecret.Decode(c.encoder.Decode)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
+			continue
+		}
+		sshKey, ok := item.(*model.SSHKeySecretItem)
+		if !ok {
+			c.view.ShowError(fmt.Errorf("internal error: failed to complete type assertion"))
+			continue
+		}
+		err = sshAgent.AddKey(sshKey)
+		if err != nil {
+			c.view.ShowError(fmt.Errorf("failed to add SSH key \"%s\": %w", sshKey.Name, err))
+			continue
+		}
+		keysCount++
+	}
+	if keysCount == 0 {
+		c.view.ShowError(errors.New("there are no SSH keys to serve"))
+		return
+	}
+	err = sshAgent.Start(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to start ssh-agent: %w", err))
+		return
+	}
+	c.sshAgent = sshAgent
+	c.view.ShowSSHAgentStarted(sshAgent.SocketPath(), keysCount)
+}
+
+// stopSSHAgent stops ssh-agent and removes keys from its memory.
+func (c *GophkeeperController) stopSSHAgent() {
+	if c.sshAgent != nil {
+		c.sshAgent.Stop()
+		c.sshAgent = nil
+	}
+}
+
+// UnAuthorize ends the current user session.
+func (c *GophkeeperController) UnAuthorize() {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	c.unauthorize()
+}
+
+// unauthorize ends the current user session, caller holds vaultMu.
+func (c *GophkeeperController) unauthorize() {
+	c.stopIdleTimer()
+	c.encoder.Lock()
+	c.stopSSHAgent()
+	c.authMeta = authorizationMeta{}
+	c.passwordChanged.Store(false)
+	c.locked.Store(false)
+	c.offline.Store(false)
+	c.twoFactorRequired.Store(false)
+	c.twoFactorNotice.Store(false)
+	c.view.SetAuthorized(false)
+}
+
+// Synchronize synchronize all secret metadata between client and backend.
+// Secrets received from other devices in outdated format are re-encoded if vault is unlocked.
+func (c *GophkeeperController) Synchronize(ctx context.Context) {
+	if !c.acquireVault(ctx) {
+		return
+	}
+	defer c.releaseVault()
+	err := c.synchronizeSecretItems(ctx)
+	if err != nil {
+		c.view.ShowError(err)
+		return
+	}
+	if c.authMeta.id == 0 || c.locked.Load() || c.passwordChanged.Load() {
+		return
+	}
+	err = c.upgradeLegacySecrets(ctx)
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to upgrade secrets encoding: %w", err))
+	}
+}
+
+func pinValidation(pin, repeatedPin string) error {
+	if pin != repeatedPin {
+		return errors.New("PINs is not equal")
+	}
+	if len(pin) < minPinLength {
+		return fmt.Errorf("PIN must contain at least %d digits", minPinLength)
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return errors.New("PIN must contain only digits")
+		}
+	}
+	return nil
+}
+
+func passwordValidation(password, repeatedPassword string) error {
+	if password != repeatedPassword {
+		return errors.New("passwords is not equal")
+	}
+	var size, number bool
+
+	letters := 0
+	for _, c := range password {
+		switch {
+		case unicode.IsNumber(c):
+			number = true
+		case unicode.IsLetter(c):
+			letters++
+		default:
+		}
+	}
+	size = letters >= 3
+
+	if !size || !number {
+		return errors.New("password must contains at least 8 characters and 1 number")
+	}
+
+	return nil
+}
+
+// newRecoveryKit generates recovery key and wraps vault key with it.
+func (c *GophkeeperController) newRecoveryKit(vaultKey []byte) (string, model.RecoveryKit, error) {
+	recoveryKey, err := c.encoder.NewRecoveryKey()
+	if err != nil {
+		return "", model.RecoveryKit{}, err
+	}
+	recoveryKit, err := c.encoder.NewRecoveryKit(recoveryKey, vaultKey)
+	if err != nil {
+		return "", model.RecoveryKit{}, fmt.Errorf("failed to wrap vault key with recovery key: %w", err)
+	}
+	return recoveryKey, recoveryKit, nil
+}
+
+// unlockVault sets encoder key from users wrapped vault key,
+// accounts without vault key use key derived from password directly.
+func (c *GophkeeperController) unlockVault(ctx context.Context, password string, user model.User) error {
+	if len(user.WrappedVaultKey) == 0 {
+		err := c.encoder.SetSecretKey(password, user.KDF)
+		if err != nil {
+			return err
+		}
+		return c.rejectOutdatedEnvelopes(ctx, c.encoder, user.ID)
+	}
+	vaultKey, err := c.encoder.UnwrapKey(password, user.KDF, user.WrappedVaultKey)
+	if err != nil {
+		return fmt.Errorf("failed to unlock vault: %w", err)
+	}
+	if _, err = c.encoder.Verifier(); err == nil && user.KeyVersion > c.authMeta.keyVersion {
+		return c.switchVaultKey(ctx, vaultKey, user)
+	}
+	err = c.encoder.SetVaultKey(vaultKey, user.CipherSuite)
+	if err != nil {
+		return err
+	}
+	c.authMeta.keyVersion = user.KeyVersion
+	return c.rejectOutdatedEnvelopes(ctx, c.encoder, user.ID)
+}
+
+// switchVaultKey replaces unlocked vault key with key rotated on another device, local secrets and attachments
+// encoded with the previous key are re-encoded with the new one, otherwise server rejects them.
+func (c *GophkeeperController) switchVaultKey(ctx context.Context, vaultKey []byte, user model.User) error {
+	newEncoder, err := c.encoder.WithVaultKey(vaultKey, user.CipherSuite)
+	if err != nil {
+		return err
+	}
+	err = c.rejectOutdatedEnvelopes(ctx, newEncoder, user.ID)
+	if err != nil {
+		return err
+	}
+	err = c.reEncodeLocalItems(ctx, newEncoder, user.KeyVersion)
+	if err != nil {
+		// items which failed to re-encode can not be decoded with any available key, vault is switched anyway
+		c.view.ShowError(fmt.Errorf("failed to re-encode local changes with rotated vault key: %w", err))
+	}
+	c.encoder.Lock()
+	c.encoder = newEncoder
+	c.authMeta.keyVersion = user.KeyVersion
+	return nil
+}
+
+// unlockStoredVault unlocks vault with locally stored user if its vault key was rotated on another device since the last
+// session, so local changes encoded with the previous key are re-encoded when vault is unlocked with the new key.
+func (c *GophkeeperController) unlockStoredVault(ctx context.Context, password string, user model.User) {
+	localUser, err := c.localStorage.GetUserByID(ctx, user.ID)
+	if err != nil || len(localUser.WrappedVaultKey) == 0 || localUser.KeyVersion >= user.KeyVersion {
+		return
+	}
+	if c.unlockVault(ctx, password, localUser) != nil {
+		// password was changed too, previous key is not available
+		c.encoder.Lock()
+	}
+}
+
+// rejectOutdatedEnvelopes makes encoder reject formats without associated data if vault of user is upgraded.
+func (c *GophkeeperController) rejectOutdatedEnvelopes(ctx context.Context, encoder Encoder, userID int64) error {
+	upgraded, err := c.localStorage.IsVaultUpgraded(ctx, userID)
+	if err != nil && !errors.Is(errs.ErrItemNotFound, err) {
+		return err
+	}
+	if upgraded {
+		encoder.RejectOutdatedEnvelopes()
+	}
+	return nil
+}
+
+// markVaultUpgraded records that every secret is encoded with associated data, outdated formats are rejected from now on.
+func (c *GophkeeperController) markVaultUpgraded(ctx context.Context, encoder Encoder) error {
+	err := c.localStorage.SetVaultUpgraded(ctx, c.authMeta.id)
+	if err != nil {
+		return err
+	}
+	encoder.RejectOutdatedEnvelopes()
+	return nil
+}
+
+// synchronizeAuthMeta stores user locally, password hash is not needed on client and is not stored.
+func (c *GophkeeperController) synchronizeAuthMeta(ctx context.Context, user model.User) error {
+	user.HashedPassword = ""
+	localUser, err := c.localStorage.GetUserByID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return c.localStorage.SaveUser(ctx, user)
+		}
+		return err
+	}
+	if !user.EqualTo(localUser) {
+		return c.localStorage.UpdateUser(ctx, user)
+	}
+
+	return nil
+}
+
+// synchronizePasswordChange stores user updated by password change or vault key rotation made on another device,
+// marks that new password must be confirmed if vault can not be unlocked with the current one.
+func (c *GophkeeperController) synchronizePasswordChange(ctx context.Context) error {
+	localUser, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err != nil {
+		return err
+	}
+	user, changed, err := c.remoteStorage.GetPasswordChangeEvent(ctx, localUser.Timestamp)
+	if err != nil {
+		return err
+	}
+	if !changed || c.locked.Load() {
+		// change is stored after vault is unlocked again, previous vault key is needed to re-encode local changes
+		return nil
+	}
+	err = c.localStorage.UpdateUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	if c.unlockVault(ctx, c.authMeta.password, user) != nil {
+		c.passwordChanged.Store(true)
+		return nil
+	}
+	c.storeLocalVerifier(ctx)
+	return nil
+}
+
+// unlockVaultLocally unlocks vault without server, password is checked with locally stored verifier.
+func (c *GophkeeperController) unlockVaultLocally(ctx context.Context, password string, user model.User) error {
+	stored, err := c.localStorage.GetLocalVerifier(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 && len(user.WrappedVaultKey) == 0 {
+		// key derived from password directly can not be checked without verifier
+		return errors.New("offline unlock is not available until first login with server")
+	}
+	err = c.unlockVault(ctx, password, user)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		// wrapped vault key is authenticated, successful unwrap proves password
+		return nil
+	}
+	verifier, err := c.encoder.Verifier()
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(stored, verifier) {
+		c.encoder.Lock()
+		return errs.ErrorInvalidPassword
+	}
+	return nil
+}
+
+// storeLocalVerifier stores verifier of current vault key for offline unlock.
+func (c *GophkeeperController) storeLocalVerifier(ctx context.Context) {
+	verifier, err := c.encoder.Verifier()
+	if err == nil {
+		err = c.localStorage.SaveLocalVerifier(ctx, c.authMeta.id, verifier)
+	}
+	if err != nil {
+		c.view.ShowError(fmt.Errorf("failed to store verifier for offline unlock: %w", err))
+	}
+}
+
+// requireOnline reports whether server connection is established, operations which change account are not available offline.
+func (c *GophkeeperController) requireOnline() bool {
+	if c.offline.Load() {
+		c.view.ShowError(errors.New("operation is not available offline"))
+		return false
+	}
+	return true
+}
+
+// pushSecretChange sends change of secret to server, change is queued if server is not available.
+func (c *GophkeeperController) pushSecretChange(ctx context.Context, secretID, operation string, push func() error) error {
+	if !c.offline.Load() {
+		err := push()
+		if err == nil || !errors.Is(errs.ErrServerIsNotAvailable, err) {
+			return err
+		}
+	}
+	return c.localStorage.AddPendingChange(ctx, dto.PendingChange{
+		SecretID:  secretID,
+		Owner:     c.authMeta.id,
+		Operation: operation,
+		Timestamp: time.Now().UTC().UnixMilli(),
+	})
+}
+
+// reconnect logins on server after offline unlock, returns false if server is still not available.
+func (c *GophkeeperController) reconnect(ctx context.Context) (bool, error) {
+	if c.authMeta.password == "" || c.twoFactorRequired.Load() {
+		// vault is locked, login is retried after password is entered again
+		return false, nil
+	}
+	token, user, err := c.remoteLogin(ctx, c.authMeta.login, c.authMeta.password)
+	if err != nil {
+		if errors.Is(errs.ErrServerIsNotAvailable, err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to login user: %w", err)
+	}
+	if user.TwoFactorEnabled {
+		// reconnect runs in background, one-time password is asked only by interactive login,
+		// user is told once on the next vault operation
+		c.twoFactorRequired.Store(true)
+		c.twoFactorNotice.Store(true)
+		return false, nil
+	}
+	c.remoteStorage.SetAuthTokenForRequests(token)
+	err = c.synchronizeAuthMeta(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	err = c.unlockVault(ctx, c.authMeta.password, user)
+	if err != nil {
+		return false, err
+	}
+	c.storeLocalVerifier(ctx)
+	c.offline.Store(false)
+	return true, nil
+}
+
+// flushPendingChanges sends changes queued while server was not available. Change rejected by server stays queued
+// and does not stop the others, e.g. changes are rejected while vault key rotation is in progress.
+func (c *GophkeeperController) flushPendingChanges(ctx context.Context) error {
+	changes, err := c.localStorage.GetPendingChanges(ctx, c.authMeta.id)
+	if err != nil {
+		return err
+	}
+	var failed []error
+	for _, change := range changes {
+		err := c.pushPendingChange(ctx, change)
+		if err != nil {
+			if errors.Is(errs.ErrServerIsNotAvailable, err) {
+				return err
+			}
+			failed = append(failed, fmt.Errorf("failed to synchronize change of %s: %w", change.SecretID, err))
+			continue
+		}
+		err = c.localStorage.DeletePendingChange(ctx, change.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// pushPendingChange sends queued change to server.
+func (c *GophkeeperController) pushPendingChange(ctx context.Context, change dto.PendingChange) error {
+	switch change.Operation {
+	case dto.PendingChangeCreate, dto.PendingChangeSave:
+		encodedSecret, err := c.localStorage.GetSecretByID(ctx, change.SecretID)
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return c.remoteStorage.SaveEncodedSecret(ctx, encodedSecret)
+	case dto.PendingChangeDelete:
+		return c.remoteStorage.DeleteSecret(ctx, change.SecretID)
+	case dto.PendingChangeSaveAttachment:
+		attachment, err := c.localStorage.GetAttachmentByID(ctx, change.SecretID)
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = c.remoteStorage.SaveAttachment(ctx, attachment)
+		// secret of attachment was deleted on another device
+		if errors.Is(errs.ErrItemNotFound, err) {
+			return nil
+		}
+		return err
+	case dto.PendingChangeDeleteAttachment:
+		return c.remoteStorage.DeleteAttachment(ctx, change.SecretID)
+	}
+	return nil
+}
+
+// acquireVault starts secret operation, asks for the password if vault was locked due to inactivity.
+// Returns false if vault stays locked, otherwise releaseVault must be called after the operation.
+func (c *GophkeeperController) acquireVault(ctx context.Context) bool {
+	c.vaultMu.Lock()
+	c.stopIdleTimer()
+	if c.twoFactorNotice.CompareAndSwap(true, false) {
+		c.view.ShowWarning("two-factor authentication is required, login again to synchronize secrets")
+	}
+	if !c.locked.Load() {
+		return true
+	}
+	password := c.view.GetPasswordInput(ctx, "vault is locked due to inactivity, enter your password:")
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err == nil {
+		err = c.unlockVaultLocally(ctx, password, user)
+	}
+	if err != nil {
+		c.view.ShowError(err)
+		c.resetIdleTimer()
+		c.vaultMu.Unlock()
+		return false
+	}
+	c.authMeta.password = password
+	c.passwordChanged.Store(false)
+	c.locked.Store(false)
+	return true
+}
+
+// releaseVault ends secret operation and restarts inactivity timer.
+func (c *GophkeeperController) releaseVault() {
+	c.resetIdleTimer()
+	c.vaultMu.Unlock()
+}
+
+func (c *GophkeeperController) resetIdleTimer() {
+	if c.idleTimeout <= 0 || c.authMeta.id == 0 || c.locked.Load() {
+		return
+	}
+	if c.idleTimer == nil {
+		c.idleTimer = time.AfterFunc(c.idleTimeout, c.Lock)
+		return
+	}
+	c.idleTimer.Reset(c.idleTimeout)
+}
+
+func (c *GophkeeperController) stopIdleTimer() {
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+}
+
+// confirmPasswordChange asks for the new password if it was changed on another device,
+// ends the session when vault can not be unlocked with entered password.
+func (c *GophkeeperController) confirmPasswordChange(ctx context.Context) bool {
+	if !c.passwordChanged.Load() {
+		return true
+	}
+	password := c.view.GetPasswordInput(ctx, "password was changed on another device, enter the new password:")
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
+	if err == nil {
+		err = c.unlockVault(ctx, password, user)
+	}
+	if err != nil {
+		c.view.ShowError(err)
+		c.unauthorize()
+		return false
+	}
+	c.authMeta.password = password
+	c.passwordChanged.Store(false)
+	return true
 }
 
-// Login logins user.
-func (c *GophkeeperController) Login(ctx context.Context, login, password string) {
-	token, user, err := c.remoteStorage.Login(ctx, login, password)
+// closeBinarySecret removes temporary files of new binary secret.
+func (c *GophkeeperController) closeBinarySecret(item *model.BinarySecretItem) {
+	err := item.Close()
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to login user: %w", err))
-		return
+		c.view.ShowWarning(fmt.Sprintf("failed to remove temporary archive: %s", err))
 	}
-	err = c.synchronizeAuthMeta(ctx, user)
-	c.remoteStorage.SetAuthTokenForRequests(token)
+}
+
+// indexChunks splits file of new binary secret into chunks addressed with chunk key of vault.
+func (c *GophkeeperController) indexChunks(ctx context.Context, item *model.BinarySecretItem) error {
+	user, err := c.localStorage.GetUserByID(ctx, c.authMeta.id)
 	if err != nil {
-		c.view.ShowError(err)
-		return
+		return fmt.Errorf("failed to get user: %w", err)
 	}
-	c.authMeta = authorizationMeta{login: login, password: password, id: user.ID}
-	err = c.SynchronizeSecretItems(ctx)
+	key, err := c.encoder.ChunkKey()
 	if err != nil {
-		c.view.ShowError(err)
-		c.authMeta = authorizationMeta{}
-		return
+		return err
 	}
-	err = c.encoder.SetSecretKey(password)
+	return item.IndexChunks(key, user.CipherSuite)
+}
+
+// chunkEncoder returns encoder for chunks of binary secret.
+func (c *GophkeeperController) chunkEncoder(item *model.BinarySecretItem) (Encoder, error) {
+	encoder, err := c.encoder.WithVaultKey(item.ContentKey, item.CipherSuite)
 	if err != nil {
-		c.view.ShowError(err)
-		c.authMeta = authorizationMeta{}
-		return
+		return nil, fmt.Errorf("failed to init file key: %w", err)
 	}
-	c.view.SetAuthorized(true)
+	return encoder, nil
 }
 
-// Register registers user.
-func (c *GophkeeperController) Register(ctx context.Context, login, password, repeatedPassword string) {
-	err := passwordValidation(password, repeatedPassword)
+// uploadChunks uploads chunks of file of new binary secret which are not stored on server yet,
+// chunks of other secrets and chunks uploaded by interrupted attempt are not uploaded again.
+func (c *GophkeeperController) uploadChunks(ctx context.Context, item *model.BinarySecretItem) error {
+	encoder, err := c.chunkEncoder(item)
 	if err != nil {
-		c.view.ShowError(err)
-		return
+		return err
 	}
-	token, user, err := c.remoteStorage.Register(ctx, login, password)
+	for attempt := 1; ; attempt++ {
+		err = c.uploadMissingChunks(ctx, encoder, item)
+		if err == nil || attempt == chunkTransferAttempts || !errors.Is(errs.ErrServerIsNotAvailable, err) {
+			return err
+		}
+	}
+}
+
+func (c *GophkeeperController) uploadMissingChunks(ctx context.Context, encoder Encoder, item *model.BinarySecretItem) error {
+	missing, err := c.remoteStorage.GetMissingChunks(ctx, item.ChunkHashes())
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to register user: %w", err))
-		return
+		return err
 	}
-	err = c.localStorage.SaveUser(ctx, user)
+	if len(missing) == 0 {
+		return nil
+	}
+	reader, err := item.OpenChunkReader(encoder.Encode, c.authMeta.id, missing)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to localy save user: %w", err))
-		return
+		return err
 	}
-	c.remoteStorage.SetAuthTokenForRequests(token)
-	c.authMeta = authorizationMeta{login: login, password: password, id: user.ID}
-	c.view.SetAuthorized(true)
-	err = c.encoder.SetSecretKey(password)
+	defer reader.Close()
+	uploaded, err := c.remoteStorage.UploadChunks(ctx, reader.Next)
 	if err != nil {
-		c.view.ShowError(err)
-		return
+		return err
+	}
+	if uploaded != int64(len(missing)) {
+		return model.ErrIncompleteChunks
 	}
+	return nil
 }
 
-// SaveSecret saves secret item.
-func (c *GophkeeperController) SaveSecret(ctx context.Context, item model.SecretItem) {
-	encodedSecret, err := item.NewEncodedSecret(c.encoder.Encode, c.authMeta.id)
+// downloadSecretChunks writes file of chunked binary secret into output path, download is resumed from partial file.
+func (c *GophkeeperController) downloadSecretChunks(ctx context.Context, secretID string, item *model.BinarySecretItem) error {
+	encoder, err := c.chunkEncoder(item)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to encode secret: %w", err))
-		return
+		return err
 	}
-	err = c.localStorage.SaveEncodedSecret(ctx, encodedSecret)
+	writer, err := item.CreateChunkWriter(encoder.Decode, secretID, c.authMeta.id)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to locally store secret: %w", err))
-		return
+		return err
 	}
-	err = c.remoteStorage.SaveEncodedSecret(ctx, encodedSecret)
-	if err != nil {
-		if !errors.Is(errs.ErrServerIsNotAvailable, err) {
-			c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+	for attempt := 1; ; attempt++ {
+		err = c.remoteStorage.DownloadSecretChunks(ctx, secretID, writer.Offset(), writer.Write)
+		if err == nil || attempt == chunkTransferAttempts || !errors.Is(errs.ErrServerIsNotAvailable, err) {
+			break
 		}
-		c.view.ShowError(err)
 	}
+	closeErr := writer.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
 }
 
-// ListSecret shows all stored secrets of user.
-func (c *GophkeeperController) ListSecret(ctx context.Context) {
-	err := c.SynchronizeSecretItems(ctx)
+// encodeSecretItem encodes secret item content, name, description and labels with vault key of specified version.
+func (c *GophkeeperController) encodeSecretItem(encoder Encoder, item model.SecretItem, labels dto.SecretLabels, owner int64, id string, keyVersion int64) (model.EncodedSecret, error) {
+	encodedSecret, err := item.NewEncodedSecret(encoder.Encode, owner, id)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+		return model.EncodedSecret{}, err
 	}
-	secretItemsInfo, err := c.localStorage.GetAllSecretsItemInfoByUserID(ctx, c.authMeta.id)
+	encodedSecret.KeyVersion = keyVersion
+	err = encodedSecret.EncodeInfo(encoder.Encode, encoder.NameIndex)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to get secrets info: %w", err))
-		return
+		return model.EncodedSecret{}, err
+	}
+	err = encodedSecret.EncodeLabels(encoder.Encode, encoder.NameIndex, labels)
+	if err != nil {
+		return model.EncodedSecret{}, err
 	}
-	c.view.ViewSecretsInfoList(secretItemsInfo)
+	return encodedSecret, nil
 }
 
-// GetSecret get decoded secret item by name.
-func (c *GophkeeperController) GetSecret(ctx context.Context, name string) {
-	remoteSyncData, syncErr := c.remoteStorage.GetSecretSyncMetaByName(ctx, name)
-	if syncErr != nil {
-		c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
+// reEncodeSecrets re-encodes secrets encoded with vault key older than keyVersion with specified encoder,
+// secrets are processed in batches, already re-encoded secrets are skipped, so process can be resumed.
+func (c *GophkeeperController) reEncodeSecrets(ctx context.Context, encoder Encoder, keyVersion int64) error {
+	syncMetas, err := c.localStorage.GetSecretSyncMetaByOwnerID(ctx, c.authMeta.id)
+	if err != nil {
+		return err
 	}
-
-	localEncSecret, err := c.localStorage.GetSecretByName(ctx, name)
-	if err != nil && syncErr != nil {
-		if errors.Is(errs.ErrItemNotFound, err) {
-			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
-			return
+	for start := 0; start < len(syncMetas); start += rotationBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		c.view.ShowError(fmt.Errorf("failed to find secret %s: %w", name, err))
-		return
-	}
-
-	//synchronization secret
-	if syncErr == nil && remoteSyncData.Hash != localEncSecret.Hash {
-		remoteSecret, err := c.remoteStorage.GetSecretByID(ctx, localEncSecret.ID)
-		if err != nil {
-			c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
-		} else {
-			err = c.localStorage.SaveEncodedSecret(ctx, remoteSecret)
+		end := start + rotationBatchSize
+		if end > len(syncMetas) {
+			end = len(syncMetas)
+		}
+		for _, syncMeta := range syncMetas[start:end] {
+			encodedSecret, err := c.localStorage.GetSecretByID(ctx, syncMeta.ID)
 			if err != nil {
-				c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", syncErr))
-			} else {
-				localEncSecret = remoteSecret
+				return err
+			}
+			if encodedSecret.KeyVersion >= keyVersion {
+				continue
+			}
+			reEncoded, err := c.reEncodeSecret(encoder, encodedSecret, keyVersion)
+			if err != nil {
+				return err
+			}
+			err = c.remoteStorage.SaveEncodedSecret(ctx, reEncoded)
+			if err != nil {
+				return err
+			}
+			err = c.localStorage.SaveEncodedSecret(ctx, reEncoded)
+			if err != nil {
+				return err
 			}
 		}
 	}
+	return nil
+}
 
-	secretItem, err := localEncSecret.Decode(c.encoder.Decode)
+// reEncodeSecret decodes secret with current encoder and encodes it with specified one.
+func (c *GophkeeperController) reEncodeSecret(encoder Encoder, encodedSecret model.EncodedSecret, keyVersion int64) (model.EncodedSecret, error) {
+	item, err := encodedSecret.Decode(c.encoder.Decode)
 	if err != nil {
-		c.view.ShowError(fmt.Errorf("failed to decode secret: %w", err))
-		return
+		return model.EncodedSecret{}, err
 	}
-	if secretItem.GetType() == model.Binary {
-		path := c.view.GetStringInput(ctx, "please enter the path where the decoded file will be saved:")
-		binarySecret, ok := secretItem.(*model.BinarySecretItem)
-		if !ok {
-			c.view.ShowError(fmt.Errorf("internal error: failed to complete type assertion"))
-			return
-		}
-		err := binarySecret.SetOutputPath(path)
-		if err != nil {
-			c.view.ShowError(err)
-			return
-		}
+	labels, err := encodedSecret.DecodeLabels(c.encoder.Decode)
+	if err != nil {
+		return model.EncodedSecret{}, err
 	}
-
-	c.view.ShowSecretItem(secretItem)
+	return c.encodeSecretItem(encoder, item, labels, encodedSecret.Owner, encodedSecret.ID, keyVersion)
 }
 
-// DeleteSecret deletes secret item.
-func (c *GophkeeperController) DeleteSecret(ctx context.Context, name string) {
-	id, err := c.localStorage.DeleteSecretByName(ctx, name)
+// reEncodeLocalItems re-encodes local secrets and downloaded attachments encoded with vault key older than keyVersion,
+// time of modification is kept, so synchronization still prefers the copy changed last. Server copies are re-encoded
+// by device which rotated the key, attachments which are not downloaded are taken from server.
+func (c *GophkeeperController) reEncodeLocalItems(ctx context.Context, encoder Encoder, keyVersion int64) error {
+	syncMetas, err := c.localStorage.GetSecretSyncMetaByOwnerID(ctx, c.authMeta.id)
 	if err != nil {
-		if errors.Is(errs.ErrItemNotFound, err) {
-			c.view.ShowError(fmt.Errorf("secret with name \"%s\" not found", name))
-			return
+		return err
+	}
+	var failed []error
+	for _, syncMeta := range syncMetas {
+		encodedSecret, err := c.localStorage.GetSecretByID(ctx, syncMeta.ID)
+		if err != nil {
+			return err
+		}
+		if encodedSecret.KeyVersion >= keyVersion {
+			continue
+		}
+		reEncoded, err := c.reEncodeSecret(encoder, encodedSecret, keyVersion)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("secret %s: %w", encodedSecret.ID, err))
+			continue
+		}
+		reEncoded.Timestamp = encodedSecret.Timestamp
+		err = c.localStorage.SaveEncodedSecret(ctx, reEncoded)
+		if err != nil {
+			return err
 		}
-		c.view.ShowError(fmt.Errorf("failed to delete secret: %w", err))
-		return
 	}
-	err = c.remoteStorage.DeleteSecret(ctx, id)
+	attachments, err := c.localStorage.GetAttachmentsByOwnerID(ctx, c.authMeta.id)
 	if err != nil {
-		if !errors.Is(errs.ErrServerIsNotAvailable, err) {
-			c.view.ShowError(fmt.Errorf("synchronization operation failed: %w", err))
+		return err
+	}
+	for _, meta := range attachments {
+		if meta.KeyVersion >= keyVersion {
+			continue
+		}
+		attachment, err := c.localStorage.GetAttachmentByID(ctx, meta.ID)
+		if err != nil {
+			return err
+		}
+		if !attachment.IsDownloaded() {
+			continue
+		}
+		reEncoded, err := attachment.ReEncode(c.encoder.Decode, encoder.Encode)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("attachment %s: %w", attachment.ID, err))
+			continue
+		}
+		reEncoded.KeyVersion = keyVersion
+		reEncoded.Timestamp = attachment.Timestamp
+		err = c.localStorage.SaveAttachment(ctx, reEncoded)
+		if err != nil {
+			return err
 		}
-		c.view.ShowError(err)
 	}
+	return errors.Join(failed...)
 }
 
-// UnAuthorize ends the current user session.
-func (c *GophkeeperController) UnAuthorize() {
-	c.authMeta = authorizationMeta{}
-	c.view.SetAuthorized(false)
-}
-
-// Synchronize synchronize all secret metadata between client and backend.
-func (c *GophkeeperController) Synchronize(ctx context.Context) {
-	err := c.SynchronizeSecretItems(ctx)
+// reEncodeAttachments re-encodes attachments encoded with vault key older than keyVersion with specified encoder,
+// attachments which are not downloaded yet are downloaded first, already re-encoded attachments are skipped.
+func (c *GophkeeperController) reEncodeAttachments(ctx context.Context, encoder Encoder, keyVersion int64) error {
+	attachments, err := c.localStorage.GetAttachmentsByOwnerID(ctx, c.authMeta.id)
 	if err != nil {
-		c.view.ShowError(err)
+		return err
+	}
+	for _, meta := range attachments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if meta.KeyVersion >= keyVersion {
+			continue
+		}
+		attachment, err := c.loadAttachment(ctx, meta.ID)
+		if err != nil {
+			return err
+		}
+		reEncoded, err := attachment.ReEncode(c.encoder.Decode, encoder.Encode)
+		if err != nil {
+			return err
+		}
+		reEncoded.KeyVersion = keyVersion
+		err = c.remoteStorage.SaveAttachment(ctx, reEncoded)
+		if err != nil {
+			return err
+		}
+		err = c.localStorage.SaveAttachment(ctx, reEncoded)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func passwordValidation(password, repeatedPassword string) error {
-	if password != repeatedPassword {
-		return errors.New("passwords is not equal")
+// upgradeLegacySecrets re-encodes secrets stored with plaintext name and description, with hash of plaintext
+// or with content encoded without associated data, secrets with plaintext name can not be found by name until upgraded.
+// Once every secret is upgraded, vault is marked upgraded and outdated formats are rejected.
+func (c *GophkeeperController) upgradeLegacySecrets(ctx context.Context) error {
+	syncMetas, err := c.localStorage.GetSecretSyncMetaByOwnerID(ctx, c.authMeta.id)
+	if err != nil {
+		return err
 	}
-	var size, number bool
-
-	letters := 0
-	for _, c := range password {
-		switch {
-		case unicode.IsNumber(c):
-			number = true
-		case unicode.IsLetter(c):
-			letters++
-		default:
+	for _, syncMeta := range syncMetas {
+		encodedSecret, err := c.localStorage.GetSecretByID(ctx, syncMeta.ID)
+		if err != nil {
+			return err
+		}
+		if encodedSecret.IsInfoEncoded() && !encodedSecret.IsHashOutdated() && !c.encoder.IsOutdated(encodedSecret.EncodedContent) {
+			continue
+		}
+		item, err := encodedSecret.Decode(c.encoder.Decode)
+		if err != nil {
+			return err
+		}
+		err = c.upgradeEncodedSecret(ctx, encodedSecret, item)
+		if err != nil {
+			return err
 		}
 	}
-	size = letters >= 3
+	return c.markVaultUpgraded(ctx, c.encoder)
+}
 
-	if !size || !number {
-		return errors.New("password must contains at least 8 characters and 1 number")
+// upgradeEncodedSecret re-encodes secret stored in outdated format, keeps secret identifier and labels.
+func (c *GophkeeperController) upgradeEncodedSecret(ctx context.Context, outdated model.EncodedSecret, item model.SecretItem) error {
+	labels, err := outdated.DecodeLabels(c.encoder.Decode)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return c.replaceEncodedSecret(ctx, outdated, item, labels)
 }
 
-func (c *GophkeeperController) synchronizeAuthMeta(ctx context.Context, user model.User) error {
-	localUser, err := c.localStorage.GetUserByID(ctx, user.ID)
+// replaceEncodedSecret re-encodes secret with specified labels, keeps secret identifier.
+func (c *GophkeeperController) replaceEncodedSecret(ctx context.Context, outdated model.EncodedSecret, item model.SecretItem, labels dto.SecretLabels) error {
+	upgraded, err := c.encodeSecretItem(c.encoder, item, labels, outdated.Owner, outdated.ID, c.authMeta.keyVersion)
 	if err != nil {
-		if errors.Is(errs.ErrItemNotFound, err) {
-			return c.localStorage.SaveUser(ctx, user)
-		}
 		return err
 	}
-	if !user.EqualTo(localUser) {
-		return c.localStorage.UpdateUser(ctx, user)
+	err = c.localStorage.SaveEncodedSecret(ctx, upgraded)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return c.pushSecretChange(ctx, upgraded.ID, dto.PendingChangeSave, func() error {
+		return c.remoteStorage.SaveEncodedSecret(ctx, upgraded)
+	})
 }
 
+// SynchronizeSecretItems synchronizes secrets on schedule. It waits for running secret operation and is skipped
+// while vault is locked, so keys are neither wiped nor replaced while synchronization uses them.
 func (c *GophkeeperController) SynchronizeSecretItems(ctx context.Context) error {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	if c.locked.Load() {
+		return nil
+	}
+	return c.synchronizeSecretItems(ctx)
+}
+
+// synchronizeSecretItems synchronizes secrets between local storage and server, caller holds vaultMu.
+func (c *GophkeeperController) synchronizeSecretItems(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 		if c.authMeta.id != 0 {
+			if c.offline.Load() {
+				online, err := c.reconnect(ctx)
+				if err != nil || !online {
+					return err
+				}
+			}
+			// vault key rotated on another device is pulled first, local changes are re-encoded with it before upload
+			err := c.synchronizePasswordChange(ctx)
+			if err != nil {
+				return err
+			}
+			flushErr := c.flushPendingChanges(ctx)
+			if errors.Is(errs.ErrServerIsNotAvailable, flushErr) {
+				return flushErr
+			}
 			remoteSyncMetadata, err := c.remoteStorage.GetSecretSyncMeta(ctx)
 			if err != nil {
 				return err
@@ -407,8 +2451,72 @@ func (c *GophkeeperController) SynchronizeSecretItems(ctx context.Context) error
 					}
 				}
 			}
-			return nil
+			return errors.Join(flushErr, c.synchronizeAttachments(ctx))
 		}
 		return nil
 	}
 }
+
+// synchronizeAttachments synchronizes metadata of attachments only, content is downloaded when attachment is opened.
+// Attachment changed on server drops local content, local attachment unknown to server is uploaded
+// if its content is stored locally, otherwise it was deleted on server and is deleted locally too.
+func (c *GophkeeperController) synchronizeAttachments(ctx context.Context) error {
+	remoteAttachments, err := c.remoteStorage.GetAttachmentsMeta(ctx)
+	if err != nil {
+		return err
+	}
+	localAttachments, err := c.localStorage.GetAttachmentsByOwnerID(ctx, c.authMeta.id)
+	if err != nil {
+		return err
+	}
+	localAttachmentsMap := make(map[string]model.EncodedAttachment, len(localAttachments))
+	for _, attachment := range localAttachments {
+		localAttachmentsMap[attachment.ID] = attachment
+	}
+
+	for _, remoteAttachment := range remoteAttachments {
+		localAttachment, contains := localAttachmentsMap[remoteAttachment.ID]
+		delete(localAttachmentsMap, remoteAttachment.ID)
+		if contains && remoteAttachment.Hash == localAttachment.Hash {
+			continue
+		}
+		if contains && remoteAttachment.Timestamp <= localAttachment.Timestamp {
+			localAttachment, err = c.localStorage.GetAttachmentByID(ctx, localAttachment.ID)
+			if err != nil {
+				return err
+			}
+			if localAttachment.IsDownloaded() {
+				err = c.remoteStorage.SaveAttachment(ctx, localAttachment)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		remoteAttachment.EncodedContent = nil
+		err = c.localStorage.SaveAttachment(ctx, remoteAttachment)
+		if err != nil {
+			return err
+		}
+	}
+
+	for id := range localAttachmentsMap {
+		err = c.uploadLocalAttachment(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadLocalAttachment uploads attachment stored locally, attachment without content is deleted locally.
+func (c *GophkeeperController) uploadLocalAttachment(ctx context.Context, id string) error {
+	attachment, err := c.localStorage.GetAttachmentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !attachment.IsDownloaded() {
+		return c.localStorage.DeleteAttachment(ctx, id)
+	}
+	return c.remoteStorage.SaveAttachment(ctx, attachment)
+}
@@ -0,0 +1,60 @@
+package encoder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	errs "github.com/apolsh/yapr-gophkeeper/internal/model/app_errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// cipherSuite AEAD algorithm which can be used for secrets encryption.
+type cipherSuite struct {
+	// id identifier of suite recorded in envelope.
+	id byte
+	// name of suite stored in user settings.
+	name string
+	// newAEAD creates AEAD instance for 32 bytes key.
+	newAEAD func(key []byte) (cipher.AEAD, error)
+}
+
+// cipherSuites registry of supported cipher suites, the first one is default.
+var cipherSuites = []cipherSuite{
+	{id: suiteAESGCM, name: model.CipherSuiteAESGCM, newAEAD: newAESGCM},
+	{id: suiteXChaCha20Poly1305, name: model.CipherSuiteXChaCha20Poly1305, newAEAD: chacha20poly1305.NewX},
+}
+
+// cipherSuiteByName returns cipher suite by its name, empty name means default suite.
+func cipherSuiteByName(name string) (cipherSuite, error) {
+	if name == "" {
+		return cipherSuites[0], nil
+	}
+	for _, suite := range cipherSuites {
+		if suite.name == name {
+			return suite, nil
+		}
+	}
+	return cipherSuite{}, errs.ErrorUnknownCipherSuite
+}
+
+// newAEADs creates AEAD instances of every registered suite for key, mapped by suite id.
+func newAEADs(key []byte) (map[byte]cipher.AEAD, error) {
+	aeads := make(map[byte]cipher.AEAD, len(cipherSuites))
+	for _, suite := range cipherSuites {
+		aead, err := suite.newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		aeads[suite.id] = aead
+	}
+	return aeads, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	aesBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(aesBlock)
+}
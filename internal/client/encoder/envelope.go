@@ -0,0 +1,80 @@
+package encoder
+
+import (
+	"errors"
+)
+
+// Envelope layout:
+//
+//	| version (1) | cipher suite (1) | kdf (1) | nonce (suite nonce size) | ciphertext with tag |
+//
+// Version 1 ciphertext is sealed without associated data,
+// version 2 ciphertext is authenticated against associated data supplied by caller.
+const (
+	envelopeVersion1 byte = 1
+	envelopeVersion2 byte = 2
+
+	envelopeHeaderSize = 3
+)
+
+const (
+	// suiteAESGCM AES-256 with Galois/Counter Mode.
+	suiteAESGCM byte = 1
+	// suiteXChaCha20Poly1305 XChaCha20-Poly1305 with 24 bytes nonce.
+	suiteXChaCha20Poly1305 byte = 2
+)
+
+const (
+	// kdfNone key is random vault key, no derivation is used.
+	kdfNone byte = 0
+	// kdfSHA256 key is a single SHA-256 hash of the password.
+	kdfSHA256 byte = 1
+	// kdfArgon2id key is derived with Argon2id and per user salt.
+	kdfArgon2id byte = 2
+)
+
+// ErrMalformedEnvelope appears when encoded data is not a valid envelope.
+var ErrMalformedEnvelope = errors.New("malformed ciphertext envelope")
+
+type envelope struct {
+	version    byte
+	suite      byte
+	kdf        byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+func (e envelope) marshal() []byte {
+	out := make([]byte, 0, envelopeHeaderSize+len(e.nonce)+len(e.ciphertext))
+	out = append(out, e.version, e.suite, e.kdf)
+	out = append(out, e.nonce...)
+	out = append(out, e.ciphertext...)
+	return out
+}
+
+// envelopeSuite returns identifier of cipher suite envelope was sealed with.
+func envelopeSuite(data []byte) (byte, error) {
+	if len(data) < envelopeHeaderSize {
+		return 0, ErrMalformedEnvelope
+	}
+	if data[0] != envelopeVersion1 && data[0] != envelopeVersion2 {
+		return 0, ErrMalformedEnvelope
+	}
+	return data[1], nil
+}
+
+func parseEnvelope(data []byte, nonceSize, overhead int) (envelope, error) {
+	if _, err := envelopeSuite(data); err != nil {
+		return envelope{}, err
+	}
+	if len(data) < envelopeHeaderSize+nonceSize+overhead {
+		return envelope{}, ErrMalformedEnvelope
+	}
+	return envelope{
+		version:    data[0],
+		suite:      data[1],
+		kdf:        data[2],
+		nonce:      data[envelopeHeaderSize : envelopeHeaderSize+nonceSize],
+		ciphertext: data[envelopeHeaderSize+nonceSize:],
+	}, nil
+}
@@ -0,0 +1,36 @@
+package encoder
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	"golang.org/x/crypto/argon2"
+)
+
+const keySize = 32
+
+// NewKDFParams generates key derivation parameters with random salt for new user.
+func NewKDFParams() (model.KDFParams, error) {
+	salt := make([]byte, model.DefaultKDFSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return model.KDFParams{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return model.NewArgon2idParams(salt), nil
+}
+
+// deriveKey derives encryption key from password, returns key and KDF identifier for envelope.
+func deriveKey(password string, params model.KDFParams) ([]byte, byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, 0, err
+	}
+	switch params.Algorithm {
+	case model.KDFArgon2id:
+		key := argon2.IDKey([]byte(password), params.Salt, params.Time, params.Memory, params.Threads, keySize)
+		return key, kdfArgon2id, nil
+	default:
+		hashedKey := sha256.Sum256([]byte(password))
+		return hashedKey[:], kdfSHA256, nil
+	}
+}
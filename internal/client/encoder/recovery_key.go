@@ -0,0 +1,104 @@
+package encoder
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+)
+
+const (
+	// recoveryKeyGroupSize number of characters between separators of printed recovery key.
+	recoveryKeyGroupSize = 4
+	recoveryKeySeparator = "-"
+
+	recoveryWrapLabel = "gophkeeper recovery key wrap"
+	recoveryAuthLabel = "gophkeeper recovery key auth"
+)
+
+// recoveryKeyEncoding alphabet of printed recovery key, base32 has no easily confused digits 0, 1 and 8.
+var recoveryKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidRecoveryKey appears when recovery key has wrong format.
+var ErrInvalidRecoveryKey = errors.New("recovery key has wrong format")
+
+// NewRecoveryKey generates random recovery key in printable form.
+func (s *SecretItemEncoder) NewRecoveryKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+	encoded := recoveryKeyEncoding.EncodeToString(key)
+	groups := make([]string, 0, len(encoded)/recoveryKeyGroupSize+1)
+	for len(encoded) > recoveryKeyGroupSize {
+		groups = append(groups, encoded[:recoveryKeyGroupSize])
+		encoded = encoded[recoveryKeyGroupSize:]
+	}
+	groups = append(groups, encoded)
+	return strings.Join(groups, recoveryKeySeparator), nil
+}
+
+// NewRecoveryKit wraps vault key with recovery key and derives key proving possession of recovery key.
+func (s *SecretItemEncoder) NewRecoveryKit(recoveryKey string, vaultKey []byte) (model.RecoveryKit, error) {
+	key, err := parseRecoveryKey(recoveryKey)
+	if err != nil {
+		return model.RecoveryKit{}, err
+	}
+	aead, err := newAESGCM(deriveSubKey(key, recoveryWrapLabel))
+	if err != nil {
+		return model.RecoveryKit{}, err
+	}
+	wrapped, err := seal(aead, suiteAESGCM, kdfNone, vaultKey, nil)
+	if err != nil {
+		return model.RecoveryKit{}, err
+	}
+	return model.RecoveryKit{
+		AuthKey:         recoveryAuthKey(key),
+		WrappedVaultKey: wrapped,
+	}, nil
+}
+
+// RecoveryAuthKey returns key proving possession of recovery key, recovery key itself is never sent to server.
+func (s *SecretItemEncoder) RecoveryAuthKey(recoveryKey string) (string, error) {
+	key, err := parseRecoveryKey(recoveryKey)
+	if err != nil {
+		return "", err
+	}
+	return recoveryAuthKey(key), nil
+}
+
+// UnwrapKeyWithRecoveryKey decrypts vault key wrapped with recovery key.
+func (s *SecretItemEncoder) UnwrapKeyWithRecoveryKey(recoveryKey string, wrappedKey []byte) ([]byte, error) {
+	key, err := parseRecoveryKey(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+	aeads, err := newAEADs(deriveSubKey(key, recoveryWrapLabel))
+	if err != nil {
+		return nil, err
+	}
+	vaultKey, err := open(aeads, kdfNone, wrappedKey, nil)
+	if err != nil {
+		return nil, ErrFailedToUnwrapKey
+	}
+	return vaultKey, nil
+}
+
+// parseRecoveryKey decodes printed recovery key, separators, spaces and case are ignored.
+func parseRecoveryKey(recoveryKey string) ([]byte, error) {
+	normalized := strings.ToUpper(recoveryKey)
+	normalized = strings.NewReplacer(recoveryKeySeparator, "", " ", "").Replace(normalized)
+	key, err := recoveryKeyEncoding.DecodeString(normalized)
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidRecoveryKey
+	}
+	return key, nil
+}
+
+func recoveryAuthKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(deriveSubKey(key, recoveryAuthLabel))
+}
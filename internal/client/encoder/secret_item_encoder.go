@@ -1,71 +1,337 @@
 package encoder
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 
 	"github.com/apolsh/yapr-gophkeeper/internal/client/controller"
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
 )
 
-// AESGMCEncoder implementation of Encoder with AES with Galois/Counter Mode (AES-GCM).
-type AESGMCEncoder struct {
-	ready   bool
-	encoder cipher.AEAD
-	nonce   []byte
+// SecretItemEncoder implementation of Encoder with pluggable AEAD cipher suites,
+// AES-256-GCM and XChaCha20-Poly1305 are supported.
+type SecretItemEncoder struct {
+	ready bool
+	// suite used for encoding, decoding dispatches on suite recorded in envelope.
+	suite cipherSuite
+	// key encoder key, AEAD instances are created from it for every call and are not kept,
+	// so Lock wipes every copy of key material held by encoder.
+	key []byte
+	kdf byte
+	// legacyNonce fixed nonce used by blobs encoded before envelope was introduced.
+	legacyNonce []byte
+	// indexKey key for blind index of secret names, derived from encoder key.
+	indexKey []byte
+	// verifier value derived from encoder key, allows to check password without server.
+	verifier []byte
+	// chunkKey key for content-defined chunks of files, derived from encoder key.
+	chunkKey []byte
+	// rejectOutdated disables decoding of formats encoded without associated data.
+	rejectOutdated bool
 }
 
+// Domain separation labels for keys derived from encoder key and from password.
+const (
+	nameIndexLabel = "gophkeeper secret name index"
+	verifierLabel  = "gophkeeper local verifier"
+	chunkKeyLabel  = "gophkeeper chunk key"
+	authKeyLabel   = "gophkeeper auth key"
+)
+
 var (
 	// ErrFailedToDecode appears when failed to decode encoded data.
 	ErrFailedToDecode = errors.New("failed to decode encoded data")
 	// ErrEncoderIsNotInitialized appears when encoder is not initialized, run SetSecretKey first.
 	ErrEncoderIsNotInitialized = errors.New("encoder is not initialized, run SetSecretKey first")
+	// ErrUnsupportedEnvelope appears when envelope was produced with unknown algorithm.
+	ErrUnsupportedEnvelope = errors.New("unsupported cipher suite or kdf")
+	// ErrFailedToUnwrapKey appears when wrapped key can not be opened with specified password.
+	ErrFailedToUnwrapKey = errors.New("failed to unwrap vault key, password is incorrect or key is corrupted")
+	// ErrOutdatedEnvelope appears when data encoded without associated data is decoded after vault was upgraded.
+	ErrOutdatedEnvelope = errors.New("data is encoded in outdated format which is not accepted by upgraded vault")
 )
 
-var _ controller.Encoder = (*AESGMCEncoder)(nil)
+var _ controller.Encoder = (*SecretItemEncoder)(nil)
 
-// Encode encodes byte.
-func (s *AESGMCEncoder) Encode(byteToEncode []byte) ([]byte, error) {
+// Encode encodes bytes, every call uses fresh random nonce,
+// ciphertext is authenticated against associatedData.
+func (s *SecretItemEncoder) Encode(byteToEncode, associatedData []byte) ([]byte, error) {
 	if !s.ready {
 		return nil, ErrEncoderIsNotInitialized
 	}
-	encoded := s.encoder.Seal(nil, s.nonce, byteToEncode, nil)
-	return encoded, nil
+	aead, err := s.suite.newAEAD(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, s.suite.id, s.kdf, byteToEncode, associatedData)
 }
 
-// Decode decodes bytes.
-func (s *AESGMCEncoder) Decode(byteToDecode []byte) ([]byte, error) {
+// Decode decodes bytes, supports both envelope and legacy fixed nonce formats.
+// Outdated formats were encoded without associated data, so associatedData is checked only for current envelope,
+// outdated formats are rejected after RejectOutdatedEnvelopes is called.
+func (s *SecretItemEncoder) Decode(byteToDecode, associatedData []byte) ([]byte, error) {
 	if !s.ready {
 		return nil, ErrEncoderIsNotInitialized
 	}
-	decoded, err := s.encoder.Open(nil, s.nonce, byteToDecode, nil) // расшифровываем
+	aeads, err := newAEADs(s.key)
+	if err != nil {
+		return nil, err
+	}
+	if s.rejectOutdated {
+		if len(byteToDecode) == 0 || byteToDecode[0] != envelopeVersion2 {
+			return nil, ErrOutdatedEnvelope
+		}
+		return open(aeads, s.kdf, byteToDecode, associatedData)
+	}
+	decoded, err := open(aeads, s.kdf, byteToDecode, associatedData)
+	if err == nil {
+		return decoded, nil
+	}
+	if s.legacyNonce == nil {
+		return nil, err
+	}
+	decoded, err = aeads[suiteAESGCM].Open(nil, s.legacyNonce, byteToDecode, nil)
 	if err != nil {
-		fmt.Printf("error: %v\n", err)
 		return nil, ErrFailedToDecode
 	}
 	return decoded, nil
 }
 
-// SetSecretKey sets secret key for encoder.
-func (s *AESGMCEncoder) SetSecretKey(secretKey string) error {
-	hashedKey := sha256.Sum256([]byte(secretKey))
+// IsOutdated reports whether bytes were encoded in outdated format and should be re-encoded.
+func (s *SecretItemEncoder) IsOutdated(encoded []byte) bool {
+	if !s.ready {
+		return false
+	}
+	aead, err := s.suite.newAEAD(s.key)
+	if err != nil {
+		return true
+	}
+	env, err := parseEnvelope(encoded, aead.NonceSize(), aead.Overhead())
+	if err != nil {
+		return true
+	}
+	return env.version != envelopeVersion2 || env.suite != s.suite.id || env.kdf != s.kdf
+}
+
+// RejectOutdatedEnvelopes makes Decode fail for formats encoded without associated data, it is called
+// once every secret of vault is re-encoded, so ciphertext of outdated format can not be substituted.
+// Setting of another key resets it.
+func (s *SecretItemEncoder) RejectOutdatedEnvelopes() {
+	s.rejectOutdated = true
+}
+
+// NameIndex returns keyed blind index of secret name, so server can look secret up without knowing its name.
+func (s *SecretItemEncoder) NameIndex(name string) (string, error) {
+	if !s.ready {
+		return "", ErrEncoderIsNotInitialized
+	}
+	mac := hmac.New(sha256.New, s.indexKey)
+	mac.Write([]byte(name))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verifier returns value derived from encoder key, it is stored locally to check password without server.
+func (s *SecretItemEncoder) Verifier() ([]byte, error) {
+	if !s.ready {
+		return nil, ErrEncoderIsNotInitialized
+	}
+	// copy outlives Lock, which wipes the verifier
+	return append([]byte(nil), s.verifier...), nil
+}
+
+// ChunkKey returns key derived from encoder key, chunks of files are addressed and encrypted with it,
+// so identical chunks of different files have the same address.
+func (s *SecretItemEncoder) ChunkKey() ([]byte, error) {
+	if !s.ready {
+		return nil, ErrEncoderIsNotInitialized
+	}
+	// copy outlives Lock, which wipes the key
+	return append([]byte(nil), s.chunkKey...), nil
+}
+
+// NewKDFParams generates key derivation parameters with random salt for new user.
+func (s *SecretItemEncoder) NewKDFParams() (model.KDFParams, error) {
+	return NewKDFParams()
+}
+
+// NewVaultKey generates random vault key.
+func (s *SecretItemEncoder) NewVaultKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate vault key: %w", err)
+	}
+	return key, nil
+}
+
+// WrapKey encrypts vault key with key derived from password.
+func (s *SecretItemEncoder) WrapKey(password string, params model.KDFParams, vaultKey []byte) ([]byte, error) {
+	kek, kdf, err := deriveKey(password, params)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAESGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, suiteAESGCM, kdf, vaultKey, nil)
+}
+
+// UnwrapKey decrypts vault key wrapped with key derived from password.
+func (s *SecretItemEncoder) UnwrapKey(password string, params model.KDFParams, wrappedKey []byte) ([]byte, error) {
+	kek, kdf, err := deriveKey(password, params)
+	if err != nil {
+		return nil, err
+	}
+	aeads, err := newAEADs(kek)
+	if err != nil {
+		return nil, err
+	}
+	vaultKey, err := open(aeads, kdf, wrappedKey, nil)
+	if err != nil {
+		return nil, ErrFailedToUnwrapKey
+	}
+	return vaultKey, nil
+}
+
+// AuthKey derives key which proves knowledge of password to server, neither password nor key
+// which wraps vault key can be restored from it.
+func (s *SecretItemEncoder) AuthKey(password string, params model.KDFParams) (string, error) {
+	key, _, err := deriveKey(password, params)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(deriveSubKey(key, authKeyLabel)), nil
+}
 
-	aesBlock, err := aes.NewCipher(hashedKey[:])
+// SetVaultKey sets random vault key as encoder key, new secrets are encoded with specified cipher suite.
+func (s *SecretItemEncoder) SetVaultKey(vaultKey []byte, suiteName string) error {
+	suite, err := cipherSuiteByName(suiteName)
+	if err != nil {
+		return err
+	}
+	_, err = newAEADs(vaultKey)
 	if err != nil {
 		return fmt.Errorf("an error occurred during secret encoder init: %w", err)
 	}
+	s.Lock()
+	s.suite = suite
+	// encoder owns its copy of key and wipes it on Lock
+	s.key = append([]byte(nil), vaultKey...)
+	s.kdf = kdfNone
+	s.indexKey = deriveSubKey(vaultKey, nameIndexLabel)
+	s.verifier = deriveSubKey(vaultKey, verifierLabel)
+	s.chunkKey = deriveSubKey(vaultKey, chunkKeyLabel)
+	s.ready = true
+	return nil
+}
+
+// WithVaultKey returns new encoder with specified vault key, current encoder is not changed.
+func (s *SecretItemEncoder) WithVaultKey(vaultKey []byte, suiteName string) (controller.Encoder, error) {
+	encoder := &SecretItemEncoder{}
+	if err := encoder.SetVaultKey(vaultKey, suiteName); err != nil {
+		return nil, err
+	}
+	return encoder, nil
+}
 
-	aesgcm, err := cipher.NewGCM(aesBlock)
+// SetSecretKey derives encoder key from secret key with specified KDF parameters,
+// used by accounts without vault key, new secrets are encoded with AES-256-GCM.
+func (s *SecretItemEncoder) SetSecretKey(secretKey string, params model.KDFParams) error {
+	key, kdf, err := deriveKey(secretKey, params)
 	if err != nil {
 		return fmt.Errorf("an error occurred during secret encoder init: %w", err)
 	}
 
-	nonce := hashedKey[len(hashedKey)-aesgcm.NonceSize():]
+	aeads, err := newAEADs(key)
+	if err != nil {
+		return fmt.Errorf("an error occurred during secret encoder init: %w", err)
+	}
 
-	s.encoder = aesgcm
-	s.nonce = nonce
+	s.Lock()
+	s.suite = cipherSuites[0]
+	s.key = key
+	s.kdf = kdf
+	if kdf == kdfSHA256 {
+		s.legacyNonce = append([]byte(nil), key[len(key)-aeads[suiteAESGCM].NonceSize():]...)
+	}
+	s.indexKey = deriveSubKey(key, nameIndexLabel)
+	s.verifier = deriveSubKey(key, verifierLabel)
+	s.chunkKey = deriveSubKey(key, chunkKeyLabel)
 	s.ready = true
 	return nil
 }
+
+// Lock wipes encoder key and every key derived from it, encoder must be initialized again before use.
+func (s *SecretItemEncoder) Lock() {
+	for _, buf := range [][]byte{s.key, s.legacyNonce, s.indexKey, s.verifier, s.chunkKey} {
+		wipe(buf)
+	}
+	s.key = nil
+	s.legacyNonce = nil
+	s.indexKey = nil
+	s.verifier = nil
+	s.chunkKey = nil
+	s.rejectOutdated = false
+	s.ready = false
+}
+
+// wipe overwrites buffer with zeros.
+func wipe(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// deriveSubKey derives independent key for specified purpose from encoder key.
+func deriveSubKey(key []byte, label string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func seal(aead cipher.AEAD, suite, kdf byte, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	env := envelope{
+		version:    envelopeVersion2,
+		suite:      suite,
+		kdf:        kdf,
+		nonce:      nonce,
+		ciphertext: aead.Seal(nil, nonce, plaintext, associatedData),
+	}
+	return env.marshal(), nil
+}
+
+// open decodes envelope with AEAD of suite recorded in envelope.
+func open(aeads map[byte]cipher.AEAD, kdf byte, data, associatedData []byte) ([]byte, error) {
+	suite, err := envelopeSuite(data)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := aeads[suite]
+	if !ok {
+		return nil, ErrUnsupportedEnvelope
+	}
+	env, err := parseEnvelope(data, aead.NonceSize(), aead.Overhead())
+	if err != nil {
+		return nil, err
+	}
+	if env.kdf != kdf {
+		return nil, ErrUnsupportedEnvelope
+	}
+	if env.version == envelopeVersion1 {
+		associatedData = nil
+	}
+	decoded, err := aead.Open(nil, env.nonce, env.ciphertext, associatedData)
+	if err != nil {
+		return nil, ErrFailedToDecode
+	}
+	return decoded, nil
+}
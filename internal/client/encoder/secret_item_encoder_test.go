@@ -0,0 +1,497 @@
+package encoder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	errs "github.com/apolsh/yapr-gophkeeper/internal/model/app_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretKey = "password1"
+
+func newTestEncoder(t *testing.T) *SecretItemEncoder {
+	enc := &SecretItemEncoder{}
+	require.NoError(t, enc.SetSecretKey(testSecretKey, model.KDFParams{}))
+	return enc
+}
+
+// testAESGCM returns AES-GCM instance with key of encoder.
+func testAESGCM(t *testing.T, enc *SecretItemEncoder) cipher.AEAD {
+	aead, err := newAESGCM(enc.key)
+	require.NoError(t, err)
+	return aead
+}
+
+func TestEncodeUsesFreshNonce(t *testing.T) {
+	enc := newTestEncoder(t)
+	plain := []byte("some secret")
+
+	first, err := enc.Encode(plain, nil)
+	require.NoError(t, err)
+	second, err := enc.Encode(plain, nil)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, envelopeVersion2, first[0])
+	assert.Equal(t, suiteAESGCM, first[1])
+	assert.Equal(t, kdfSHA256, first[2])
+
+	decoded, err := enc.Decode(first, nil)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+	assert.False(t, enc.IsOutdated(first))
+}
+
+func TestDecodeLegacyFixedNonce(t *testing.T) {
+	enc := newTestEncoder(t)
+	plain := []byte("legacy secret")
+
+	hashedKey := sha256.Sum256([]byte(testSecretKey))
+	block, err := aes.NewCipher(hashedKey[:])
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	legacy := gcm.Seal(nil, hashedKey[len(hashedKey)-gcm.NonceSize():], plain, nil)
+
+	decoded, err := enc.Decode(legacy, nil)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+	assert.True(t, enc.IsOutdated(legacy))
+}
+
+func TestDecodeEnvelopeWithoutAssociatedData(t *testing.T) {
+	enc := newTestEncoder(t)
+	gcm := testAESGCM(t, enc)
+	plain := []byte("some secret")
+
+	nonce := make([]byte, gcm.NonceSize())
+	env := envelope{
+		version:    envelopeVersion1,
+		suite:      suiteAESGCM,
+		kdf:        kdfSHA256,
+		nonce:      nonce,
+		ciphertext: gcm.Seal(nil, nonce, plain, nil),
+	}
+	encoded := env.marshal()
+
+	decoded, err := enc.Decode(encoded, []byte("associated data"))
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+	assert.True(t, enc.IsOutdated(encoded))
+}
+
+func TestRejectOutdatedEnvelopes(t *testing.T) {
+	enc := newTestEncoder(t)
+	gcm := testAESGCM(t, enc)
+	plain := []byte("some secret")
+
+	hashedKey := sha256.Sum256([]byte(testSecretKey))
+	legacy := gcm.Seal(nil, hashedKey[len(hashedKey)-gcm.NonceSize():], plain, nil)
+	nonce := make([]byte, gcm.NonceSize())
+	env := envelope{
+		version:    envelopeVersion1,
+		suite:      suiteAESGCM,
+		kdf:        kdfSHA256,
+		nonce:      nonce,
+		ciphertext: gcm.Seal(nil, nonce, plain, nil),
+	}
+	current, err := enc.Encode(plain, []byte("associated data"))
+	require.NoError(t, err)
+
+	enc.RejectOutdatedEnvelopes()
+	_, err = enc.Decode(legacy, nil)
+	assert.ErrorIs(t, err, ErrOutdatedEnvelope)
+	_, err = enc.Decode(env.marshal(), nil)
+	assert.ErrorIs(t, err, ErrOutdatedEnvelope)
+	decoded, err := enc.Decode(current, []byte("associated data"))
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+
+	// flag belongs to vault, another key accepts outdated formats until its vault is upgraded
+	require.NoError(t, enc.SetSecretKey(testSecretKey, model.KDFParams{}))
+	decoded, err = enc.Decode(legacy, nil)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+}
+
+func TestDecodeRejectsMovedContent(t *testing.T) {
+	enc := newTestEncoder(t)
+	item, err := model.NewCardSecretItem("card", "description", "owner", "4111111111111111", "123", "12/30", "", "", "")
+	require.NoError(t, err)
+	encoded, err := item.NewEncodedSecret(enc.Encode, 1, model.NewSecretID())
+	require.NoError(t, err)
+
+	decoded, err := encoded.Decode(enc.Decode)
+	require.NoError(t, err)
+	assert.Equal(t, item, decoded)
+
+	moved := encoded
+	moved.ID = model.NewSecretID()
+	_, err = moved.Decode(enc.Decode)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+
+	moved = encoded
+	moved.Owner = 2
+	_, err = moved.Decode(enc.Decode)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+
+	moved = encoded
+	moved.Type = model.Credentials
+	_, err = moved.Decode(enc.Decode)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+}
+
+func TestDecodeTampered(t *testing.T) {
+	enc := newTestEncoder(t)
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+
+	encoded[len(encoded)-1] ^= 0xff
+	_, err = enc.Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+}
+
+func TestArgon2idKey(t *testing.T) {
+	params, err := NewKDFParams()
+	require.NoError(t, err)
+	require.NoError(t, params.Validate())
+
+	enc := &SecretItemEncoder{}
+	require.NoError(t, enc.SetSecretKey(testSecretKey, params))
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, kdfArgon2id, encoded[2])
+
+	legacy := newTestEncoder(t)
+	_, err = legacy.Decode(encoded, nil)
+	assert.Error(t, err)
+
+	otherSalt, err := NewKDFParams()
+	require.NoError(t, err)
+	other := &SecretItemEncoder{}
+	require.NoError(t, other.SetSecretKey(testSecretKey, otherSalt))
+	_, err = other.Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+}
+
+func TestWeakKDFParamsRejected(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	err := enc.SetSecretKey(testSecretKey, model.KDFParams{Algorithm: model.KDFArgon2id, Salt: []byte("short"), Time: 1, Memory: 1024, Threads: 1})
+	assert.ErrorIs(t, err, errs.ErrorInvalidKDFParams)
+}
+
+func TestVaultKeyWrapping(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	params, err := NewKDFParams()
+	require.NoError(t, err)
+	vaultKey, err := enc.NewVaultKey()
+	require.NoError(t, err)
+
+	wrapped, err := enc.WrapKey(testSecretKey, params, vaultKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(wrapped), string(vaultKey))
+
+	_, err = enc.UnwrapKey("wrong password", params, wrapped)
+	assert.ErrorIs(t, err, ErrFailedToUnwrapKey)
+
+	unwrapped, err := enc.UnwrapKey(testSecretKey, params, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, vaultKey, unwrapped)
+
+	require.NoError(t, enc.SetVaultKey(unwrapped, model.CipherSuiteAESGCM))
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, kdfNone, encoded[2])
+	decoded, err := enc.Decode(encoded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("some secret"), decoded)
+}
+
+func TestNotInitialized(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	_, err := enc.Encode([]byte("some secret"), nil)
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+	_, err = enc.Decode([]byte("some secret"), nil)
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+}
+
+func TestNameIndex(t *testing.T) {
+	enc := newTestEncoder(t)
+	first, err := enc.NameIndex("secret name")
+	require.NoError(t, err)
+	second, err := enc.NameIndex("secret name")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	other, err := enc.NameIndex("other name")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+	assert.NotContains(t, first, "secret name")
+
+	_, err = (&SecretItemEncoder{}).NameIndex("secret name")
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+}
+
+func TestEncodeSecretInfo(t *testing.T) {
+	enc := newTestEncoder(t)
+	item := model.NewTextSecretItem("secret name", "secret description", "text")
+	encoded, err := item.NewEncodedSecret(enc.Encode, 1, model.NewSecretID())
+	require.NoError(t, err)
+	require.NoError(t, encoded.EncodeInfo(enc.Encode, enc.NameIndex))
+
+	assert.True(t, encoded.IsInfoEncoded())
+	assert.NotContains(t, encoded.Name, "secret name")
+	assert.NotContains(t, encoded.Description, "secret description")
+	nameIndex, err := enc.NameIndex("secret name")
+	require.NoError(t, err)
+	assert.Equal(t, nameIndex, encoded.NameIndex)
+
+	info, err := encoded.DecodeInfo(enc.Decode)
+	require.NoError(t, err)
+	assert.Equal(t, "secret name", info.Name)
+	assert.Equal(t, "secret description", info.Description)
+	assert.Equal(t, model.Text, info.SecretType)
+
+	moved := encoded
+	moved.Name, moved.Description = encoded.Description, encoded.Name
+	_, err = moved.DecodeInfo(enc.Decode)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+}
+
+func TestSyncHashDoesNotDependOnPlaintext(t *testing.T) {
+	enc := newTestEncoder(t)
+	item, err := model.NewCardSecretItem("card", "description", "owner", "4111111111111111", "123", "12/30", "", "", "")
+	require.NoError(t, err)
+	first, err := item.NewEncodedSecret(enc.Encode, 1, model.NewSecretID())
+	require.NoError(t, err)
+	second, err := item.NewEncodedSecret(enc.Encode, 1, first.ID)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.Hash, second.Hash)
+	assert.False(t, first.IsHashOutdated())
+
+	hash := first.Hash
+	require.NoError(t, first.EncodeInfo(enc.Encode, enc.NameIndex))
+	assert.NotEqual(t, hash, first.Hash)
+	assert.False(t, first.IsHashOutdated())
+}
+
+func TestXChaCha20Poly1305Suite(t *testing.T) {
+	vaultKey, err := (&SecretItemEncoder{}).NewVaultKey()
+	require.NoError(t, err)
+
+	aesEnc := &SecretItemEncoder{}
+	require.NoError(t, aesEnc.SetVaultKey(vaultKey, model.CipherSuiteAESGCM))
+	aesEncoded, err := aesEnc.Encode([]byte("aes secret"), []byte("ad"))
+	require.NoError(t, err)
+
+	enc := &SecretItemEncoder{}
+	require.NoError(t, enc.SetVaultKey(vaultKey, model.CipherSuiteXChaCha20Poly1305))
+	encoded, err := enc.Encode([]byte("xchacha secret"), []byte("ad"))
+	require.NoError(t, err)
+	assert.Equal(t, suiteXChaCha20Poly1305, encoded[1])
+	assert.False(t, enc.IsOutdated(encoded))
+
+	decoded, err := enc.Decode(encoded, []byte("ad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("xchacha secret"), decoded)
+	_, err = enc.Decode(encoded, []byte("other ad"))
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+
+	decoded, err = enc.Decode(aesEncoded, []byte("ad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("aes secret"), decoded)
+	assert.True(t, enc.IsOutdated(aesEncoded))
+
+	decoded, err = aesEnc.Decode(encoded, []byte("ad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("xchacha secret"), decoded)
+}
+
+func TestUnknownCipherSuite(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	err := enc.SetVaultKey(make([]byte, keySize), "des")
+	assert.ErrorIs(t, err, errs.ErrorUnknownCipherSuite)
+
+	require.NoError(t, enc.SetVaultKey(make([]byte, keySize), ""))
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+	encoded[1] = 0xff
+	_, err = enc.Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrUnsupportedEnvelope)
+}
+
+func TestWithVaultKey(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	oldKey, err := enc.NewVaultKey()
+	require.NoError(t, err)
+	require.NoError(t, enc.SetVaultKey(oldKey, model.CipherSuiteAESGCM))
+	newKey, err := enc.NewVaultKey()
+	require.NoError(t, err)
+
+	rotated, err := enc.WithVaultKey(newKey, model.CipherSuiteXChaCha20Poly1305)
+	require.NoError(t, err)
+
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+	_, err = rotated.Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrFailedToDecode)
+
+	reEncoded, err := rotated.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, suiteXChaCha20Poly1305, reEncoded[1])
+	decoded, err := rotated.Decode(reEncoded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("some secret"), decoded)
+
+	_, err = enc.WithVaultKey(newKey, "des")
+	assert.ErrorIs(t, err, errs.ErrorUnknownCipherSuite)
+}
+
+func TestRecoveryKey(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	vaultKey, err := enc.NewVaultKey()
+	require.NoError(t, err)
+	recoveryKey, err := enc.NewRecoveryKey()
+	require.NoError(t, err)
+	assert.Contains(t, recoveryKey, recoveryKeySeparator)
+
+	kit, err := enc.NewRecoveryKit(recoveryKey, vaultKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(kit.WrappedVaultKey), string(vaultKey))
+	assert.NotContains(t, recoveryKey, kit.AuthKey)
+
+	authKey, err := enc.RecoveryAuthKey(strings.ToLower(strings.ReplaceAll(recoveryKey, recoveryKeySeparator, " ")))
+	require.NoError(t, err)
+	assert.Equal(t, kit.AuthKey, authKey)
+
+	unwrapped, err := enc.UnwrapKeyWithRecoveryKey(recoveryKey, kit.WrappedVaultKey)
+	require.NoError(t, err)
+	assert.Equal(t, vaultKey, unwrapped)
+
+	otherRecoveryKey, err := enc.NewRecoveryKey()
+	require.NoError(t, err)
+	_, err = enc.UnwrapKeyWithRecoveryKey(otherRecoveryKey, kit.WrappedVaultKey)
+	assert.ErrorIs(t, err, ErrFailedToUnwrapKey)
+
+	_, err = enc.RecoveryAuthKey("not a recovery key")
+	assert.ErrorIs(t, err, ErrInvalidRecoveryKey)
+}
+
+func TestLock(t *testing.T) {
+	enc := newTestEncoder(t)
+	encoded, err := enc.Encode([]byte("some secret"), nil)
+	require.NoError(t, err)
+
+	enc.Lock()
+	_, err = enc.Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+	_, err = enc.NameIndex("name")
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+
+	require.NoError(t, enc.SetSecretKey(testSecretKey, model.KDFParams{}))
+	decoded, err := enc.Decode(encoded, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("some secret"), decoded)
+}
+
+func TestVerifier(t *testing.T) {
+	enc := newTestEncoder(t)
+	verifier, err := enc.Verifier()
+	require.NoError(t, err)
+	assert.NotEmpty(t, verifier)
+
+	same := newTestEncoder(t)
+	sameVerifier, err := same.Verifier()
+	require.NoError(t, err)
+	assert.Equal(t, verifier, sameVerifier)
+
+	other := &SecretItemEncoder{}
+	require.NoError(t, other.SetSecretKey("wrong password", model.KDFParams{}))
+	otherVerifier, err := other.Verifier()
+	require.NoError(t, err)
+	assert.NotEqual(t, verifier, otherVerifier)
+
+	enc.Lock()
+	_, err = enc.Verifier()
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+}
+
+func TestChunkKey(t *testing.T) {
+	enc := newTestEncoder(t)
+	chunkKey, err := enc.ChunkKey()
+	require.NoError(t, err)
+	assert.Len(t, chunkKey, keySize)
+
+	same := newTestEncoder(t)
+	sameChunkKey, err := same.ChunkKey()
+	require.NoError(t, err)
+	assert.Equal(t, chunkKey, sameChunkKey)
+	verifier, err := enc.Verifier()
+	require.NoError(t, err)
+	assert.NotEqual(t, verifier, chunkKey)
+
+	chunkEncoder, err := enc.WithVaultKey(chunkKey, model.CipherSuiteAESGCM)
+	require.NoError(t, err)
+	encoded, err := chunkEncoder.Encode([]byte("chunk"), []byte("address"))
+	require.NoError(t, err)
+	_, err = enc.Decode(encoded, []byte("address"))
+	assert.Error(t, err)
+
+	enc.Lock()
+	assert.NotEqual(t, make([]byte, keySize), chunkKey)
+	_, err = enc.ChunkKey()
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+}
+
+func TestLockWipesKeys(t *testing.T) {
+	enc := newTestEncoder(t)
+	buffers := [][]byte{enc.key, enc.legacyNonce, enc.indexKey, enc.verifier, enc.chunkKey}
+	for _, buf := range buffers {
+		require.NotEmpty(t, buf)
+	}
+
+	enc.Lock()
+	for _, buf := range buffers {
+		assert.Equal(t, make([]byte, len(buf)), buf)
+	}
+	_, err := enc.Encode([]byte("some secret"), nil)
+	assert.ErrorIs(t, err, ErrEncoderIsNotInitialized)
+
+	vaultKey := bytes.Repeat([]byte{1}, keySize)
+	require.NoError(t, enc.SetVaultKey(vaultKey, model.CipherSuiteXChaCha20Poly1305))
+	enc.Lock()
+	// encoder wipes only its own copy of vault key
+	assert.Equal(t, bytes.Repeat([]byte{1}, keySize), vaultKey)
+}
+
+func TestAuthKey(t *testing.T) {
+	enc := &SecretItemEncoder{}
+	params, err := NewKDFParams()
+	require.NoError(t, err)
+
+	authKey, err := enc.AuthKey(testSecretKey, params)
+	require.NoError(t, err)
+	assert.NotContains(t, authKey, testSecretKey)
+	same, err := enc.AuthKey(testSecretKey, params)
+	require.NoError(t, err)
+	assert.Equal(t, authKey, same)
+	other, err := enc.AuthKey("wrong password", params)
+	require.NoError(t, err)
+	assert.NotEqual(t, authKey, other)
+
+	// auth key must not be usable as key which wraps vault key
+	vaultKey, err := enc.NewVaultKey()
+	require.NoError(t, err)
+	wrapped, err := enc.WrapKey(testSecretKey, params, vaultKey)
+	require.NoError(t, err)
+	_, err = enc.UnwrapKey(authKey, params, wrapped)
+	assert.ErrorIs(t, err, ErrFailedToUnwrapKey)
+}
@@ -0,0 +1,203 @@
+package sshagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/logger"
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var log = logger.LoggerOfComponent("ssh-agent")
+
+// socketPermissions only owner of socket is allowed to use keys.
+const socketPermissions = 0600
+
+// ErrSocketInUse appears when socket path is already used by another file or running agent.
+var ErrSocketInUse = errors.New("ssh-agent socket path is already in use")
+
+// SSHAgent serves unlocked SSH keys over Unix socket with ssh-agent protocol,
+// keys are kept only in memory and removed when agent is stopped.
+type SSHAgent struct {
+	socketPath string
+	keyring    agent.Agent
+	listener   net.Listener
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	conns      map[net.Conn]struct{}
+	// stopped is closed by Stop.
+	stopped chan struct{}
+}
+
+// NewSSHAgent SSHAgent constructor.
+func NewSSHAgent(socketPath string) *SSHAgent {
+	return &SSHAgent{
+		socketPath: socketPath,
+		keyring:    agent.NewKeyring(),
+		conns:      make(map[net.Conn]struct{}),
+	}
+}
+
+// SocketPath returns path of Unix socket, ssh uses it from SSH_AUTH_SOCK environment variable.
+func (a *SSHAgent) SocketPath() string {
+	return a.socketPath
+}
+
+// AddKey adds decrypted private key of SSH key secret to agent.
+func (a *SSHAgent) AddKey(item *model.SSHKeySecretItem) error {
+	rawKey, err := item.RawPrivateKey()
+	if err != nil {
+		return err
+	}
+	comment := item.Comment
+	if comment == "" {
+		comment = item.Name
+	}
+	return a.keyring.Add(agent.AddedKey{PrivateKey: rawKey, Comment: comment})
+}
+
+// Start listens Unix socket and serves ssh-agent protocol until Stop is called or context is done.
+func (a *SSHAgent) Start(ctx context.Context) error {
+	if err := removeStaleSocket(a.socketPath); err != nil {
+		return err
+	}
+	listener, err := listenSocket(a.socketPath)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.listener = listener
+	a.stopped = make(chan struct{})
+	stopped := a.stopped
+	a.mu.Unlock()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.acceptConnections(listener)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.Stop()
+		case <-stopped:
+		}
+	}()
+	return nil
+}
+
+// listenSocket creates socket in private directory and links it to socket path once its permissions are restricted,
+// socket created with permissions of umask is never reachable by other users.
+func listenSocket(socketPath string) (net.Listener, error) {
+	// directory is created with 0700 permissions
+	dir, err := os.MkdirTemp(filepath.Dir(socketPath), ".ssh-agent-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ssh-agent socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	privatePath := filepath.Join(dir, "agent.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: privatePath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen ssh-agent socket: %w", err)
+	}
+	// socket is served from socket path, Stop removes it
+	listener.SetUnlinkOnClose(false)
+	if err = os.Chmod(privatePath, socketPermissions); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict ssh-agent socket permissions: %w", err)
+	}
+	// unlike rename, link does not replace file created at socket path in the meantime
+	if err = os.Link(privatePath, socketPath); err != nil {
+		_ = listener.Close()
+		if os.IsExist(err) {
+			return nil, ErrSocketInUse
+		}
+		return nil, fmt.Errorf("failed to listen ssh-agent socket: %w", err)
+	}
+	return listener, nil
+}
+
+func (a *SSHAgent) acceptConnections(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error(err)
+			}
+			return
+		}
+		a.mu.Lock()
+		if a.listener == nil {
+			a.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		a.conns[conn] = struct{}{}
+		a.mu.Unlock()
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			defer a.closeConnection(conn)
+			if err := agent.ServeAgent(a.keyring, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+				log.Debug(err.Error())
+			}
+		}()
+	}
+}
+
+func (a *SSHAgent) closeConnection(conn net.Conn) {
+	a.mu.Lock()
+	delete(a.conns, conn)
+	a.mu.Unlock()
+	_ = conn.Close()
+}
+
+// Stop closes socket and connections, removes keys from memory.
+func (a *SSHAgent) Stop() {
+	a.mu.Lock()
+	if a.listener == nil {
+		a.mu.Unlock()
+		return
+	}
+	_ = a.listener.Close()
+	a.listener = nil
+	close(a.stopped)
+	for conn := range a.conns {
+		_ = conn.Close()
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+
+	if err := a.keyring.RemoveAll(); err != nil {
+		log.Error(err)
+	}
+	if err := os.Remove(a.socketPath); err != nil && !os.IsNotExist(err) {
+		log.Error(err)
+	}
+}
+
+// removeStaleSocket removes socket left by agent which was not stopped, other files and sockets of running agents are kept.
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return ErrSocketInUse
+	}
+	conn, err := net.Dial("unix", socketPath)
+	if err == nil {
+		_ = conn.Close()
+		return ErrSocketInUse
+	}
+	return os.Remove(socketPath)
+}
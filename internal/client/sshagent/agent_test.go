@@ -0,0 +1,91 @@
+package sshagent
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func newTestSSHKeySecretItem(t *testing.T) *model.SSHKeySecretItem {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	item, err := model.NewSSHKeySecretItem("deploy", "", string(privatePEM), "", "deploy@ci", "")
+	require.NoError(t, err)
+	return item
+}
+
+func TestSSHAgentServesKeys(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	item := newTestSSHKeySecretItem(t)
+	sshAgent := NewSSHAgent(socketPath)
+	require.NoError(t, sshAgent.AddKey(item))
+	require.NoError(t, sshAgent.Start(context.Background()))
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(socketPermissions), info.Mode().Perm())
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	client := agent.NewClient(conn)
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "deploy@ci", keys[0].Comment)
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(item.PublicKey))
+	require.NoError(t, err)
+	data := []byte("challenge")
+	signature, err := client.Sign(publicKey, data)
+	require.NoError(t, err)
+	assert.NoError(t, publicKey.Verify(data, signature))
+
+	sshAgent.Stop()
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(filepath.Dir(socketPath))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = client.List()
+	assert.Error(t, err)
+}
+
+func TestSSHAgentStopsWithContext(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	sshAgent := NewSSHAgent(socketPath)
+	require.NoError(t, sshAgent.AddKey(newTestSSHKeySecretItem(t)))
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, sshAgent.Start(ctx))
+
+	assert.ErrorIs(t, NewSSHAgent(socketPath).Start(context.Background()), ErrSocketInUse)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSSHAgentKeepsRegularFile(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	require.NoError(t, os.WriteFile(socketPath, []byte("data"), 0600))
+	assert.ErrorIs(t, NewSSHAgent(socketPath).Start(context.Background()), ErrSocketInUse)
+	_, err := os.Stat(socketPath)
+	assert.NoError(t, err)
+}
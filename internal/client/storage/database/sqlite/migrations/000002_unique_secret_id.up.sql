@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS unique_secret_id ON secrets (secret_id);
@@ -0,0 +1,5 @@
+ALTER TABLE clients ADD COLUMN kdf_algorithm TEXT NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN kdf_salt BLOB;
+ALTER TABLE clients ADD COLUMN kdf_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN kdf_memory INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN kdf_threads INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN wrapped_vault_key BLOB;
@@ -0,0 +1,5 @@
+ALTER TABLE secrets ADD COLUMN name_index TEXT NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS unique_secret_name;
+
+CREATE UNIQUE INDEX unique_secret_name_index ON secrets (name_index) WHERE name_index <> '';
@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN cipher_suite TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE clients ADD COLUMN key_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN pending_wrapped_vault_key BLOB;
+ALTER TABLE secrets ADD COLUMN key_version INTEGER NOT NULL DEFAULT 0;
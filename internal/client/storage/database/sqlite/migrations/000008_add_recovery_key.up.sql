@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN recovery_wrapped_vault_key BLOB;
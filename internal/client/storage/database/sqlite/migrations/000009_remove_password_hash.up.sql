@@ -0,0 +1 @@
+UPDATE clients SET password = '';
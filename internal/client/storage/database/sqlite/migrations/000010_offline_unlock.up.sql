@@ -0,0 +1,7 @@
+ALTER TABLE clients ADD COLUMN local_verifier BLOB;
+CREATE TABLE IF NOT EXISTS pending_changes (
+    secret_id TEXT PRIMARY KEY,
+    owner INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    date_last_modified INTEGER NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS pin_unlock (
+    owner INTEGER PRIMARY KEY,
+    wrapped_vault_key BLOB NOT NULL,
+    kdf_algorithm TEXT NOT NULL,
+    kdf_salt BLOB,
+    kdf_time INTEGER NOT NULL,
+    kdf_memory INTEGER NOT NULL,
+    kdf_threads INTEGER NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,15 @@
+ALTER TABLE secrets ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN folder_index TEXT NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE secrets ADD COLUMN tag_indexes TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS secrets_folder_index ON secrets (owner, folder_index);
+
+CREATE TABLE IF NOT EXISTS secret_tags (
+    secret_id TEXT NOT NULL,
+    owner INTEGER NOT NULL,
+    tag_index TEXT NOT NULL,
+    PRIMARY KEY (secret_id, tag_index)
+);
+
+CREATE INDEX IF NOT EXISTS secret_tags_owner_tag_index ON secret_tags (owner, tag_index);
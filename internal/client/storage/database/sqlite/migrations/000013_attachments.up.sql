@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    attachment_id TEXT PRIMARY KEY,
+    secret_id TEXT NOT NULL,
+    owner INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    enc_data BLOB,
+    hash TEXT NOT NULL,
+    key_version INTEGER NOT NULL DEFAULT 0,
+    date_last_modified INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachments_secret_id ON attachments (secret_id);
+CREATE INDEX IF NOT EXISTS attachments_owner ON attachments (owner);
@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN vault_upgraded INTEGER NOT NULL DEFAULT 0;
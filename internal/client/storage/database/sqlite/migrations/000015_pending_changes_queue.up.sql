@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pending_changes_queue (
+    change_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    secret_id TEXT NOT NULL,
+    owner INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    date_last_modified INTEGER NOT NULL
+);
+INSERT INTO pending_changes_queue (secret_id, owner, operation, date_last_modified)
+    SELECT secret_id, owner, operation, date_last_modified FROM pending_changes ORDER BY date_last_modified;
+DROP TABLE pending_changes;
+ALTER TABLE pending_changes_queue RENAME TO pending_changes;
+CREATE INDEX IF NOT EXISTS pending_changes_secret_id ON pending_changes (secret_id);
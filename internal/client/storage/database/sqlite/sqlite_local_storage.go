@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/apolsh/yapr-gophkeeper/internal/client/controller"
 	"github.com/apolsh/yapr-gophkeeper/internal/logger"
@@ -20,6 +21,15 @@ import (
 
 const databaseName = "gophkeeper.db"
 
+const (
+	secretColumns = `secret_id, owner, name, name_index, hash, description, enc_data, type, key_version, date_last_modified,
+		folder, folder_index, tags, tag_indexes`
+	secretInfoColumns     = "secret_id, owner, name, name_index, description, type, folder, folder_index, tags"
+	attachmentInfoColumns = "attachment_id, secret_id, owner, name, size, hash, key_version, date_last_modified"
+	// tagIndexesSeparator separates blind indexes of tags, they are base64 strings without spaces.
+	tagIndexesSeparator = " "
+)
+
 //go:embed migrations/*.sql
 var fs embed.FS
 
@@ -27,7 +37,9 @@ var log = logger.LoggerOfComponent("sqlite-local-storage")
 
 var _ controller.LocalStorage = (*GophkeeperLocalStorageSqlite)(nil)
 
-// GophkeeperLocalStorageSqlite implementation of LocalStorage.
+// GophkeeperLocalStorageSqlite implementation of LocalStorage. Content, names, descriptions and labels of secrets
+// are stored encrypted with vault key. Login, types of secrets and times of modification are kept in plain form:
+// login finds user before vault is unlocked, types and times are metadata server stores in plain form too.
 type GophkeeperLocalStorageSqlite struct {
 	db *sql.DB
 }
@@ -56,20 +68,28 @@ func NewGophkeeperLocalStorageSqlite(applicationDir string) (*GophkeeperLocalSto
 	}, nil
 }
 
-// SaveUser saves new user.
+// SaveUser saves new user, password hash is not stored locally.
 func (g GophkeeperLocalStorageSqlite) SaveUser(ctx context.Context, user model.User) error {
-	q := "INSERT INTO clients (client_id, username, password, date_last_modified) VALUES ($1, $2, $3, $4)"
-	_, err := g.db.ExecContext(ctx, q, user.ID, user.Login, user.HashedPassword, user.Timestamp)
+	q := `INSERT INTO clients (client_id, username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite,
+		key_version, pending_wrapped_vault_key, recovery_wrapped_vault_key, date_last_modified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err := g.db.ExecContext(ctx, q, user.ID, user.Login, "",
+		user.KDF.Algorithm, user.KDF.Salt, user.KDF.Time, user.KDF.Memory, user.KDF.Threads, user.WrappedVaultKey, user.CipherSuite,
+		user.KeyVersion, user.PendingWrappedVaultKey, user.RecoveryWrappedVaultKey, user.Timestamp)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// UpdateUser update users metadata.
+// UpdateUser update users metadata, password hash is not stored locally.
 func (g GophkeeperLocalStorageSqlite) UpdateUser(ctx context.Context, user model.User) error {
-	q := "UPDATE clients SET username = $1, password = $2, date_last_modified = $3 WHERE client_id = $4"
-	_, err := g.db.ExecContext(ctx, q, user.Login, user.HashedPassword, user.Timestamp, user.ID)
+	q := `UPDATE clients SET username = $1, password = $2, kdf_algorithm = $3, kdf_salt = $4, kdf_time = $5, kdf_memory = $6,
+		kdf_threads = $7, wrapped_vault_key = $8, cipher_suite = $9, key_version = $10, pending_wrapped_vault_key = $11,
+		recovery_wrapped_vault_key = $12, date_last_modified = $13 WHERE client_id = $14`
+	_, err := g.db.ExecContext(ctx, q, user.Login, "",
+		user.KDF.Algorithm, user.KDF.Salt, user.KDF.Time, user.KDF.Memory, user.KDF.Threads, user.WrappedVaultKey, user.CipherSuite,
+		user.KeyVersion, user.PendingWrappedVaultKey, user.RecoveryWrappedVaultKey, user.Timestamp, user.ID)
 	if err != nil {
 		return err
 	}
@@ -77,21 +97,224 @@ func (g GophkeeperLocalStorageSqlite) UpdateUser(ctx context.Context, user model
 }
 
 // GetUserByID returns user by ID.
-func (g GophkeeperLocalStorageSqlite) GetUserByID(ctx context.Context, userID int64) (user model.User, err error) {
-	q := "SELECT client_id, username, password, date_last_modified FROM clients WHERE client_id = $1"
-	row := g.db.QueryRowContext(ctx, q, userID)
+func (g GophkeeperLocalStorageSqlite) GetUserByID(ctx context.Context, userID int64) (model.User, error) {
+	q := `SELECT client_id, username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite, key_version,
+		pending_wrapped_vault_key, recovery_wrapped_vault_key, date_last_modified FROM clients WHERE client_id = $1`
+	return scanUser(g.db.QueryRowContext(ctx, q, userID))
+}
+
+// GetUserByLogin returns user by login.
+func (g GophkeeperLocalStorageSqlite) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	q := `SELECT client_id, username, password, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, wrapped_vault_key, cipher_suite, key_version,
+		pending_wrapped_vault_key, recovery_wrapped_vault_key, date_last_modified FROM clients WHERE username = $1`
+	return scanUser(g.db.QueryRowContext(ctx, q, login))
+}
+
+func scanUser(row *sql.Row) (user model.User, err error) {
+	err = row.Scan(
+		&user.ID,
+		&user.Login,
+		&user.HashedPassword,
+		&user.KDF.Algorithm,
+		&user.KDF.Salt,
+		&user.KDF.Time,
+		&user.KDF.Memory,
+		&user.KDF.Threads,
+		&user.WrappedVaultKey,
+		&user.CipherSuite,
+		&user.KeyVersion,
+		&user.PendingWrappedVaultKey,
+		&user.RecoveryWrappedVaultKey,
+		&user.Timestamp)
 	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return model.User{}, errs.ErrItemNotFound
+		}
 		return model.User{}, err
 	}
-	err = row.Scan(&user.ID, &user.Login, &user.HashedPassword, &user.Timestamp)
+	return user, nil
+}
+
+// SaveLocalVerifier saves value which allows to check password without server.
+func (g GophkeeperLocalStorageSqlite) SaveLocalVerifier(ctx context.Context, userID int64, verifier []byte) error {
+	q := "UPDATE clients SET local_verifier = $1 WHERE client_id = $2"
+	_, err := g.db.ExecContext(ctx, q, verifier, userID)
+	return err
+}
+
+// GetLocalVerifier returns value which allows to check password without server.
+func (g GophkeeperLocalStorageSqlite) GetLocalVerifier(ctx context.Context, userID int64) (verifier []byte, err error) {
+	q := "SELECT local_verifier FROM clients WHERE client_id = $1"
+	err = g.db.QueryRowContext(ctx, q, userID).Scan(&verifier)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
-			return model.User{}, errs.ErrItemNotFound
+			return nil, errs.ErrItemNotFound
+		}
+	}
+	return
+}
+
+// SetVaultUpgraded marks that every secret of user is encoded with associated data.
+func (g GophkeeperLocalStorageSqlite) SetVaultUpgraded(ctx context.Context, userID int64) error {
+	q := "UPDATE clients SET vault_upgraded = 1 WHERE client_id = $1"
+	_, err := g.db.ExecContext(ctx, q, userID)
+	return err
+}
+
+// IsVaultUpgraded reports whether every secret of user is encoded with associated data.
+func (g GophkeeperLocalStorageSqlite) IsVaultUpgraded(ctx context.Context, userID int64) (upgraded bool, err error) {
+	q := "SELECT vault_upgraded FROM clients WHERE client_id = $1"
+	err = g.db.QueryRowContext(ctx, q, userID).Scan(&upgraded)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return false, errs.ErrItemNotFound
+		}
+	}
+	return
+}
+
+// AddPendingChange queues change of secret which is not sent to server yet. Deletion drops queued changes of secret,
+// deletion of secret or attachment created while server was not available cancels its creation.
+func (g GophkeeperLocalStorageSqlite) AddPendingChange(ctx context.Context, change dto.PendingChange) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(sql.ErrTxDone, err) {
+			log.Error(err)
+		}
+	}(tx)
+	creation := ""
+	switch change.Operation {
+	case dto.PendingChangeDelete:
+		creation = dto.PendingChangeCreate
+	case dto.PendingChangeDeleteAttachment:
+		creation = dto.PendingChangeSaveAttachment
+	}
+	if creation != "" {
+		var created int
+		q := "SELECT COUNT(*) FROM pending_changes WHERE secret_id = $1 AND operation = $2"
+		err = tx.QueryRowContext(ctx, q, change.SecretID, creation).Scan(&created)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM pending_changes WHERE secret_id = $1", change.SecretID)
+		if err != nil {
+			return err
+		}
+		if created > 0 {
+			return tx.Commit()
+		}
+	}
+	q := "INSERT INTO pending_changes (secret_id, owner, operation, date_last_modified) VALUES ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctx, q, change.SecretID, change.Owner, change.Operation, change.Timestamp)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPendingChanges returns queued changes of user secrets in order they were made.
+func (g GophkeeperLocalStorageSqlite) GetPendingChanges(ctx context.Context, ownerID int64) ([]dto.PendingChange, error) {
+	changes := make([]dto.PendingChange, 0)
+
+	q := "SELECT change_id, secret_id, owner, operation, date_last_modified FROM pending_changes WHERE owner = $1 ORDER BY change_id"
+	rows, err := g.db.QueryContext(ctx, q, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var change dto.PendingChange
+		err := rows.Scan(&change.ID, &change.SecretID, &change.Owner, &change.Operation, &change.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// DeletePendingChange removes change which is sent to server, later changes of the same secret stay queued.
+func (g GophkeeperLocalStorageSqlite) DeletePendingChange(ctx context.Context, changeID int64) error {
+	q := "DELETE FROM pending_changes WHERE change_id = $1"
+	_, err := g.db.ExecContext(ctx, q, changeID)
+	return err
+}
+
+// SavePinUnlock saves vault key wrapped with PIN, replaces previous one and resets failed attempts.
+func (g GophkeeperLocalStorageSqlite) SavePinUnlock(ctx context.Context, pin model.PinUnlock) error {
+	q := `INSERT INTO pin_unlock (owner, wrapped_vault_key, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, failed_attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0)
+		ON CONFLICT (owner) DO UPDATE SET wrapped_vault_key = excluded.wrapped_vault_key, kdf_algorithm = excluded.kdf_algorithm,
+		kdf_salt = excluded.kdf_salt, kdf_time = excluded.kdf_time, kdf_memory = excluded.kdf_memory,
+		kdf_threads = excluded.kdf_threads, failed_attempts = 0`
+	_, err := g.db.ExecContext(ctx, q, pin.Owner, pin.WrappedVaultKey, pin.KDF.Algorithm, pin.KDF.Salt, pin.KDF.Time, pin.KDF.Memory, pin.KDF.Threads)
+	return err
+}
+
+// GetPinUnlock returns vault key wrapped with PIN.
+func (g GophkeeperLocalStorageSqlite) GetPinUnlock(ctx context.Context, userID int64) (pin model.PinUnlock, err error) {
+	q := `SELECT owner, wrapped_vault_key, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, failed_attempts
+		FROM pin_unlock WHERE owner = $1`
+	err = g.db.QueryRowContext(ctx, q, userID).Scan(
+		&pin.Owner,
+		&pin.WrappedVaultKey,
+		&pin.KDF.Algorithm,
+		&pin.KDF.Salt,
+		&pin.KDF.Time,
+		&pin.KDF.Memory,
+		&pin.KDF.Threads,
+		&pin.FailedAttempts)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return model.PinUnlock{}, errs.ErrItemNotFound
+		}
+		return model.PinUnlock{}, err
+	}
+	return pin, nil
+}
+
+// RegisterFailedPinAttempt increments number of wrong PINs and returns it.
+func (g GophkeeperLocalStorageSqlite) RegisterFailedPinAttempt(ctx context.Context, userID int64) (attempts int, err error) {
+	q := "UPDATE pin_unlock SET failed_attempts = failed_attempts + 1 WHERE owner = $1 RETURNING failed_attempts"
+	err = g.db.QueryRowContext(ctx, q, userID).Scan(&attempts)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return 0, errs.ErrItemNotFound
 		}
 	}
 	return
 }
 
+// ResetPinAttempts resets number of wrong PINs after successful unlock.
+func (g GophkeeperLocalStorageSqlite) ResetPinAttempts(ctx context.Context, userID int64) error {
+	q := "UPDATE pin_unlock SET failed_attempts = 0 WHERE owner = $1"
+	_, err := g.db.ExecContext(ctx, q, userID)
+	return err
+}
+
+// DeletePinUnlock wipes vault key wrapped with PIN.
+func (g GophkeeperLocalStorageSqlite) DeletePinUnlock(ctx context.Context, userID int64) error {
+	q := "DELETE FROM pin_unlock WHERE owner = $1"
+	_, err := g.db.ExecContext(ctx, q, userID)
+	return err
+}
+
 // GetSecretSyncMetaByID returns metadata for one secret synchronization.
 func (g GophkeeperLocalStorageSqlite) GetSecretSyncMetaByID(ctx context.Context, id string) (secretMeta dto.SecretSyncMetadata, err error) {
 	q := "SELECT secret_id, hash, date_last_modified FROM secrets WHERE secret_id = $1"
@@ -130,38 +353,91 @@ func (g GophkeeperLocalStorageSqlite) GetSecretSyncMetaByOwnerID(ctx context.Con
 	return secretSyncMetas, nil
 }
 
-// SaveEncodedSecret saves EncodedSecret.
+// SaveEncodedSecret saves EncodedSecret, replaces existing one with the same ID.
 func (g GophkeeperLocalStorageSqlite) SaveEncodedSecret(ctx context.Context, encSecret model.EncodedSecret) error {
-	q := "INSERT INTO secrets (secret_id, owner, name, hash, description, enc_data, type, date_last_modified) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
-	_, err := g.db.ExecContext(ctx, q, encSecret.ID, encSecret.Owner, encSecret.Name, encSecret.Hash, encSecret.Description, encSecret.EncodedContent, encSecret.Type, encSecret.Timestamp)
+	tx, err := g.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(sql.ErrTxDone, err) {
+			log.Error(err)
+		}
+	}(tx)
+	q := `INSERT INTO secrets (secret_id, owner, name, name_index, hash, description, enc_data, type, key_version, date_last_modified,
+		folder, folder_index, tags, tag_indexes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (secret_id) DO UPDATE SET name = excluded.name, name_index = excluded.name_index, hash = excluded.hash, description = excluded.description,
+		enc_data = excluded.enc_data, type = excluded.type, key_version = excluded.key_version, date_last_modified = excluded.date_last_modified,
+		folder = excluded.folder, folder_index = excluded.folder_index, tags = excluded.tags, tag_indexes = excluded.tag_indexes`
+	_, err = tx.ExecContext(ctx, q, encSecret.ID, encSecret.Owner, encSecret.Name, encSecret.NameIndex, encSecret.Hash, encSecret.Description,
+		encSecret.EncodedContent, encSecret.Type, encSecret.KeyVersion, encSecret.Timestamp,
+		encSecret.Folder, encSecret.FolderIndex, encSecret.Tags, strings.Join(encSecret.TagIndexes, tagIndexesSeparator))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM secret_tags WHERE secret_id = $1", encSecret.ID)
+	if err != nil {
+		return err
+	}
+	for _, tagIndex := range encSecret.TagIndexes {
+		_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO secret_tags (secret_id, owner, tag_index) VALUES ($1, $2, $3)",
+			encSecret.ID, encSecret.Owner, tagIndex)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // GetSecretByID returns EncodedSecret by ID.
-func (g GophkeeperLocalStorageSqlite) GetSecretByID(ctx context.Context, id string) (encSecret model.EncodedSecret, err error) {
-	q := "SELECT secret_id, owner, name, hash, description, enc_data, type, date_last_modified FROM secrets WHERE secret_id = $1"
-	row := g.db.QueryRowContext(ctx, q, id)
-	err = row.Scan(
-		&encSecret.ID,
-		&encSecret.Owner,
-		&encSecret.Name,
-		&encSecret.Hash,
-		&encSecret.Description,
-		&encSecret.EncodedContent,
-		&encSecret.Type,
-		&encSecret.Timestamp)
-	return
+func (g GophkeeperLocalStorageSqlite) GetSecretByID(ctx context.Context, id string) (model.EncodedSecret, error) {
+	q := "SELECT " + secretColumns + " FROM secrets WHERE secret_id = $1"
+	return scanEncodedSecret(g.db.QueryRowContext(ctx, q, id))
 }
 
-// GetAllSecretsItemInfoByUserID returns all secret item info by user id.
-func (g GophkeeperLocalStorageSqlite) GetAllSecretsItemInfoByUserID(ctx context.Context, ownerID int64) ([]dto.SecretItemInfo, error) {
-	secretInfos := make([]dto.SecretItemInfo, 0, 0)
+// GetAllSecretsItemInfoByUserID returns encoded info of all secrets by user id, content of secrets is not loaded.
+func (g GophkeeperLocalStorageSqlite) GetAllSecretsItemInfoByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error) {
+	q := "SELECT " + secretInfoColumns + " FROM secrets WHERE owner = $1"
+	return g.querySecretInfos(ctx, q, ownerID)
+}
 
-	q := "SELECT name, description, type FROM secrets WHERE owner = $1"
-	rows, err := g.db.QueryContext(ctx, q, ownerID)
+// GetSecretsItemInfoByFolder returns encoded info of secrets placed directly in folder with specified blind index,
+// empty index stands for root folder.
+func (g GophkeeperLocalStorageSqlite) GetSecretsItemInfoByFolder(ctx context.Context, ownerID int64, folderIndex string) ([]model.EncodedSecret, error) {
+	q := "SELECT " + secretInfoColumns + " FROM secrets WHERE owner = $1 AND folder_index = $2"
+	return g.querySecretInfos(ctx, q, ownerID, folderIndex)
+}
+
+// GetSecretsItemInfoByTag returns encoded info of secrets marked with tag with specified blind index.
+func (g GophkeeperLocalStorageSqlite) GetSecretsItemInfoByTag(ctx context.Context, ownerID int64, tagIndex string) ([]model.EncodedSecret, error) {
+	q := `SELECT ` + secretInfoColumns + ` FROM secrets WHERE owner = $1
+		AND secret_id IN (SELECT secret_id FROM secret_tags WHERE owner = $1 AND tag_index = $2)`
+	return g.querySecretInfos(ctx, q, ownerID, tagIndex)
+}
+
+// GetFoldersByUserID returns encoded info of one secret of every folder, folder path of each is decoded only once.
+func (g GophkeeperLocalStorageSqlite) GetFoldersByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error) {
+	q := `SELECT ` + secretInfoColumns + ` FROM secrets WHERE owner = $1 AND folder_index <> ''
+		AND rowid IN (SELECT MIN(rowid) FROM secrets WHERE owner = $1 GROUP BY folder_index)`
+	return g.querySecretInfos(ctx, q, ownerID)
+}
+
+// GetTagsByUserID returns encoded info of one secret of every tag, tags of each are decoded only once.
+func (g GophkeeperLocalStorageSqlite) GetTagsByUserID(ctx context.Context, ownerID int64) ([]model.EncodedSecret, error) {
+	q := `SELECT ` + secretInfoColumns + ` FROM secrets WHERE owner = $1
+		AND secret_id IN (SELECT MIN(secret_id) FROM secret_tags WHERE owner = $1 GROUP BY tag_index)`
+	return g.querySecretInfos(ctx, q, ownerID)
+}
+
+func (g GophkeeperLocalStorageSqlite) querySecretInfos(ctx context.Context, q string, args ...any) ([]model.EncodedSecret, error) {
+	secretInfos := make([]model.EncodedSecret, 0)
+
+	rows, err := g.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
@@ -169,8 +445,9 @@ func (g GophkeeperLocalStorageSqlite) GetAllSecretsItemInfoByUserID(ctx context.
 		}
 	}(rows)
 	for rows.Next() {
-		var secretInfo dto.SecretItemInfo
-		err := rows.Scan(&secretInfo.Name, &secretInfo.Description, &secretInfo.SecretType)
+		var secretInfo model.EncodedSecret
+		err := rows.Scan(&secretInfo.ID, &secretInfo.Owner, &secretInfo.Name, &secretInfo.NameIndex, &secretInfo.Description, &secretInfo.Type,
+			&secretInfo.Folder, &secretInfo.FolderIndex, &secretInfo.Tags)
 		if err != nil {
 			return nil, err
 		}
@@ -185,29 +462,43 @@ func (g GophkeeperLocalStorageSqlite) GetAllSecretsItemInfoByUserID(ctx context.
 	return secretInfos, nil
 }
 
-// GetSecretByName returns secret by it name.
-func (g GophkeeperLocalStorageSqlite) GetSecretByName(ctx context.Context, name string) (encSecret model.EncodedSecret, err error) {
-	q := "SELECT secret_id, owner, name, hash, description, enc_data, type, date_last_modified FROM secrets WHERE name = $1"
-	row := g.db.QueryRowContext(ctx, q, name)
+// GetSecretByName returns secret by blind index of it name.
+func (g GophkeeperLocalStorageSqlite) GetSecretByName(ctx context.Context, nameIndex string) (model.EncodedSecret, error) {
+	q := "SELECT " + secretColumns + " FROM secrets WHERE name_index = $1"
+	return scanEncodedSecret(g.db.QueryRowContext(ctx, q, nameIndex))
+}
+
+func scanEncodedSecret(row *sql.Row) (encSecret model.EncodedSecret, err error) {
+	var tagIndexes string
 	err = row.Scan(
 		&encSecret.ID,
 		&encSecret.Owner,
 		&encSecret.Name,
+		&encSecret.NameIndex,
 		&encSecret.Hash,
 		&encSecret.Description,
 		&encSecret.EncodedContent,
 		&encSecret.Type,
-		&encSecret.Timestamp)
+		&encSecret.KeyVersion,
+		&encSecret.Timestamp,
+		&encSecret.Folder,
+		&encSecret.FolderIndex,
+		&encSecret.Tags,
+		&tagIndexes)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return model.EncodedSecret{}, errs.ErrItemNotFound
 		}
+		return
+	}
+	if tagIndexes != "" {
+		encSecret.TagIndexes = strings.Split(tagIndexes, tagIndexesSeparator)
 	}
 	return
 }
 
-// DeleteSecretByName delete secret by it name.
-func (g GophkeeperLocalStorageSqlite) DeleteSecretByName(ctx context.Context, name string) (id string, err error) {
+// DeleteSecretByName delete secret by blind index of it name.
+func (g GophkeeperLocalStorageSqlite) DeleteSecretByName(ctx context.Context, nameIndex string) (id string, err error) {
 	tx, err := g.db.BeginTx(ctx, nil)
 	defer func(tx *sql.Tx) {
 		err := tx.Rollback()
@@ -215,8 +506,8 @@ func (g GophkeeperLocalStorageSqlite) DeleteSecretByName(ctx context.Context, na
 			log.Error(err)
 		}
 	}(tx)
-	idQuery := "SELECT secret_id FROM secrets WHERE name = $1"
-	row := tx.QueryRowContext(ctx, idQuery, name)
+	idQuery := "SELECT secret_id FROM secrets WHERE name_index = $1"
+	row := tx.QueryRowContext(ctx, idQuery, nameIndex)
 	err = row.Scan(&id)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -224,11 +515,92 @@ func (g GophkeeperLocalStorageSqlite) DeleteSecretByName(ctx context.Context, na
 		}
 		return
 	}
-	deleteQuery := "DELETE FROM secrets WHERE name = $1"
-	_, err = tx.ExecContext(ctx, deleteQuery, name)
+	deleteQuery := "DELETE FROM secrets WHERE name_index = $1"
+	_, err = tx.ExecContext(ctx, deleteQuery, nameIndex)
+	if err != nil {
+		return
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM secret_tags WHERE secret_id = $1", id)
+	if err != nil {
+		return
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM attachments WHERE secret_id = $1", id)
 	if err != nil {
 		return
 	}
 	err = tx.Commit()
 	return
 }
+
+// SaveAttachment saves EncodedAttachment, replaces existing one with the same ID,
+// nil content marks attachment which is not downloaded.
+func (g GophkeeperLocalStorageSqlite) SaveAttachment(ctx context.Context, attachment model.EncodedAttachment) error {
+	q := `INSERT INTO attachments (attachment_id, secret_id, owner, name, size, enc_data, hash, key_version, date_last_modified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (attachment_id) DO UPDATE SET secret_id = excluded.secret_id, name = excluded.name, size = excluded.size,
+		enc_data = excluded.enc_data, hash = excluded.hash, key_version = excluded.key_version, date_last_modified = excluded.date_last_modified`
+	_, err := g.db.ExecContext(ctx, q, attachment.ID, attachment.SecretID, attachment.Owner, attachment.Name, attachment.Size,
+		attachment.EncodedContent, attachment.Hash, attachment.KeyVersion, attachment.Timestamp)
+	return err
+}
+
+// GetAttachmentByID returns EncodedAttachment by ID, content is nil if attachment is not downloaded.
+func (g GophkeeperLocalStorageSqlite) GetAttachmentByID(ctx context.Context, id string) (attachment model.EncodedAttachment, err error) {
+	q := `SELECT attachment_id, secret_id, owner, name, size, enc_data, hash, key_version, date_last_modified
+		FROM attachments WHERE attachment_id = $1`
+	err = g.db.QueryRowContext(ctx, q, id).Scan(&attachment.ID, &attachment.SecretID, &attachment.Owner, &attachment.Name, &attachment.Size,
+		&attachment.EncodedContent, &attachment.Hash, &attachment.KeyVersion, &attachment.Timestamp)
+	if err != nil && errors.Is(sql.ErrNoRows, err) {
+		return model.EncodedAttachment{}, errs.ErrItemNotFound
+	}
+	return
+}
+
+// GetAttachmentsBySecretID returns attachments of secret, content of attachments is not loaded.
+func (g GophkeeperLocalStorageSqlite) GetAttachmentsBySecretID(ctx context.Context, secretID string) ([]model.EncodedAttachment, error) {
+	q := "SELECT " + attachmentInfoColumns + " FROM attachments WHERE secret_id = $1 ORDER BY date_last_modified"
+	return g.queryAttachmentInfos(ctx, q, secretID)
+}
+
+// GetAttachmentsByOwnerID returns attachments of user, content of attachments is not loaded.
+func (g GophkeeperLocalStorageSqlite) GetAttachmentsByOwnerID(ctx context.Context, ownerID int64) ([]model.EncodedAttachment, error) {
+	q := "SELECT " + attachmentInfoColumns + " FROM attachments WHERE owner = $1"
+	return g.queryAttachmentInfos(ctx, q, ownerID)
+}
+
+func (g GophkeeperLocalStorageSqlite) queryAttachmentInfos(ctx context.Context, q string, args ...any) ([]model.EncodedAttachment, error) {
+	attachments := make([]model.EncodedAttachment, 0)
+
+	rows, err := g.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}(rows)
+	for rows.Next() {
+		var attachment model.EncodedAttachment
+		err := rows.Scan(&attachment.ID, &attachment.SecretID, &attachment.Owner, &attachment.Name, &attachment.Size,
+			&attachment.Hash, &attachment.KeyVersion, &attachment.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment deletes attachment by ID.
+func (g GophkeeperLocalStorageSqlite) DeleteAttachment(ctx context.Context, id string) error {
+	_, err := g.db.ExecContext(ctx, "DELETE FROM attachments WHERE attachment_id = $1", id)
+	return err
+}
@@ -2,7 +2,11 @@ package view
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
@@ -13,22 +17,31 @@ import (
 )
 
 const (
-	login        string = "login"
-	register     string = "register"
-	logout       string = "logout"
-	addSecret    string = "add secret"
-	getSecret    string = "get secret"
-	deleteSecret string = "delete secret"
-	listSecrets  string = "list secrets"
-	synchronize  string = "synchronize with remote"
-	quite        string = "quite"
-)
-
-const (
-	credentials string = "credentials"
-	text        string = "text"
-	binary      string = "binary"
-	card        string = "card"
+	login              string = "login"
+	register           string = "register"
+	logout             string = "logout"
+	changePassword     string = "change password"
+	rotateVaultKey     string = "rotate vault key"
+	createRecoveryKey  string = "create recovery key"
+	recoverAccount     string = "recover account"
+	unlockWithPin      string = "unlock with PIN"
+	setPin             string = "set PIN"
+	enableTwoFactor    string = "enable two-factor auth"
+	disableTwoFactor   string = "disable two-factor auth"
+	startSSHAgent      string = "start ssh-agent"
+	editCustomFields   string = "edit custom fields"
+	editLabels         string = "edit folder and tags"
+	browseFolders      string = "browse folders"
+	browseTags         string = "browse tags"
+	addAttachment      string = "add attachment"
+	downloadAttachment string = "download attachment"
+	deleteAttachment   string = "delete attachment"
+	addSecret          string = "add secret"
+	getSecret          string = "get secret"
+	deleteSecret       string = "delete secret"
+	listSecrets        string = "list secrets"
+	synchronize        string = "synchronize with remote"
+	quite              string = "quite"
 )
 
 // GophkeeperViewInteractiveCLI cli implementation of GophkeeperView.
@@ -82,65 +95,124 @@ MENU:
 					break MENU
 				}
 			}
-			v.c.Register(ctx, ans.Login, ans.Password, ans.RepeatedPassword)
+			v.c.Register(ctx, ans.Login, ans.Password, ans.RepeatedPassword, ans.CipherSuite, ans.RecoveryKey)
+		case recoverAccount:
+			ans := recoverAccountAnswer{}
+			err := survey.Ask(recoverAccountQuestions, &ans)
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.RecoverAccount(ctx, ans.Login, ans.RecoveryKey, ans.NewPassword, ans.RepeatedPassword)
+		case unlockWithPin:
+			var userLogin string
+			err := survey.AskOne(unlockWithPinQuestion, &userLogin, survey.WithValidator(survey.Required))
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.UnlockWithPin(ctx, userLogin)
 		case logout:
 			v.c.UnAuthorize()
+		case changePassword:
+			ans := changePasswordAnswer{}
+			err := survey.Ask(changePasswordQuestions, &ans)
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.ChangePassword(ctx, ans.OldPassword, ans.NewPassword, ans.RepeatedPassword)
+		case rotateVaultKey:
+			password := v.GetPasswordInput(ctx, "enter your password to rotate vault key:")
+			v.c.RotateVaultKey(ctx, password)
+		case createRecoveryKey:
+			password := v.GetPasswordInput(ctx, "enter your password to create recovery key:")
+			v.c.CreateRecoveryKey(ctx, password)
+		case setPin:
+			ans := setPinAnswer{}
+			err := survey.Ask(setPinQuestions, &ans)
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.SetPin(ctx, ans.Password, ans.Pin, ans.RepeatedPin)
+		case startSSHAgent:
+			v.c.StartSSHAgent(ctx)
+		case enableTwoFactor:
+			v.c.EnableTwoFactor(ctx)
+		case disableTwoFactor:
+			code := v.GetTwoFactorCodeInput(ctx, "enter one-time password or recovery code to disable two-factor auth:")
+			v.c.DisableTwoFactor(ctx, code)
 		case addSecret:
-			err := survey.AskOne(addSelectOptions, &variant, survey.WithValidator(survey.Required))
+			var title string
+			err := survey.AskOne(getAddSelectOptions(), &title, survey.WithValidator(survey.Required))
 			if err != nil {
 				fmt.Println(err)
 				if err == terminal.InterruptErr {
 					break MENU
 				}
 			}
-			var secret model.SecretItem
-			switch variant {
-			case credentials:
-				ans := addCredentialsAnswer{}
-				err := survey.Ask(addCredentialsQuestions, &ans)
-				if err != nil {
-					fmt.Println(err)
-					if err == terminal.InterruptErr {
-						break MENU
-					}
-				}
-				secret = model.NewCredentialsSecretItem(ans.Name, ans.Description, ans.Login, ans.Password)
-			case text:
-				ans := addTextAnswer{}
-				err := survey.Ask(addTextQuestions, &ans)
-				if err != nil {
-					fmt.Println(err)
-					if err == terminal.InterruptErr {
-						break MENU
-					}
-				}
-				secret = model.NewTextSecretItem(ans.Name, ans.Description, ans.Text)
-			case binary:
-				ans := addBinaryAnswer{}
-				err := survey.Ask(addBinaryQuestions, &ans)
-				if err != nil {
-					fmt.Println(err)
-					if err == terminal.InterruptErr {
-						break MENU
-					}
+			secretType, ok := findSecretType(title)
+			if !ok {
+				v.ShowError(fmt.Errorf("unknown secret type %s", title))
+				continue
+			}
+			answers, err := askSecretTypeQuestions(secretType)
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
 				}
-				secret, err = model.NewBinarySecretItem(ans.Name, ans.Description, ans.FilePath)
+				continue
+			}
+			secret, err := secretType.New(answers)
+			if err != nil {
+				v.ShowError(err)
+				continue
+			}
+			var addFields bool
+			err = survey.AskOne(addCustomFieldsQuestion, &addFields)
+			if err == terminal.InterruptErr {
+				discardSecret(secret)
+				break MENU
+			}
+			if addFields {
+				err = secret.SetCustomFields(v.EditCustomFields(ctx, nil))
 				if err != nil {
 					v.ShowError(err)
+					discardSecret(secret)
 					continue
 				}
-			case card:
-				ans := addCardAnswer{}
-				err := survey.Ask(addCardQuestions, &ans)
-				if err != nil {
-					fmt.Println(err)
-					if err == terminal.InterruptErr {
-						break MENU
-					}
+			}
+			v.c.SaveSecret(ctx, secret, v.EditSecretLabels(ctx, dto.SecretLabels{}))
+		case editCustomFields:
+			var name string
+			err := survey.AskOne(getSecretNameQuestion, &name, survey.WithValidator(survey.Required))
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
 				}
-				secret = model.NewCardSecretItem(ans.Name, ans.Description, ans.CardName, ans.CardNumber, ans.CardCVV)
 			}
-			v.c.SaveSecret(ctx, secret)
+			v.c.EditSecretCustomFields(ctx, name)
+		case editLabels:
+			var name string
+			err := survey.AskOne(getSecretNameQuestion, &name, survey.WithValidator(survey.Required))
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.EditSecretLabels(ctx, name)
 		case getSecret:
 			var name string
 			err := survey.AskOne(getSecretNameQuestion, &name, survey.WithValidator(survey.Required))
@@ -161,8 +233,42 @@ MENU:
 				}
 			}
 			v.c.DeleteSecret(ctx, name)
+		case addAttachment:
+			ans := attachmentAnswer{}
+			err := survey.Ask(addAttachmentQuestions, &ans)
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.AddAttachment(ctx, ans.Name, ans.Path)
+		case downloadAttachment:
+			var name string
+			err := survey.AskOne(getSecretNameQuestion, &name, survey.WithValidator(survey.Required))
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.DownloadAttachment(ctx, name)
+		case deleteAttachment:
+			var name string
+			err := survey.AskOne(getSecretNameQuestion, &name, survey.WithValidator(survey.Required))
+			if err != nil {
+				fmt.Println(err)
+				if err == terminal.InterruptErr {
+					break MENU
+				}
+			}
+			v.c.DeleteAttachment(ctx, name)
 		case listSecrets:
 			v.c.ListSecret(ctx)
+		case browseFolders:
+			v.c.BrowseFolders(ctx)
+		case browseTags:
+			v.c.BrowseTags(ctx)
 		case synchronize:
 			v.c.Synchronize(ctx)
 		case quite:
@@ -182,11 +288,12 @@ func (v *GophkeeperViewInteractiveCLI) SetAuthorized(isAuthorized bool) {
 
 // ViewSecretsInfoList shows secret info list.
 func (v *GophkeeperViewInteractiveCLI) ViewSecretsInfoList(secretInfos []dto.SecretItemInfo) {
-	headers := []string{"NAME", "TYPE", "DESCRIPTION"}
+	headers := []string{"NAME", "TYPE", "FOLDER", "TAGS", "DESCRIPTION"}
 	tableData := make(pterm.TableData, len(secretInfos)+1, len(secretInfos)+1)
 	tableData = append(tableData, headers)
 	for _, secretInfo := range secretInfos {
-		row := []string{secretInfo.Name, secretInfo.SecretType, secretInfo.Description}
+		row := []string{secretInfo.Name, secretInfo.SecretType, model.FolderSeparator + secretInfo.Folder,
+			strings.Join(secretInfo.Tags, ", "), secretInfo.Description}
 		tableData = append(tableData, row)
 	}
 	err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
@@ -200,6 +307,36 @@ func (v *GophkeeperViewInteractiveCLI) ShowSecretItem(item model.SecretItem) {
 	pterm.Info.Println(item.GetSecretPayload())
 }
 
+// ShowRecoveryKey shows recovery key, it is shown only once.
+func (v *GophkeeperViewInteractiveCLI) ShowRecoveryKey(recoveryKey string) {
+	pterm.Warning.Println("your recovery key is shown only once, print it or write it down and keep it in a safe place.\n" +
+		"it is the only way to restore access to your secrets if you forget your password.")
+	pterm.Info.Println(recoveryKey)
+}
+
+// ShowTwoFactorSetup shows TOTP seed and otpauth URI to import into authenticator application.
+func (v *GophkeeperViewInteractiveCLI) ShowTwoFactorSetup(secret, uri string) {
+	pterm.Info.Println("add this key to your authenticator application:\n" + secret + "\nor import URI:\n" + uri)
+}
+
+// ShowRecoveryCodes shows two-factor recovery codes, they are shown only once.
+func (v *GophkeeperViewInteractiveCLI) ShowRecoveryCodes(recoveryCodes []string) {
+	pterm.Warning.Println("two-factor recovery codes are shown only once, write them down and keep them in a safe place.\n" +
+		"each code can be used once instead of one-time password.")
+	pterm.Info.Println(strings.Join(recoveryCodes, "\n"))
+}
+
+// ShowSSHAgentStarted shows socket of started ssh-agent and number of served keys.
+func (v *GophkeeperViewInteractiveCLI) ShowSSHAgentStarted(socketPath string, keysCount int) {
+	pterm.Info.Printf("ssh-agent serves %d key(s), it is stopped when vault is locked. to use it run:\nexport SSH_AUTH_SOCK=%s\n",
+		keysCount, socketPath)
+}
+
+// ShowWarning shows warning.
+func (v *GophkeeperViewInteractiveCLI) ShowWarning(message string) {
+	pterm.Warning.Println(message)
+}
+
 // ShowError shows error.
 func (v *GophkeeperViewInteractiveCLI) ShowError(err error) {
 	pterm.Error.Println(err)
@@ -218,3 +355,270 @@ func (v *GophkeeperViewInteractiveCLI) GetStringInput(ctx context.Context, input
 	}
 	return path
 }
+
+// GetPasswordInput gets hidden password input.
+func (v *GophkeeperViewInteractiveCLI) GetPasswordInput(ctx context.Context, inputText string) string {
+	var password string
+	err := survey.AskOne(&survey.Password{Message: inputText}, &password, survey.WithValidator(survey.Required))
+	if err != nil {
+		fmt.Println(err)
+		if err == terminal.InterruptErr {
+			return ""
+		}
+		v.ShowError(err)
+	}
+	return password
+}
+
+// GetPinInput gets hidden PIN input.
+func (v *GophkeeperViewInteractiveCLI) GetPinInput(ctx context.Context, inputText string) string {
+	var pin string
+	err := survey.AskOne(&survey.Password{Message: inputText}, &pin, survey.WithValidator(survey.Required))
+	if err != nil {
+		fmt.Println(err)
+		if err == terminal.InterruptErr {
+			return ""
+		}
+		v.ShowError(err)
+	}
+	return pin
+}
+
+// findSecretType returns registered secret type by title shown in menu.
+func findSecretType(title string) (model.SecretType, bool) {
+	for _, secretType := range model.SecretTypes() {
+		if secretType.Title == title {
+			return secretType, true
+		}
+	}
+	return model.SecretType{}, false
+}
+
+// askSecretTypeQuestions asks questions of secret type one by one, so conditional questions see previous answers.
+func askSecretTypeQuestions(secretType model.SecretType) (model.Answers, error) {
+	answers := make(model.Answers, len(secretType.Questions))
+	for _, question := range secretType.Questions {
+		if question.When != nil && !question.When(answers) {
+			continue
+		}
+		var answer string
+		err := survey.Ask([]*survey.Question{secretTypeQuestion(question)}, &answer)
+		if err != nil {
+			return nil, err
+		}
+		answers[question.Name] = answer
+	}
+	return answers, nil
+}
+
+// discardSecret releases new secret which is not saved, binary secret of directory holds temporary archive.
+func discardSecret(secret model.SecretItem) {
+	if closer, ok := secret.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// EditCustomFields lets user add, change, reorder and remove custom fields, returns edited fields.
+func (v *GophkeeperViewInteractiveCLI) EditCustomFields(ctx context.Context, fields []model.CustomField) []model.CustomField {
+	fields = append([]model.CustomField(nil), fields...)
+	for {
+		if len(fields) > 0 {
+			tableData := pterm.TableData{{"#", "Name", "Kind"}}
+			for i, field := range fields {
+				tableData = append(tableData, []string{strconv.Itoa(i + 1), field.Name, field.Kind})
+			}
+			if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+				v.ShowError(err)
+			}
+		}
+		var action string
+		err := survey.AskOne(customFieldsActions, &action)
+		if err != nil || action == fieldsDone {
+			return fields
+		}
+		if action == addField {
+			field, ok := v.askCustomField(model.CustomField{Kind: model.FieldText})
+			if ok {
+				fields = append(fields, field)
+			}
+			continue
+		}
+		index, ok := v.selectCustomField(fields)
+		if !ok {
+			continue
+		}
+		switch action {
+		case editField:
+			if field, ok := v.askCustomField(fields[index]); ok {
+				fields[index] = field
+			}
+		case moveFieldUp:
+			if index > 0 {
+				fields[index-1], fields[index] = fields[index], fields[index-1]
+			}
+		case removeField:
+			fields = append(fields[:index], fields[index+1:]...)
+		}
+	}
+}
+
+// askCustomField asks name, kind and value of custom field, hidden values are entered without echo.
+func (v *GophkeeperViewInteractiveCLI) askCustomField(current model.CustomField) (model.CustomField, bool) {
+	ans := customFieldAnswer{}
+	err := survey.Ask(customFieldQuestions(current), &ans)
+	if err != nil {
+		return model.CustomField{}, false
+	}
+	var value string
+	var prompt survey.Prompt = &survey.Input{Message: customFieldValueMessage(ans.Kind), Default: current.Value}
+	if ans.Kind == model.FieldHidden {
+		prompt = &survey.Password{Message: customFieldValueMessage(ans.Kind)}
+	}
+	if err = survey.AskOne(prompt, &value); err != nil {
+		return model.CustomField{}, false
+	}
+	field, err := model.NewCustomField(ans.Name, ans.Kind, value)
+	if err != nil {
+		v.ShowError(err)
+		return model.CustomField{}, false
+	}
+	return field, true
+}
+
+func (v *GophkeeperViewInteractiveCLI) selectCustomField(fields []model.CustomField) (int, bool) {
+	if len(fields) == 0 {
+		v.ShowError(errors.New("there are no custom fields"))
+		return 0, false
+	}
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	var index int
+	err := survey.AskOne(&survey.Select{Message: "Select field:", Options: names}, &index)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// EditSecretLabels asks folder and tags of secret, current labels are offered as defaults.
+func (v *GophkeeperViewInteractiveCLI) EditSecretLabels(ctx context.Context, labels dto.SecretLabels) dto.SecretLabels {
+	ans := labelsAnswer{}
+	err := survey.Ask(labelsQuestions(labels), &ans)
+	if err != nil {
+		fmt.Println(err)
+		return labels
+	}
+	return dto.SecretLabels{Folder: model.NormalizeFolder(ans.Folder), Tags: model.ParseTags(ans.Tags)}
+}
+
+// SelectFolder lets user go into sub folders and back to parent folder until current folder is chosen.
+func (v *GophkeeperViewInteractiveCLI) SelectFolder(ctx context.Context, folders []string) (string, bool) {
+	current := ""
+	for {
+		options := []string{showFolderSecrets}
+		if current != "" {
+			options = append(options, parentFolder)
+		}
+		subFolders := model.SubFolders(folders, current)
+		for _, subFolder := range subFolders {
+			options = append(options, model.FolderSeparator+subFolder)
+		}
+		var choice string
+		err := survey.AskOne(&survey.Select{Message: "Folder " + model.FolderSeparator + current + ":", Options: options}, &choice)
+		if err != nil {
+			fmt.Println(err)
+			return "", false
+		}
+		switch choice {
+		case showFolderSecrets:
+			return current, true
+		case parentFolder:
+			if i := strings.LastIndex(current, model.FolderSeparator); i >= 0 {
+				current = current[:i]
+			} else {
+				current = ""
+			}
+		default:
+			current = strings.TrimPrefix(choice, model.FolderSeparator)
+		}
+	}
+}
+
+// SelectTag lets user choose one of tags.
+func (v *GophkeeperViewInteractiveCLI) SelectTag(ctx context.Context, tags []string) (string, bool) {
+	var tag string
+	err := survey.AskOne(&survey.Select{Message: "Select tag:", Options: tags}, &tag)
+	if err != nil {
+		fmt.Println(err)
+		return "", false
+	}
+	return tag, true
+}
+
+// ShowAttachments shows files attached to secret.
+func (v *GophkeeperViewInteractiveCLI) ShowAttachments(attachments []dto.AttachmentInfo) {
+	tableData := pterm.TableData{{"ATTACHMENT", "SIZE"}}
+	for _, attachment := range attachments {
+		tableData = append(tableData, []string{attachment.Name, formatSize(attachment.Size)})
+	}
+	err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	if err != nil {
+		v.ShowError(fmt.Errorf("failed to show attachments: %w", err))
+	}
+}
+
+// SelectAttachment lets user choose one of attachments, returns its ID.
+func (v *GophkeeperViewInteractiveCLI) SelectAttachment(ctx context.Context, attachments []dto.AttachmentInfo) (string, bool) {
+	options := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		options = append(options, fmt.Sprintf("%s (%s)", attachment.Name, formatSize(attachment.Size)))
+	}
+	var index int
+	err := survey.AskOne(&survey.Select{Message: "Select attachment:", Options: options}, &index)
+	if err != nil {
+		fmt.Println(err)
+		return "", false
+	}
+	return attachments[index].ID, true
+}
+
+// formatSize returns size in bytes in human-readable form.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// GetTwoFactorCodeInput gets one-time password or two-factor recovery code.
+func (v *GophkeeperViewInteractiveCLI) GetTwoFactorCodeInput(ctx context.Context, inputText string) string {
+	var code string
+	err := survey.AskOne(&survey.Input{Message: inputText}, &code, survey.WithValidator(survey.Required))
+	if err != nil {
+		fmt.Println(err)
+		if err == terminal.InterruptErr {
+			return ""
+		}
+		v.ShowError(err)
+	}
+	return code
+}
+
+// GetConfirmation asks yes or no question, default answer is no.
+func (v *GophkeeperViewInteractiveCLI) GetConfirmation(ctx context.Context, inputText string) bool {
+	var confirmed bool
+	err := survey.AskOne(&survey.Confirm{Message: inputText, Default: false}, &confirmed)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return confirmed
+}
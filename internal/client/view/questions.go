@@ -1,10 +1,18 @@
 package view
 
-import "github.com/AlecAivazis/survey/v2"
+import (
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/apolsh/yapr-gophkeeper/internal/model"
+	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
+)
 
 var (
-	unauthorizedMenuItems = []string{login, register, quite}
-	authorizedMenuItems   = []string{logout, addSecret, getSecret, deleteSecret, listSecrets, synchronize, quite}
+	unauthorizedMenuItems = []string{login, unlockWithPin, register, recoverAccount, quite}
+	authorizedMenuItems   = []string{logout, changePassword, rotateVaultKey, createRecoveryKey, setPin,
+		enableTwoFactor, disableTwoFactor, startSSHAgent, addSecret, editCustomFields, editLabels, getSecret, deleteSecret, listSecrets,
+		browseFolders, browseTags, addAttachment, downloadAttachment, deleteAttachment, synchronize, quite}
 )
 
 func getMainMenu(isAuthorized bool) *survey.Select {
@@ -55,135 +63,228 @@ var registerQuestions = []*survey.Question{
 		Prompt:   &survey.Password{Message: "Repeat Password"},
 		Validate: survey.Required,
 	},
+	{
+		Name: "CipherSuite",
+		Prompt: &survey.Select{
+			Message: "Choose encryption algorithm for your secrets",
+			Options: model.CipherSuites,
+			Default: model.CipherSuiteAESGCM,
+		},
+	},
+	{
+		Name: "RecoveryKey",
+		Prompt: &survey.Confirm{
+			Message: "Create recovery key to restore access if you forget your password?",
+			Default: true,
+		},
+	},
 }
 
 type resisterAnswer struct {
 	Login            string
 	Password         string
 	RepeatedPassword string
+	CipherSuite      string
+	RecoveryKey      bool
 }
 
-var addCredentialsQuestions = []*survey.Question{
+var recoverAccountQuestions = []*survey.Question{
 	{
-		Name:     "Name",
-		Prompt:   &survey.Input{Message: "Enter secret name to store"},
-		Validate: survey.MinLength(3),
+		Name:     "Login",
+		Prompt:   &survey.Input{Message: "Enter your Login"},
+		Validate: survey.Required,
 	},
 	{
-		Name:     "Login",
-		Prompt:   &survey.Input{Message: "Enter your Login to store"},
+		Name:     "RecoveryKey",
+		Prompt:   &survey.Password{Message: "Enter your recovery key"},
 		Validate: survey.Required,
 	},
 	{
-		Name:     "Password",
-		Prompt:   &survey.Password{Message: "Enter your Password to store"},
+		Name:     "NewPassword",
+		Prompt:   &survey.Password{Message: "Enter new Password"},
 		Validate: survey.Required,
 	},
 	{
-		Name:     "Description",
-		Prompt:   &survey.Input{Message: "Enter description to store"},
+		Name:     "RepeatedPassword",
+		Prompt:   &survey.Password{Message: "Repeat new Password"},
 		Validate: survey.Required,
 	},
 }
 
-type addCredentialsAnswer struct {
-	Name        string
-	Login       string
-	Password    string
-	Description string
+type recoverAccountAnswer struct {
+	Login            string
+	RecoveryKey      string
+	NewPassword      string
+	RepeatedPassword string
 }
 
-var addTextQuestions = []*survey.Question{
+var unlockWithPinQuestion = &survey.Input{Message: "Enter your Login"}
+
+var setPinQuestions = []*survey.Question{
 	{
-		Name:     "Name",
-		Prompt:   &survey.Input{Message: "Enter secret name"},
-		Validate: survey.MinLength(3),
+		Name:     "Password",
+		Prompt:   &survey.Password{Message: "Enter your Password"},
+		Validate: survey.Required,
 	},
 	{
-		Name:     "Text",
-		Prompt:   &survey.Input{Message: "Enter your textual data"},
+		Name:     "Pin",
+		Prompt:   &survey.Password{Message: "Enter new PIN"},
 		Validate: survey.Required,
 	},
 	{
-		Name:     "Description",
-		Prompt:   &survey.Input{Message: "Enter description"},
+		Name:     "RepeatedPin",
+		Prompt:   &survey.Password{Message: "Repeat new PIN"},
 		Validate: survey.Required,
 	},
 }
 
-type addTextAnswer struct {
-	Name        string
-	Text        string
-	Description string
+type setPinAnswer struct {
+	Password    string
+	Pin         string
+	RepeatedPin string
 }
 
-var addBinaryQuestions = []*survey.Question{
+var changePasswordQuestions = []*survey.Question{
 	{
-		Name:     "Name",
-		Prompt:   &survey.Input{Message: "Enter secret name"},
-		Validate: survey.MinLength(3),
+		Name:     "OldPassword",
+		Prompt:   &survey.Password{Message: "Enter your current Password"},
+		Validate: survey.Required,
 	},
 	{
-		Name:     "Filepath",
-		Prompt:   &survey.Input{Message: "Enter filepath"},
+		Name:     "NewPassword",
+		Prompt:   &survey.Password{Message: "Enter new Password"},
 		Validate: survey.Required,
 	},
 	{
-		Name:     "Description",
-		Prompt:   &survey.Input{Message: "Enter description"},
+		Name:     "RepeatedPassword",
+		Prompt:   &survey.Password{Message: "Repeat new Password"},
 		Validate: survey.Required,
 	},
 }
 
-type addBinaryAnswer struct {
-	Name        string
-	FilePath    string
-	Description string
+type changePasswordAnswer struct {
+	OldPassword      string
+	NewPassword      string
+	RepeatedPassword string
 }
 
-var addCardQuestions = []*survey.Question{
+func labelsQuestions(current dto.SecretLabels) []*survey.Question {
+	return []*survey.Question{
+		{
+			Name:   "Folder",
+			Prompt: &survey.Input{Message: "Enter folder, e.g. work/aws (leave empty for root folder)", Default: current.Folder},
+		},
+		{
+			Name:   "Tags",
+			Prompt: &survey.Input{Message: "Enter tags separated by comma (leave empty for no tags)", Default: strings.Join(current.Tags, ", ")},
+		},
+	}
+}
+
+type labelsAnswer struct {
+	Folder string
+	Tags   string
+}
+
+const (
+	showFolderSecrets string = "show secrets of this folder"
+	parentFolder      string = ".."
+)
+
+var addCustomFieldsQuestion = &survey.Confirm{Message: "Add custom fields?", Default: false}
+
+const (
+	addField    string = "add field"
+	editField   string = "edit field"
+	moveFieldUp string = "move field up"
+	removeField string = "remove field"
+	fieldsDone  string = "done"
+)
+
+var customFieldsActions = &survey.Select{
+	Message: "What do you want to do with custom fields ?:",
+	Options: []string{addField, editField, moveFieldUp, removeField, fieldsDone},
+}
+
+func customFieldQuestions(current model.CustomField) []*survey.Question {
+	return []*survey.Question{
+		{
+			Name:     "Name",
+			Prompt:   &survey.Input{Message: "Enter field name", Default: current.Name},
+			Validate: survey.Required,
+		},
+		{
+			Name: "Kind",
+			Prompt: &survey.Select{
+				Message: "Choose field kind",
+				Options: model.FieldKinds,
+				Default: current.Kind,
+			},
+		},
+	}
+}
+
+type customFieldAnswer struct {
+	Name string
+	Kind string
+}
+
+func customFieldValueMessage(kind string) string {
+	if kind == model.FieldDate {
+		return "Enter field value (YYYY-MM-DD)"
+	}
+	return "Enter field value"
+}
+
+func getAddSelectOptions() *survey.Select {
+	var options []string
+	for _, secretType := range model.SecretTypes() {
+		options = append(options, secretType.Title)
+	}
+	return &survey.Select{
+		Message: "What type of credentials do you want to save ?:",
+		Options: options,
+	}
+}
+
+// secretTypeQuestion converts question of secret type into survey question.
+func secretTypeQuestion(question model.Question) *survey.Question {
+	var prompt survey.Prompt
+	switch question.Kind {
+	case model.QuestionPassword:
+		prompt = &survey.Password{Message: question.Message}
+	case model.QuestionSelect:
+		prompt = &survey.Select{Message: question.Message, Options: question.Options, Default: question.Default}
+	default:
+		prompt = &survey.Input{Message: question.Message, Default: question.Default}
+	}
+	var validator survey.Validator
+	if question.MinLength > 0 {
+		validator = survey.MinLength(question.MinLength)
+	}
+	return &survey.Question{Name: question.Name, Prompt: prompt, Validate: validator}
+}
+
+var getSecretNameQuestion = &survey.Input{
+	Message: "Enter stored secret name:",
+}
+
+var addAttachmentQuestions = []*survey.Question{
 	{
 		Name:     "Name",
-		Prompt:   &survey.Input{Message: "Enter secret name"},
-		Validate: survey.MinLength(3),
-	},
-	{
-		Name:     "CardNumber",
-		Prompt:   &survey.Input{Message: "Enter card number"},
-		Validate: survey.Required,
-	},
-	{
-		Name:     "CardName",
-		Prompt:   &survey.Input{Message: "Enter card owner name"},
+		Prompt:   getSecretNameQuestion,
 		Validate: survey.Required,
 	},
 	{
-		Name:     "CardCVV",
-		Prompt:   &survey.Input{Message: "Enter cvv"},
+		Name:     "Path",
+		Prompt:   &survey.Input{Message: "Enter the path to the file to attach:"},
 		Validate: survey.Required,
 	},
-	{
-		Name:     "Description",
-		Prompt:   &survey.Input{Message: "Enter description"},
-		Validate: survey.Required,
-	},
-}
-
-type addCardAnswer struct {
-	Name        string
-	CardNumber  string
-	CardName    string
-	CardCVV     string
-	Description string
 }
 
-var addSelectOptions = &survey.Select{
-	Message: "What type of credentials do you want to save ?:",
-	Options: []string{credentials, text, binary, card},
-}
-
-var getSecretNameQuestion = &survey.Input{
-	Message: "Enter stored secret name:",
+type attachmentAnswer struct {
+	Name string
+	Path string
 }
 
 var getStoreFilepathQuestion = &survey.Input{
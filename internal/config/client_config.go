@@ -14,6 +14,7 @@ type ClientConfig struct {
 	SyncServerURL string `env:"GOPHKEEPER_SYNC_SERVER_URL" envDefault:":3333"`
 	LogLevel      string `env:"GOPHKEEPER_LOG_LEVEL" envDefault:"info"`
 	SyncPeriod    int64  `env:"GOPHKEEPER_SYNC_PERIOD" envDefault:"100"`
+	IdleTimeout   int64  `env:"GOPHKEEPER_IDLE_TIMEOUT" envDefault:"300"`
 	HTTPSEnabled  bool   `env:"ENABLE_HTTPS" json:"enable_https"`
 }
 
@@ -27,6 +28,9 @@ func (c *ClientConfig) populateEmptyFields(another ClientConfig) {
 	if c.SyncPeriod == 30 && another.SyncPeriod != 30 {
 		c.SyncPeriod = another.SyncPeriod
 	}
+	if c.IdleTimeout == 300 && another.IdleTimeout != 300 {
+		c.IdleTimeout = another.IdleTimeout
+	}
 	if !c.HTTPSEnabled && another.HTTPSEnabled {
 		c.HTTPSEnabled = another.HTTPSEnabled
 	}
@@ -40,6 +44,7 @@ func LoadClientConfig() (ClientConfig, error) {
 	flag.StringVar(&mainConfig.BaseDir, "baseDir", "", "base directory where Gophkeeper will store data")
 	flag.StringVar(&mainConfig.SyncServerURL, "server", "", "gophkeeper synchronization server")
 	flag.Int64Var(&mainConfig.SyncPeriod, "syncPeriod", 30, "gophkeeper synchronization period, in seconds")
+	flag.Int64Var(&mainConfig.IdleTimeout, "idleTimeout", 300, "inactivity period after which vault is locked, in seconds, 0 disables auto-lock")
 	flag.BoolVar(&mainConfig.HTTPSEnabled, "t", true, "enable HTTPS with self signed certificate")
 
 	flag.Parse()
@@ -18,6 +18,7 @@ type ServerConfig struct {
 	LogLevel       string `env:"LOG_LEVEL" envDefault:"info"`
 	TokenSecretKey string `env:"TOKEN_SECRET_KEY" envDefault:"secret"`
 	HTTPSEnabled   bool   `env:"ENABLE_HTTPS" json:"enable_https"`
+	ChunkGCPeriod  int64  `env:"CHUNK_GC_PERIOD" envDefault:"3600"`
 }
 
 func (c *ServerConfig) populateEmptyFields(another ServerConfig) {
@@ -36,6 +37,9 @@ func (c *ServerConfig) populateEmptyFields(another ServerConfig) {
 	if !c.HTTPSEnabled && another.HTTPSEnabled {
 		c.HTTPSEnabled = another.HTTPSEnabled
 	}
+	if c.ChunkGCPeriod == 3600 && another.ChunkGCPeriod != 3600 {
+		c.ChunkGCPeriod = another.ChunkGCPeriod
+	}
 }
 
 // LoadServerConfig reads environment variables and flags, prior to flags.
@@ -48,6 +52,7 @@ func LoadServerConfig() ServerConfig {
 	flag.StringVar(&mainConfig.Storage, "st", "", "storage type (postgres)")
 	flag.StringVar(&mainConfig.TokenSecretKey, "s", "", "secret key for token generator")
 	flag.BoolVar(&mainConfig.HTTPSEnabled, "t", true, "enable HTTPS with self signed certificate")
+	flag.Int64Var(&mainConfig.ChunkGCPeriod, "gc", 3600, "period of removal of file chunks not referenced by secrets, in seconds")
 
 	flag.Parse()
 
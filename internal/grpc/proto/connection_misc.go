@@ -12,6 +12,7 @@ const servicePath = "/proto.Gophkeeper/"
 
 // DefaultAuthMethods default authorization schema for grpc methods.
 var DefaultAuthMethods = map[string]bool{
+	servicePath + "GetAuthParams":           false,
 	servicePath + "Login":                   false,
 	servicePath + "Register":                false,
 	servicePath + "GetSecretSyncMeta":       true,
@@ -19,25 +20,116 @@ var DefaultAuthMethods = map[string]bool{
 	servicePath + "GetSecret":               true,
 	servicePath + "SaveEncodedSecret":       true,
 	servicePath + "DeleteSecret":            true,
+	servicePath + "ChangePassword":          true,
+	servicePath + "GetChangeEvents":         true,
+	servicePath + "StartKeyRotation":        true,
+	servicePath + "FinishKeyRotation":       true,
+	servicePath + "SetRecoveryKit":          true,
+	servicePath + "GetRecoveryKit":          false,
+	servicePath + "RecoverAccount":          false,
+	servicePath + "VerifyTwoFactor":         true,
+	servicePath + "EnableTwoFactor":         true,
+	servicePath + "DisableTwoFactor":        true,
+	servicePath + "GetAttachmentsMeta":      true,
+	servicePath + "GetAttachment":           true,
+	servicePath + "SaveAttachment":          true,
+	servicePath + "DeleteAttachment":        true,
+	servicePath + "GetMissingChunks":        true,
+	servicePath + "UploadChunks":            true,
+	servicePath + "SetSecretChunks":         true,
+	servicePath + "DownloadSecretChunks":    true,
+}
+
+// RestrictedAuthMethods methods which accept only restricted token issued by the first step of two-factor login.
+var RestrictedAuthMethods = map[string]bool{
+	servicePath + "VerifyTwoFactor": true,
 }
 
 // NewUserFromProtoUser convert proto user to model user.
 func NewUserFromProtoUser(protoUser *User) model.User {
 	return model.User{
-		ID:             protoUser.GetID(),
-		Login:          protoUser.GetUsername(),
-		HashedPassword: protoUser.GetPasswordHash(),
-		Timestamp:      protoUser.GetTimestamp(),
+		ID:              protoUser.GetID(),
+		Login:           protoUser.GetUsername(),
+		KDF:             KDFParamsFromProto(protoUser.GetKdfParams()),
+		WrappedVaultKey: protoUser.GetWrappedVaultKey(),
+		CipherSuite:     protoUser.GetCipherSuite(),
+		KeyVersion:      protoUser.GetKeyVersion(),
+
+		PendingWrappedVaultKey:  protoUser.GetPendingWrappedVaultKey(),
+		RecoveryWrappedVaultKey: protoUser.GetRecoveryWrappedVaultKey(),
+		TwoFactorEnabled:        protoUser.GetTwoFactorEnabled(),
+		Timestamp:               protoUser.GetTimestamp(),
 	}
 }
 
-// NewProtoUserFromUser  convert model user to proto user.
+// NewProtoUserFromUser  convert model user to proto user, password hash never leaves server.
 func NewProtoUserFromUser(user model.User) *User {
 	return &User{
-		ID:           user.ID,
-		Username:     user.Login,
-		PasswordHash: user.HashedPassword,
-		Timestamp:    user.Timestamp,
+		ID:              user.ID,
+		Username:        user.Login,
+		Timestamp:       user.Timestamp,
+		KdfParams:       NewProtoKDFParams(user.KDF),
+		WrappedVaultKey: user.WrappedVaultKey,
+		CipherSuite:     user.CipherSuite,
+		KeyVersion:      user.KeyVersion,
+
+		PendingWrappedVaultKey:  user.PendingWrappedVaultKey,
+		RecoveryWrappedVaultKey: user.RecoveryWrappedVaultKey,
+		TwoFactorEnabled:        user.TwoFactorEnabled,
+	}
+}
+
+// KDFParamsFromProto convert proto KDF parameters to model KDF parameters.
+func KDFParamsFromProto(proto *KDFParams) model.KDFParams {
+	return model.KDFParams{
+		Algorithm: proto.GetAlgorithm(),
+		Salt:      proto.GetSalt(),
+		Time:      proto.GetTime(),
+		Memory:    proto.GetMemory(),
+		Threads:   uint8(proto.GetThreads()),
+	}
+}
+
+// NewProtoKDFParams convert model KDF parameters to proto KDF parameters.
+func NewProtoKDFParams(params model.KDFParams) *KDFParams {
+	return &KDFParams{
+		Algorithm: params.Algorithm,
+		Salt:      params.Salt,
+		Time:      params.Time,
+		Memory:    params.Memory,
+		Threads:   uint32(params.Threads),
+	}
+}
+
+// RecoveryKitFromProto convert proto recovery kit to model recovery kit.
+func RecoveryKitFromProto(proto *RecoveryKit) model.RecoveryKit {
+	return model.RecoveryKit{
+		AuthKey:         proto.GetAuthKey(),
+		WrappedVaultKey: proto.GetWrappedVaultKey(),
+	}
+}
+
+// NewProtoRecoveryKit convert model recovery kit to proto recovery kit.
+func NewProtoRecoveryKit(kit model.RecoveryKit) *RecoveryKit {
+	return &RecoveryKit{
+		AuthKey:         kit.AuthKey,
+		WrappedVaultKey: kit.WrappedVaultKey,
+	}
+}
+
+// AuthParamsFromProto convert proto auth params to model auth params.
+func AuthParamsFromProto(proto *AuthParams) model.AuthParams {
+	return model.AuthParams{
+		KDF:           KDFParamsFromProto(proto.GetKdfParams()),
+		ZeroKnowledge: proto.GetZeroKnowledge(),
+	}
+}
+
+// NewProtoAuthParams convert model auth params to proto auth params.
+func NewProtoAuthParams(params model.AuthParams) *AuthParams {
+	return &AuthParams{
+		KdfParams:     NewProtoKDFParams(params.KDF),
+		ZeroKnowledge: params.ZeroKnowledge,
 	}
 }
 
@@ -55,12 +147,18 @@ func EncodedSecretFromProto(proto *EncodedSecret) model.EncodedSecret {
 	return model.EncodedSecret{
 		ID:             proto.GetId(),
 		Name:           proto.GetName(),
+		NameIndex:      proto.GetNameIndex(),
+		KeyVersion:     proto.GetKeyVersion(),
 		Owner:          proto.GetOwner(),
 		Description:    proto.GetDescription(),
-		Type:           getTypeFromProto(proto.GetType()),
+		Type:           getTypeFromProto(proto.GetType(), proto.GetTypeName()),
 		EncodedContent: proto.GetEncData(),
 		Hash:           proto.GetHash(),
 		Timestamp:      proto.GetDateLastModified(),
+		Folder:         proto.GetFolder(),
+		FolderIndex:    proto.GetFolderIndex(),
+		Tags:           proto.GetTags(),
+		TagIndexes:     proto.GetTagIndexes(),
 	}
 }
 
@@ -75,6 +173,13 @@ func EncSecretProtoFromEncSecret(encSecret model.EncodedSecret) *EncodedSecret {
 		EncData:          encSecret.EncodedContent,
 		Hash:             encSecret.Hash,
 		DateLastModified: encSecret.Timestamp,
+		NameIndex:        encSecret.NameIndex,
+		KeyVersion:       encSecret.KeyVersion,
+		TypeName:         encSecret.Type,
+		Folder:           encSecret.Folder,
+		FolderIndex:      encSecret.FolderIndex,
+		Tags:             encSecret.Tags,
+		TagIndexes:       encSecret.TagIndexes,
 	}
 }
 
@@ -87,32 +192,72 @@ func SecretSyncMetadataFromProto(proto *SecretSyncData) dto.SecretSyncMetadata {
 	}
 }
 
-func getTypeFromProto(proto SECRET_TYPE) string {
-	switch proto {
-	case SECRET_TYPE_CREDENTIALS:
-		return model.Credentials
-	case SECRET_TYPE_TEXT:
-		return model.Text
-	case SECRET_TYPE_BINARY:
-		return model.Binary
-	case SECRET_TYPE_CARD:
-		return model.Card
-	default:
-		return model.Text
+// EncodedAttachmentFromProto convert proto EncodedAttachment to model.
+func EncodedAttachmentFromProto(proto *EncodedAttachment) model.EncodedAttachment {
+	return model.EncodedAttachment{
+		ID:             proto.GetId(),
+		SecretID:       proto.GetSecretID(),
+		Owner:          proto.GetOwner(),
+		Name:           proto.GetName(),
+		Size:           proto.GetSize(),
+		EncodedContent: proto.GetEncData(),
+		Hash:           proto.GetHash(),
+		Timestamp:      proto.GetDateLastModified(),
+		KeyVersion:     proto.GetKeyVersion(),
+	}
+}
+
+// NewProtoEncodedAttachment convert model EncodedAttachment to proto.
+func NewProtoEncodedAttachment(attachment model.EncodedAttachment) *EncodedAttachment {
+	return &EncodedAttachment{
+		Id:               attachment.ID,
+		SecretID:         attachment.SecretID,
+		Owner:            attachment.Owner,
+		Name:             attachment.Name,
+		Size:             attachment.Size,
+		EncData:          attachment.EncodedContent,
+		Hash:             attachment.Hash,
+		DateLastModified: attachment.Timestamp,
+		KeyVersion:       attachment.KeyVersion,
+	}
+}
+
+// SecretChunkFromProto convert proto SecretChunk to model.
+func SecretChunkFromProto(proto *SecretChunk) model.SecretChunk {
+	return model.SecretChunk{
+		SecretID: proto.GetSecretID(),
+		Index:    proto.GetIndex(),
+		Hash:     proto.GetHash(),
+		Data:     proto.GetData(),
+	}
+}
+
+// NewProtoSecretChunk convert model SecretChunk to proto.
+func NewProtoSecretChunk(chunk model.SecretChunk) *SecretChunk {
+	return &SecretChunk{
+		SecretID: chunk.SecretID,
+		Index:    chunk.Index,
+		Hash:     chunk.Hash,
+		Data:     chunk.Data,
+	}
+}
+
+// getTypeFromProto returns secret type by name, peers which send only enum code are resolved with registry,
+// name of type unknown to registry is kept, so secret is stored unchanged.
+func getTypeFromProto(proto SECRET_TYPE, name string) string {
+	if name != "" {
+		return name
+	}
+	if secretType, ok := model.LookupSecretTypeByCode(int32(proto)); ok {
+		return secretType.Name
 	}
+	return proto.String()
 }
 
+// getProtoSecretType returns enum code of secret type for peers which do not send type name.
 func getProtoSecretType(secretType string) SECRET_TYPE {
-	switch secretType {
-	case model.Credentials:
-		return SECRET_TYPE_CREDENTIALS
-	case model.Text:
-		return SECRET_TYPE_TEXT
-	case model.Binary:
-		return SECRET_TYPE_BINARY
-	case model.Card:
-		return SECRET_TYPE_CARD
-	default:
-		return SECRET_TYPE_TEXT
+	if registered, ok := model.LookupSecretType(secretType); ok {
+		return SECRET_TYPE(registered.Code)
 	}
+	return SECRET_TYPE(model.UnknownSecretTypeCode)
 }
@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GophkeeperClient interface {
+	GetAuthParams(ctx context.Context, in *Name, opts ...grpc.CallOption) (*AuthParams, error)
 	Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*AuthMeta, error)
 	Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*AuthMeta, error)
 	GetSecretSyncMeta(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetSecretsSyncDataResponse, error)
@@ -30,6 +31,24 @@ type GophkeeperClient interface {
 	GetSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*EncodedSecret, error)
 	SaveEncodedSecret(ctx context.Context, in *EncodedSecret, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	DeleteSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*AuthMeta, error)
+	GetChangeEvents(ctx context.Context, in *ChangeEventsRequest, opts ...grpc.CallOption) (*ChangeEvents, error)
+	StartKeyRotation(ctx context.Context, in *KeyRotationRequest, opts ...grpc.CallOption) (*User, error)
+	FinishKeyRotation(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*User, error)
+	SetRecoveryKit(ctx context.Context, in *RecoveryKit, opts ...grpc.CallOption) (*User, error)
+	GetRecoveryKit(ctx context.Context, in *RecoveryRequest, opts ...grpc.CallOption) (*RecoveryKit, error)
+	RecoverAccount(ctx context.Context, in *RecoverAccountRequest, opts ...grpc.CallOption) (*AuthMeta, error)
+	VerifyTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*AuthMeta, error)
+	EnableTwoFactor(ctx context.Context, in *TwoFactorSetup, opts ...grpc.CallOption) (*RecoveryCodes, error)
+	DisableTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*User, error)
+	GetAttachmentsMeta(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Attachments, error)
+	GetAttachment(ctx context.Context, in *AttachmentID, opts ...grpc.CallOption) (*EncodedAttachment, error)
+	SaveAttachment(ctx context.Context, in *EncodedAttachment, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteAttachment(ctx context.Context, in *AttachmentID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetMissingChunks(ctx context.Context, in *ChunkHashes, opts ...grpc.CallOption) (*ChunkHashes, error)
+	UploadChunks(ctx context.Context, opts ...grpc.CallOption) (Gophkeeper_UploadChunksClient, error)
+	SetSecretChunks(ctx context.Context, in *SecretChunkRefs, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DownloadSecretChunks(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (Gophkeeper_DownloadSecretChunksClient, error)
 }
 
 type gophkeeperClient struct {
@@ -40,6 +59,15 @@ func NewGophkeeperClient(cc grpc.ClientConnInterface) GophkeeperClient {
 	return &gophkeeperClient{cc}
 }
 
+func (c *gophkeeperClient) GetAuthParams(ctx context.Context, in *Name, opts ...grpc.CallOption) (*AuthParams, error) {
+	out := new(AuthParams)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetAuthParams", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *gophkeeperClient) Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*AuthMeta, error) {
 	out := new(AuthMeta)
 	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/Login", in, out, opts...)
@@ -103,10 +131,221 @@ func (c *gophkeeperClient) DeleteSecret(ctx context.Context, in *SecretID, opts
 	return out, nil
 }
 
+func (c *gophkeeperClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*AuthMeta, error) {
+	out := new(AuthMeta)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/ChangePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) GetChangeEvents(ctx context.Context, in *ChangeEventsRequest, opts ...grpc.CallOption) (*ChangeEvents, error) {
+	out := new(ChangeEvents)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetChangeEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) StartKeyRotation(ctx context.Context, in *KeyRotationRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/StartKeyRotation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) FinishKeyRotation(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/FinishKeyRotation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) SetRecoveryKit(ctx context.Context, in *RecoveryKit, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/SetRecoveryKit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) GetRecoveryKit(ctx context.Context, in *RecoveryRequest, opts ...grpc.CallOption) (*RecoveryKit, error) {
+	out := new(RecoveryKit)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetRecoveryKit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) RecoverAccount(ctx context.Context, in *RecoverAccountRequest, opts ...grpc.CallOption) (*AuthMeta, error) {
+	out := new(AuthMeta)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/RecoverAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) VerifyTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*AuthMeta, error) {
+	out := new(AuthMeta)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/VerifyTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) EnableTwoFactor(ctx context.Context, in *TwoFactorSetup, opts ...grpc.CallOption) (*RecoveryCodes, error) {
+	out := new(RecoveryCodes)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/EnableTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) DisableTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/DisableTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) GetAttachmentsMeta(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Attachments, error) {
+	out := new(Attachments)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetAttachmentsMeta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) GetAttachment(ctx context.Context, in *AttachmentID, opts ...grpc.CallOption) (*EncodedAttachment, error) {
+	out := new(EncodedAttachment)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetAttachment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) SaveAttachment(ctx context.Context, in *EncodedAttachment, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/SaveAttachment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) DeleteAttachment(ctx context.Context, in *AttachmentID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/DeleteAttachment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) GetMissingChunks(ctx context.Context, in *ChunkHashes, opts ...grpc.CallOption) (*ChunkHashes, error) {
+	out := new(ChunkHashes)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/GetMissingChunks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) UploadChunks(ctx context.Context, opts ...grpc.CallOption) (Gophkeeper_UploadChunksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Gophkeeper_ServiceDesc.Streams[0], "/proto.Gophkeeper/UploadChunks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophkeeperUploadChunksClient{stream}
+	return x, nil
+}
+
+type Gophkeeper_UploadChunksClient interface {
+	Send(*SecretChunk) error
+	CloseAndRecv() (*UploadedChunks, error)
+	grpc.ClientStream
+}
+
+type gophkeeperUploadChunksClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophkeeperUploadChunksClient) Send(m *SecretChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gophkeeperUploadChunksClient) CloseAndRecv() (*UploadedChunks, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadedChunks)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gophkeeperClient) SetSecretChunks(ctx context.Context, in *SecretChunkRefs, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.Gophkeeper/SetSecretChunks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophkeeperClient) DownloadSecretChunks(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (Gophkeeper_DownloadSecretChunksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Gophkeeper_ServiceDesc.Streams[1], "/proto.Gophkeeper/DownloadSecretChunks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gophkeeperDownloadSecretChunksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Gophkeeper_DownloadSecretChunksClient interface {
+	Recv() (*SecretChunk, error)
+	grpc.ClientStream
+}
+
+type gophkeeperDownloadSecretChunksClient struct {
+	grpc.ClientStream
+}
+
+func (x *gophkeeperDownloadSecretChunksClient) Recv() (*SecretChunk, error) {
+	m := new(SecretChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // GophkeeperServer is the server API for Gophkeeper service.
 // All implementations must embed UnimplementedGophkeeperServer
 // for forward compatibility
 type GophkeeperServer interface {
+	GetAuthParams(context.Context, *Name) (*AuthParams, error)
 	Login(context.Context, *Credentials) (*AuthMeta, error)
 	Register(context.Context, *Credentials) (*AuthMeta, error)
 	GetSecretSyncMeta(context.Context, *emptypb.Empty) (*GetSecretsSyncDataResponse, error)
@@ -114,6 +353,24 @@ type GophkeeperServer interface {
 	GetSecret(context.Context, *SecretID) (*EncodedSecret, error)
 	SaveEncodedSecret(context.Context, *EncodedSecret) (*emptypb.Empty, error)
 	DeleteSecret(context.Context, *SecretID) (*emptypb.Empty, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*AuthMeta, error)
+	GetChangeEvents(context.Context, *ChangeEventsRequest) (*ChangeEvents, error)
+	StartKeyRotation(context.Context, *KeyRotationRequest) (*User, error)
+	FinishKeyRotation(context.Context, *emptypb.Empty) (*User, error)
+	SetRecoveryKit(context.Context, *RecoveryKit) (*User, error)
+	GetRecoveryKit(context.Context, *RecoveryRequest) (*RecoveryKit, error)
+	RecoverAccount(context.Context, *RecoverAccountRequest) (*AuthMeta, error)
+	VerifyTwoFactor(context.Context, *TwoFactorCode) (*AuthMeta, error)
+	EnableTwoFactor(context.Context, *TwoFactorSetup) (*RecoveryCodes, error)
+	DisableTwoFactor(context.Context, *TwoFactorCode) (*User, error)
+	GetAttachmentsMeta(context.Context, *emptypb.Empty) (*Attachments, error)
+	GetAttachment(context.Context, *AttachmentID) (*EncodedAttachment, error)
+	SaveAttachment(context.Context, *EncodedAttachment) (*emptypb.Empty, error)
+	DeleteAttachment(context.Context, *AttachmentID) (*emptypb.Empty, error)
+	GetMissingChunks(context.Context, *ChunkHashes) (*ChunkHashes, error)
+	UploadChunks(Gophkeeper_UploadChunksServer) error
+	SetSecretChunks(context.Context, *SecretChunkRefs) (*emptypb.Empty, error)
+	DownloadSecretChunks(*ChunkRequest, Gophkeeper_DownloadSecretChunksServer) error
 	mustEmbedUnimplementedGophkeeperServer()
 }
 
@@ -121,6 +378,9 @@ type GophkeeperServer interface {
 type UnimplementedGophkeeperServer struct {
 }
 
+func (UnimplementedGophkeeperServer) GetAuthParams(context.Context, *Name) (*AuthParams, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAuthParams not implemented")
+}
 func (UnimplementedGophkeeperServer) Login(context.Context, *Credentials) (*AuthMeta, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
@@ -142,6 +402,60 @@ func (UnimplementedGophkeeperServer) SaveEncodedSecret(context.Context, *Encoded
 func (UnimplementedGophkeeperServer) DeleteSecret(context.Context, *SecretID) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteSecret not implemented")
 }
+func (UnimplementedGophkeeperServer) ChangePassword(context.Context, *ChangePasswordRequest) (*AuthMeta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedGophkeeperServer) GetChangeEvents(context.Context, *ChangeEventsRequest) (*ChangeEvents, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChangeEvents not implemented")
+}
+func (UnimplementedGophkeeperServer) StartKeyRotation(context.Context, *KeyRotationRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartKeyRotation not implemented")
+}
+func (UnimplementedGophkeeperServer) FinishKeyRotation(context.Context, *emptypb.Empty) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinishKeyRotation not implemented")
+}
+func (UnimplementedGophkeeperServer) SetRecoveryKit(context.Context, *RecoveryKit) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRecoveryKit not implemented")
+}
+func (UnimplementedGophkeeperServer) GetRecoveryKit(context.Context, *RecoveryRequest) (*RecoveryKit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecoveryKit not implemented")
+}
+func (UnimplementedGophkeeperServer) RecoverAccount(context.Context, *RecoverAccountRequest) (*AuthMeta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecoverAccount not implemented")
+}
+func (UnimplementedGophkeeperServer) VerifyTwoFactor(context.Context, *TwoFactorCode) (*AuthMeta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyTwoFactor not implemented")
+}
+func (UnimplementedGophkeeperServer) EnableTwoFactor(context.Context, *TwoFactorSetup) (*RecoveryCodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableTwoFactor not implemented")
+}
+func (UnimplementedGophkeeperServer) DisableTwoFactor(context.Context, *TwoFactorCode) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTwoFactor not implemented")
+}
+func (UnimplementedGophkeeperServer) GetAttachmentsMeta(context.Context, *emptypb.Empty) (*Attachments, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttachmentsMeta not implemented")
+}
+func (UnimplementedGophkeeperServer) GetAttachment(context.Context, *AttachmentID) (*EncodedAttachment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttachment not implemented")
+}
+func (UnimplementedGophkeeperServer) SaveAttachment(context.Context, *EncodedAttachment) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveAttachment not implemented")
+}
+func (UnimplementedGophkeeperServer) DeleteAttachment(context.Context, *AttachmentID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAttachment not implemented")
+}
+func (UnimplementedGophkeeperServer) GetMissingChunks(context.Context, *ChunkHashes) (*ChunkHashes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMissingChunks not implemented")
+}
+func (UnimplementedGophkeeperServer) UploadChunks(Gophkeeper_UploadChunksServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadChunks not implemented")
+}
+func (UnimplementedGophkeeperServer) SetSecretChunks(context.Context, *SecretChunkRefs) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSecretChunks not implemented")
+}
+func (UnimplementedGophkeeperServer) DownloadSecretChunks(*ChunkRequest, Gophkeeper_DownloadSecretChunksServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadSecretChunks not implemented")
+}
 func (UnimplementedGophkeeperServer) mustEmbedUnimplementedGophkeeperServer() {}
 
 // UnsafeGophkeeperServer may be embedded to opt out of forward compatibility for this service.
@@ -155,6 +469,24 @@ func RegisterGophkeeperServer(s grpc.ServiceRegistrar, srv GophkeeperServer) {
 	s.RegisterService(&Gophkeeper_ServiceDesc, srv)
 }
 
+func _Gophkeeper_GetAuthParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Name)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetAuthParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetAuthParams",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetAuthParams(ctx, req.(*Name))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Gophkeeper_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Credentials)
 	if err := dec(in); err != nil {
@@ -281,6 +613,341 @@ func _Gophkeeper_DeleteSecret_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Gophkeeper_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/ChangePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_GetChangeEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetChangeEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetChangeEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetChangeEvents(ctx, req.(*ChangeEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_StartKeyRotation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRotationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).StartKeyRotation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/StartKeyRotation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).StartKeyRotation(ctx, req.(*KeyRotationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_FinishKeyRotation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).FinishKeyRotation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/FinishKeyRotation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).FinishKeyRotation(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_SetRecoveryKit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoveryKit)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).SetRecoveryKit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/SetRecoveryKit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).SetRecoveryKit(ctx, req.(*RecoveryKit))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_GetRecoveryKit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetRecoveryKit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetRecoveryKit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetRecoveryKit(ctx, req.(*RecoveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_RecoverAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoverAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).RecoverAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/RecoverAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).RecoverAccount(ctx, req.(*RecoverAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_VerifyTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).VerifyTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/VerifyTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).VerifyTwoFactor(ctx, req.(*TwoFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_EnableTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorSetup)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).EnableTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/EnableTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).EnableTwoFactor(ctx, req.(*TwoFactorSetup))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_DisableTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).DisableTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/DisableTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).DisableTwoFactor(ctx, req.(*TwoFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_GetAttachmentsMeta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetAttachmentsMeta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetAttachmentsMeta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetAttachmentsMeta(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_GetAttachment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttachmentID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetAttachment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetAttachment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetAttachment(ctx, req.(*AttachmentID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_SaveAttachment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncodedAttachment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).SaveAttachment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/SaveAttachment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).SaveAttachment(ctx, req.(*EncodedAttachment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_DeleteAttachment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttachmentID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).DeleteAttachment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/DeleteAttachment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).DeleteAttachment(ctx, req.(*AttachmentID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_GetMissingChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChunkHashes)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).GetMissingChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/GetMissingChunks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).GetMissingChunks(ctx, req.(*ChunkHashes))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_UploadChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GophkeeperServer).UploadChunks(&gophkeeperUploadChunksServer{stream})
+}
+
+type Gophkeeper_UploadChunksServer interface {
+	SendAndClose(*UploadedChunks) error
+	Recv() (*SecretChunk, error)
+	grpc.ServerStream
+}
+
+type gophkeeperUploadChunksServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophkeeperUploadChunksServer) SendAndClose(m *UploadedChunks) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gophkeeperUploadChunksServer) Recv() (*SecretChunk, error) {
+	m := new(SecretChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Gophkeeper_SetSecretChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretChunkRefs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophkeeperServer).SetSecretChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Gophkeeper/SetSecretChunks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophkeeperServer).SetSecretChunks(ctx, req.(*SecretChunkRefs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gophkeeper_DownloadSecretChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChunkRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophkeeperServer).DownloadSecretChunks(m, &gophkeeperDownloadSecretChunksServer{stream})
+}
+
+type Gophkeeper_DownloadSecretChunksServer interface {
+	Send(*SecretChunk) error
+	grpc.ServerStream
+}
+
+type gophkeeperDownloadSecretChunksServer struct {
+	grpc.ServerStream
+}
+
+func (x *gophkeeperDownloadSecretChunksServer) Send(m *SecretChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Gophkeeper_ServiceDesc is the grpc.ServiceDesc for Gophkeeper service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -288,6 +955,10 @@ var Gophkeeper_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.Gophkeeper",
 	HandlerType: (*GophkeeperServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAuthParams",
+			Handler:    _Gophkeeper_GetAuthParams_Handler,
+		},
 		{
 			MethodName: "Login",
 			Handler:    _Gophkeeper_Login_Handler,
@@ -316,7 +987,82 @@ var Gophkeeper_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteSecret",
 			Handler:    _Gophkeeper_DeleteSecret_Handler,
 		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _Gophkeeper_ChangePassword_Handler,
+		},
+		{
+			MethodName: "GetChangeEvents",
+			Handler:    _Gophkeeper_GetChangeEvents_Handler,
+		},
+		{
+			MethodName: "StartKeyRotation",
+			Handler:    _Gophkeeper_StartKeyRotation_Handler,
+		},
+		{
+			MethodName: "FinishKeyRotation",
+			Handler:    _Gophkeeper_FinishKeyRotation_Handler,
+		},
+		{
+			MethodName: "SetRecoveryKit",
+			Handler:    _Gophkeeper_SetRecoveryKit_Handler,
+		},
+		{
+			MethodName: "GetRecoveryKit",
+			Handler:    _Gophkeeper_GetRecoveryKit_Handler,
+		},
+		{
+			MethodName: "RecoverAccount",
+			Handler:    _Gophkeeper_RecoverAccount_Handler,
+		},
+		{
+			MethodName: "VerifyTwoFactor",
+			Handler:    _Gophkeeper_VerifyTwoFactor_Handler,
+		},
+		{
+			MethodName: "EnableTwoFactor",
+			Handler:    _Gophkeeper_EnableTwoFactor_Handler,
+		},
+		{
+			MethodName: "DisableTwoFactor",
+			Handler:    _Gophkeeper_DisableTwoFactor_Handler,
+		},
+		{
+			MethodName: "GetAttachmentsMeta",
+			Handler:    _Gophkeeper_GetAttachmentsMeta_Handler,
+		},
+		{
+			MethodName: "GetAttachment",
+			Handler:    _Gophkeeper_GetAttachment_Handler,
+		},
+		{
+			MethodName: "SaveAttachment",
+			Handler:    _Gophkeeper_SaveAttachment_Handler,
+		},
+		{
+			MethodName: "DeleteAttachment",
+			Handler:    _Gophkeeper_DeleteAttachment_Handler,
+		},
+		{
+			MethodName: "GetMissingChunks",
+			Handler:    _Gophkeeper_GetMissingChunks_Handler,
+		},
+		{
+			MethodName: "SetSecretChunks",
+			Handler:    _Gophkeeper_SetSecretChunks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadChunks",
+			Handler:       _Gophkeeper_UploadChunks_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadSecretChunks",
+			Handler:       _Gophkeeper_DownloadSecretChunks_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "gophkeeper.proto",
 }
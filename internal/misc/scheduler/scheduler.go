@@ -36,6 +36,7 @@ func (s *Scheduler) RunWithInterval(ctx context.Context, interval time.Duration)
 				s.wg.Done()
 			case <-ctx.Done():
 				ticker.Stop()
+				return
 			}
 		}
 	}(ctx, s.task, s.errorHandler)
@@ -36,6 +36,36 @@ func (m *MockGophkeeperService) EXPECT() *MockGophkeeperServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockGophkeeperService) ChangePassword(arg0 context.Context, arg1 int, arg2, arg3 string, arg4 model.KDFParams, arg5 []byte) (string, model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(model.User)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockGophkeeperServiceMockRecorder) ChangePassword(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockGophkeeperService)(nil).ChangePassword), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DeleteAttachment mocks base method.
+func (m *MockGophkeeperService) DeleteAttachment(arg0 context.Context, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttachment indicates an expected call of DeleteAttachment.
+func (mr *MockGophkeeperServiceMockRecorder) DeleteAttachment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachment", reflect.TypeOf((*MockGophkeeperService)(nil).DeleteAttachment), arg0, arg1, arg2)
+}
+
 // DeleteSecret mocks base method.
 func (m *MockGophkeeperService) DeleteSecret(arg0 context.Context, arg1 int, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -50,6 +80,126 @@ func (mr *MockGophkeeperServiceMockRecorder) DeleteSecret(arg0, arg1, arg2 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockGophkeeperService)(nil).DeleteSecret), arg0, arg1, arg2)
 }
 
+// DisableTwoFactor mocks base method.
+func (m *MockGophkeeperService) DisableTwoFactor(arg0 context.Context, arg1 int, arg2 string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockGophkeeperServiceMockRecorder) DisableTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockGophkeeperService)(nil).DisableTwoFactor), arg0, arg1, arg2)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockGophkeeperService) EnableTwoFactor(arg0 context.Context, arg1 int, arg2, arg3 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockGophkeeperServiceMockRecorder) EnableTwoFactor(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockGophkeeperService)(nil).EnableTwoFactor), arg0, arg1, arg2, arg3)
+}
+
+// FinishKeyRotation mocks base method.
+func (m *MockGophkeeperService) FinishKeyRotation(arg0 context.Context, arg1 int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishKeyRotation", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishKeyRotation indicates an expected call of FinishKeyRotation.
+func (mr *MockGophkeeperServiceMockRecorder) FinishKeyRotation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishKeyRotation", reflect.TypeOf((*MockGophkeeperService)(nil).FinishKeyRotation), arg0, arg1)
+}
+
+// GetAttachment mocks base method.
+func (m *MockGophkeeperService) GetAttachment(arg0 context.Context, arg1 int, arg2 string) (model.EncodedAttachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.EncodedAttachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockGophkeeperServiceMockRecorder) GetAttachment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockGophkeeperService)(nil).GetAttachment), arg0, arg1, arg2)
+}
+
+// GetAttachmentsMeta mocks base method.
+func (m *MockGophkeeperService) GetAttachmentsMeta(arg0 context.Context, arg1 int) ([]model.EncodedAttachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachmentsMeta", arg0, arg1)
+	ret0, _ := ret[0].([]model.EncodedAttachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachmentsMeta indicates an expected call of GetAttachmentsMeta.
+func (mr *MockGophkeeperServiceMockRecorder) GetAttachmentsMeta(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachmentsMeta", reflect.TypeOf((*MockGophkeeperService)(nil).GetAttachmentsMeta), arg0, arg1)
+}
+
+// GetAuthParams mocks base method.
+func (m *MockGophkeeperService) GetAuthParams(arg0 context.Context, arg1 string) (model.AuthParams, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthParams", arg0, arg1)
+	ret0, _ := ret[0].(model.AuthParams)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthParams indicates an expected call of GetAuthParams.
+func (mr *MockGophkeeperServiceMockRecorder) GetAuthParams(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthParams", reflect.TypeOf((*MockGophkeeperService)(nil).GetAuthParams), arg0, arg1)
+}
+
+// GetMissingChunks mocks base method.
+func (m *MockGophkeeperService) GetMissingChunks(arg0 context.Context, arg1 int, arg2 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMissingChunks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMissingChunks indicates an expected call of GetMissingChunks.
+func (mr *MockGophkeeperServiceMockRecorder) GetMissingChunks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingChunks", reflect.TypeOf((*MockGophkeeperService)(nil).GetMissingChunks), arg0, arg1, arg2)
+}
+
+// GetRecoveryKit mocks base method.
+func (m *MockGophkeeperService) GetRecoveryKit(arg0 context.Context, arg1, arg2 string) (model.RecoveryKit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecoveryKit", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.RecoveryKit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecoveryKit indicates an expected call of GetRecoveryKit.
+func (mr *MockGophkeeperServiceMockRecorder) GetRecoveryKit(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecoveryKit", reflect.TypeOf((*MockGophkeeperService)(nil).GetRecoveryKit), arg0, arg1, arg2)
+}
+
 // GetSecret mocks base method.
 func (m *MockGophkeeperService) GetSecret(arg0 context.Context, arg1 int, arg2 string) (model.EncodedSecret, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +215,20 @@ func (mr *MockGophkeeperServiceMockRecorder) GetSecret(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockGophkeeperService)(nil).GetSecret), arg0, arg1, arg2)
 }
 
+// GetSecretChunks mocks base method.
+func (m *MockGophkeeperService) GetSecretChunks(arg0 context.Context, arg1 int, arg2 string, arg3 int64, arg4 func(model.SecretChunk) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretChunks", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetSecretChunks indicates an expected call of GetSecretChunks.
+func (mr *MockGophkeeperServiceMockRecorder) GetSecretChunks(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretChunks", reflect.TypeOf((*MockGophkeeperService)(nil).GetSecretChunks), arg0, arg1, arg2, arg3, arg4)
+}
+
 // GetSecretSyncMetaByOwnerAndName mocks base method.
 func (m *MockGophkeeperService) GetSecretSyncMetaByOwnerAndName(arg0 context.Context, arg1 int, arg2 string) (dto.SecretSyncMetadata, error) {
 	m.ctrl.T.Helper()
@@ -95,10 +259,25 @@ func (mr *MockGophkeeperServiceMockRecorder) GetSecretSyncMetaByUser(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretSyncMetaByUser", reflect.TypeOf((*MockGophkeeperService)(nil).GetSecretSyncMetaByUser), arg0, arg1)
 }
 
+// GetUser mocks base method.
+func (m *MockGophkeeperService) GetUser(arg0 context.Context, arg1 int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockGophkeeperServiceMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockGophkeeperService)(nil).GetUser), arg0, arg1)
+}
+
 // Login mocks base method.
-func (m *MockGophkeeperService) Login(arg0 context.Context, arg1, arg2 string) (string, model.User, error) {
+func (m *MockGophkeeperService) Login(arg0 context.Context, arg1, arg2, arg3 string) (string, model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Login", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(model.User)
 	ret2, _ := ret[2].(error)
@@ -106,15 +285,31 @@ func (m *MockGophkeeperService) Login(arg0 context.Context, arg1, arg2 string) (
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockGophkeeperServiceMockRecorder) Login(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockGophkeeperServiceMockRecorder) Login(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGophkeeperService)(nil).Login), arg0, arg1, arg2, arg3)
+}
+
+// RecoverAccount mocks base method.
+func (m *MockGophkeeperService) RecoverAccount(arg0 context.Context, arg1, arg2, arg3 string, arg4 model.KDFParams, arg5 []byte) (string, model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoverAccount", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(model.User)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecoverAccount indicates an expected call of RecoverAccount.
+func (mr *MockGophkeeperServiceMockRecorder) RecoverAccount(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGophkeeperService)(nil).Login), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverAccount", reflect.TypeOf((*MockGophkeeperService)(nil).RecoverAccount), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
 // Register mocks base method.
-func (m *MockGophkeeperService) Register(arg0 context.Context, arg1, arg2 string) (string, model.User, error) {
+func (m *MockGophkeeperService) Register(arg0 context.Context, arg1, arg2 string, arg3 model.KDFParams, arg4 []byte, arg5 string, arg6 model.RecoveryKit) (string, model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Register", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Register", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(model.User)
 	ret2, _ := ret[2].(error)
@@ -122,9 +317,38 @@ func (m *MockGophkeeperService) Register(arg0 context.Context, arg1, arg2 string
 }
 
 // Register indicates an expected call of Register.
-func (mr *MockGophkeeperServiceMockRecorder) Register(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockGophkeeperServiceMockRecorder) Register(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockGophkeeperService)(nil).Register), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// SaveAttachment mocks base method.
+func (m *MockGophkeeperService) SaveAttachment(arg0 context.Context, arg1 int, arg2 model.EncodedAttachment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAttachment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAttachment indicates an expected call of SaveAttachment.
+func (mr *MockGophkeeperServiceMockRecorder) SaveAttachment(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockGophkeeperService)(nil).Register), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAttachment", reflect.TypeOf((*MockGophkeeperService)(nil).SaveAttachment), arg0, arg1, arg2)
+}
+
+// SaveChunks mocks base method.
+func (m *MockGophkeeperService) SaveChunks(arg0 context.Context, arg1 int, arg2 func() (model.SecretChunk, error)) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChunks", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveChunks indicates an expected call of SaveChunks.
+func (mr *MockGophkeeperServiceMockRecorder) SaveChunks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChunks", reflect.TypeOf((*MockGophkeeperService)(nil).SaveChunks), arg0, arg1, arg2)
 }
 
 // SaveEncodedSecret mocks base method.
@@ -140,3 +364,63 @@ func (mr *MockGophkeeperServiceMockRecorder) SaveEncodedSecret(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEncodedSecret", reflect.TypeOf((*MockGophkeeperService)(nil).SaveEncodedSecret), arg0, arg1, arg2)
 }
+
+// SetRecoveryKit mocks base method.
+func (m *MockGophkeeperService) SetRecoveryKit(arg0 context.Context, arg1 int, arg2 model.RecoveryKit) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRecoveryKit", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRecoveryKit indicates an expected call of SetRecoveryKit.
+func (mr *MockGophkeeperServiceMockRecorder) SetRecoveryKit(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRecoveryKit", reflect.TypeOf((*MockGophkeeperService)(nil).SetRecoveryKit), arg0, arg1, arg2)
+}
+
+// SetSecretChunks mocks base method.
+func (m *MockGophkeeperService) SetSecretChunks(arg0 context.Context, arg1 int, arg2 string, arg3 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecretChunks", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecretChunks indicates an expected call of SetSecretChunks.
+func (mr *MockGophkeeperServiceMockRecorder) SetSecretChunks(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecretChunks", reflect.TypeOf((*MockGophkeeperService)(nil).SetSecretChunks), arg0, arg1, arg2, arg3)
+}
+
+// StartKeyRotation mocks base method.
+func (m *MockGophkeeperService) StartKeyRotation(arg0 context.Context, arg1 int, arg2 []byte) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartKeyRotation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartKeyRotation indicates an expected call of StartKeyRotation.
+func (mr *MockGophkeeperServiceMockRecorder) StartKeyRotation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartKeyRotation", reflect.TypeOf((*MockGophkeeperService)(nil).StartKeyRotation), arg0, arg1, arg2)
+}
+
+// VerifyTwoFactor mocks base method.
+func (m *MockGophkeeperService) VerifyTwoFactor(arg0 context.Context, arg1 int, arg2 string) (string, model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(model.User)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VerifyTwoFactor indicates an expected call of VerifyTwoFactor.
+func (mr *MockGophkeeperServiceMockRecorder) VerifyTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTwoFactor", reflect.TypeOf((*MockGophkeeperService)(nil).VerifyTwoFactor), arg0, arg1, arg2)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/apolsh/yapr-gophkeeper/internal/model"
 	dto "github.com/apolsh/yapr-gophkeeper/internal/model/dto"
@@ -36,6 +37,21 @@ func (m *MockUserStorage) EXPECT() *MockUserStorageMockRecorder {
 	return m.recorder
 }
 
+// AcceptTwoFactorCounter mocks base method.
+func (m *MockUserStorage) AcceptTwoFactorCounter(arg0 context.Context, arg1, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptTwoFactorCounter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptTwoFactorCounter indicates an expected call of AcceptTwoFactorCounter.
+func (mr *MockUserStorageMockRecorder) AcceptTwoFactorCounter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptTwoFactorCounter", reflect.TypeOf((*MockUserStorage)(nil).AcceptTwoFactorCounter), arg0, arg1, arg2)
+}
+
 // Close mocks base method.
 func (m *MockUserStorage) Close() {
 	m.ctrl.T.Helper()
@@ -48,6 +64,79 @@ func (mr *MockUserStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockUserStorage)(nil).Close))
 }
 
+// DisableTwoFactor mocks base method.
+func (m *MockUserStorage) DisableTwoFactor(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockUserStorageMockRecorder) DisableTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockUserStorage)(nil).DisableTwoFactor), arg0, arg1)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockUserStorage) EnableTwoFactor(arg0 context.Context, arg1 int64, arg2 string, arg3 int64, arg4 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockUserStorageMockRecorder) EnableTwoFactor(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockUserStorage)(nil).EnableTwoFactor), arg0, arg1, arg2, arg3, arg4)
+}
+
+// FinishKeyRotation mocks base method.
+func (m *MockUserStorage) FinishKeyRotation(arg0 context.Context, arg1 int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishKeyRotation", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishKeyRotation indicates an expected call of FinishKeyRotation.
+func (mr *MockUserStorageMockRecorder) FinishKeyRotation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishKeyRotation", reflect.TypeOf((*MockUserStorage)(nil).FinishKeyRotation), arg0, arg1)
+}
+
+// GetTwoFactorState mocks base method.
+func (m *MockUserStorage) GetTwoFactorState(arg0 context.Context, arg1 int64) (model.TwoFactorState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactorState", arg0, arg1)
+	ret0, _ := ret[0].(model.TwoFactorState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactorState indicates an expected call of GetTwoFactorState.
+func (mr *MockUserStorageMockRecorder) GetTwoFactorState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactorState", reflect.TypeOf((*MockUserStorage)(nil).GetTwoFactorState), arg0, arg1)
+}
+
+// GetUserByID mocks base method.
+func (m *MockUserStorage) GetUserByID(arg0 context.Context, arg1 int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUserStorageMockRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserStorage)(nil).GetUserByID), arg0, arg1)
+}
+
 // GetUserByLogin mocks base method.
 func (m *MockUserStorage) GetUserByLogin(arg0 context.Context, arg1 string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -64,18 +153,121 @@ func (mr *MockUserStorageMockRecorder) GetUserByLogin(arg0, arg1 interface{}) *g
 }
 
 // NewUser mocks base method.
-func (m *MockUserStorage) NewUser(arg0 context.Context, arg1, arg2 string) (model.User, error) {
+func (m *MockUserStorage) NewUser(arg0 context.Context, arg1 model.User) (model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "NewUser", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "NewUser", arg0, arg1)
 	ret0, _ := ret[0].(model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // NewUser indicates an expected call of NewUser.
-func (mr *MockUserStorageMockRecorder) NewUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockUserStorageMockRecorder) NewUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewUser", reflect.TypeOf((*MockUserStorage)(nil).NewUser), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewUser", reflect.TypeOf((*MockUserStorage)(nil).NewUser), arg0, arg1)
+}
+
+// RegisterTwoFactorFailure mocks base method.
+func (m *MockUserStorage) RegisterTwoFactorFailure(arg0 context.Context, arg1 int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterTwoFactorFailure", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterTwoFactorFailure indicates an expected call of RegisterTwoFactorFailure.
+func (mr *MockUserStorageMockRecorder) RegisterTwoFactorFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterTwoFactorFailure", reflect.TypeOf((*MockUserStorage)(nil).RegisterTwoFactorFailure), arg0, arg1)
+}
+
+// ResetTwoFactorFailures mocks base method.
+func (m *MockUserStorage) ResetTwoFactorFailures(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTwoFactorFailures", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTwoFactorFailures indicates an expected call of ResetTwoFactorFailures.
+func (mr *MockUserStorageMockRecorder) ResetTwoFactorFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTwoFactorFailures", reflect.TypeOf((*MockUserStorage)(nil).ResetTwoFactorFailures), arg0, arg1)
+}
+
+// StartKeyRotation mocks base method.
+func (m *MockUserStorage) StartKeyRotation(arg0 context.Context, arg1 int64, arg2 []byte) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartKeyRotation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartKeyRotation indicates an expected call of StartKeyRotation.
+func (mr *MockUserStorageMockRecorder) StartKeyRotation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartKeyRotation", reflect.TypeOf((*MockUserStorage)(nil).StartKeyRotation), arg0, arg1, arg2)
+}
+
+// UpdateAuthKeyHash mocks base method.
+func (m *MockUserStorage) UpdateAuthKeyHash(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthKeyHash", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAuthKeyHash indicates an expected call of UpdateAuthKeyHash.
+func (mr *MockUserStorageMockRecorder) UpdateAuthKeyHash(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthKeyHash", reflect.TypeOf((*MockUserStorage)(nil).UpdateAuthKeyHash), arg0, arg1, arg2)
+}
+
+// UpdateRecoveryKit mocks base method.
+func (m *MockUserStorage) UpdateRecoveryKit(arg0 context.Context, arg1 int64, arg2 string, arg3 []byte) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecoveryKit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecoveryKit indicates an expected call of UpdateRecoveryKit.
+func (mr *MockUserStorageMockRecorder) UpdateRecoveryKit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecoveryKit", reflect.TypeOf((*MockUserStorage)(nil).UpdateRecoveryKit), arg0, arg1, arg2, arg3)
+}
+
+// UpdateUserCredentials mocks base method.
+func (m *MockUserStorage) UpdateUserCredentials(arg0 context.Context, arg1 model.User) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserCredentials", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserCredentials indicates an expected call of UpdateUserCredentials.
+func (mr *MockUserStorageMockRecorder) UpdateUserCredentials(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserCredentials", reflect.TypeOf((*MockUserStorage)(nil).UpdateUserCredentials), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserStorage) UseRecoveryCode(arg0 context.Context, arg1 int64, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserStorageMockRecorder) UseRecoveryCode(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserStorage)(nil).UseRecoveryCode), arg0, arg1, arg2)
 }
 
 // MockSecretStorage is a mock of SecretStorage interface.
@@ -113,33 +305,166 @@ func (mr *MockSecretStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSecretStorage)(nil).Close))
 }
 
+// CountAttachmentsWithOutdatedKey mocks base method.
+func (m *MockSecretStorage) CountAttachmentsWithOutdatedKey(arg0 context.Context, arg1 int, arg2 int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAttachmentsWithOutdatedKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAttachmentsWithOutdatedKey indicates an expected call of CountAttachmentsWithOutdatedKey.
+func (mr *MockSecretStorageMockRecorder) CountAttachmentsWithOutdatedKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAttachmentsWithOutdatedKey", reflect.TypeOf((*MockSecretStorage)(nil).CountAttachmentsWithOutdatedKey), arg0, arg1, arg2)
+}
+
+// CountSecretsWithOutdatedKey mocks base method.
+func (m *MockSecretStorage) CountSecretsWithOutdatedKey(arg0 context.Context, arg1 int, arg2 int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSecretsWithOutdatedKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSecretsWithOutdatedKey indicates an expected call of CountSecretsWithOutdatedKey.
+func (mr *MockSecretStorageMockRecorder) CountSecretsWithOutdatedKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSecretsWithOutdatedKey", reflect.TypeOf((*MockSecretStorage)(nil).CountSecretsWithOutdatedKey), arg0, arg1, arg2)
+}
+
+// DeleteAttachment mocks base method.
+func (m *MockSecretStorage) DeleteAttachment(arg0 context.Context, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttachment indicates an expected call of DeleteAttachment.
+func (mr *MockSecretStorageMockRecorder) DeleteAttachment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachment", reflect.TypeOf((*MockSecretStorage)(nil).DeleteAttachment), arg0, arg1, arg2)
+}
+
 // DeleteEncodedSecret mocks base method.
-func (m *MockSecretStorage) DeleteEncodedSecret(arg0 context.Context, arg1 string) error {
+func (m *MockSecretStorage) DeleteEncodedSecret(arg0 context.Context, arg1 int, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteEncodedSecret", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteEncodedSecret", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteEncodedSecret indicates an expected call of DeleteEncodedSecret.
-func (mr *MockSecretStorageMockRecorder) DeleteEncodedSecret(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockSecretStorageMockRecorder) DeleteEncodedSecret(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEncodedSecret", reflect.TypeOf((*MockSecretStorage)(nil).DeleteEncodedSecret), arg0, arg1, arg2)
+}
+
+// DeleteUnreferencedChunks mocks base method.
+func (m *MockSecretStorage) DeleteUnreferencedChunks(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnreferencedChunks", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUnreferencedChunks indicates an expected call of DeleteUnreferencedChunks.
+func (mr *MockSecretStorageMockRecorder) DeleteUnreferencedChunks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnreferencedChunks", reflect.TypeOf((*MockSecretStorage)(nil).DeleteUnreferencedChunks), arg0, arg1)
+}
+
+// GetAttachmentByID mocks base method.
+func (m *MockSecretStorage) GetAttachmentByID(arg0 context.Context, arg1 int, arg2 string) (model.EncodedAttachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachmentByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.EncodedAttachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachmentByID indicates an expected call of GetAttachmentByID.
+func (mr *MockSecretStorageMockRecorder) GetAttachmentByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachmentByID", reflect.TypeOf((*MockSecretStorage)(nil).GetAttachmentByID), arg0, arg1, arg2)
+}
+
+// GetAttachmentsMetaByUser mocks base method.
+func (m *MockSecretStorage) GetAttachmentsMetaByUser(arg0 context.Context, arg1 int64) ([]model.EncodedAttachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachmentsMetaByUser", arg0, arg1)
+	ret0, _ := ret[0].([]model.EncodedAttachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachmentsMetaByUser indicates an expected call of GetAttachmentsMetaByUser.
+func (mr *MockSecretStorageMockRecorder) GetAttachmentsMetaByUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEncodedSecret", reflect.TypeOf((*MockSecretStorage)(nil).DeleteEncodedSecret), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachmentsMetaByUser", reflect.TypeOf((*MockSecretStorage)(nil).GetAttachmentsMetaByUser), arg0, arg1)
+}
+
+// GetExistingChunks mocks base method.
+func (m *MockSecretStorage) GetExistingChunks(arg0 context.Context, arg1 int, arg2 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExistingChunks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExistingChunks indicates an expected call of GetExistingChunks.
+func (mr *MockSecretStorageMockRecorder) GetExistingChunks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExistingChunks", reflect.TypeOf((*MockSecretStorage)(nil).GetExistingChunks), arg0, arg1, arg2)
 }
 
 // GetSecretByID mocks base method.
-func (m *MockSecretStorage) GetSecretByID(arg0 context.Context, arg1 string) (model.EncodedSecret, error) {
+func (m *MockSecretStorage) GetSecretByID(arg0 context.Context, arg1 int, arg2 string) (model.EncodedSecret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSecretByID", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetSecretByID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(model.EncodedSecret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSecretByID indicates an expected call of GetSecretByID.
-func (mr *MockSecretStorageMockRecorder) GetSecretByID(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockSecretStorageMockRecorder) GetSecretByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretByID", reflect.TypeOf((*MockSecretStorage)(nil).GetSecretByID), arg0, arg1, arg2)
+}
+
+// GetSecretChunks mocks base method.
+func (m *MockSecretStorage) GetSecretChunks(arg0 context.Context, arg1 int, arg2 string, arg3 int64, arg4 func(model.SecretChunk) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretChunks", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetSecretChunks indicates an expected call of GetSecretChunks.
+func (mr *MockSecretStorageMockRecorder) GetSecretChunks(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretByID", reflect.TypeOf((*MockSecretStorage)(nil).GetSecretByID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretChunks", reflect.TypeOf((*MockSecretStorage)(nil).GetSecretChunks), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetSecretSyncMetaByOwnerAndName mocks base method.
+func (m *MockSecretStorage) GetSecretSyncMetaByOwnerAndName(arg0 context.Context, arg1 int, arg2 string) (dto.SecretSyncMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretSyncMetaByOwnerAndName", arg0, arg1, arg2)
+	ret0, _ := ret[0].(dto.SecretSyncMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretSyncMetaByOwnerAndName indicates an expected call of GetSecretSyncMetaByOwnerAndName.
+func (mr *MockSecretStorageMockRecorder) GetSecretSyncMetaByOwnerAndName(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretSyncMetaByOwnerAndName", reflect.TypeOf((*MockSecretStorage)(nil).GetSecretSyncMetaByOwnerAndName), arg0, arg1, arg2)
 }
 
 // GetSecretSyncMetaByUser mocks base method.
@@ -157,6 +482,34 @@ func (mr *MockSecretStorageMockRecorder) GetSecretSyncMetaByUser(arg0, arg1 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretSyncMetaByUser", reflect.TypeOf((*MockSecretStorage)(nil).GetSecretSyncMetaByUser), arg0, arg1)
 }
 
+// SaveAttachment mocks base method.
+func (m *MockSecretStorage) SaveAttachment(arg0 context.Context, arg1 model.EncodedAttachment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAttachment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAttachment indicates an expected call of SaveAttachment.
+func (mr *MockSecretStorageMockRecorder) SaveAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAttachment", reflect.TypeOf((*MockSecretStorage)(nil).SaveAttachment), arg0, arg1)
+}
+
+// SaveChunk mocks base method.
+func (m *MockSecretStorage) SaveChunk(arg0 context.Context, arg1 int, arg2 model.SecretChunk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChunk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChunk indicates an expected call of SaveChunk.
+func (mr *MockSecretStorageMockRecorder) SaveChunk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChunk", reflect.TypeOf((*MockSecretStorage)(nil).SaveChunk), arg0, arg1, arg2)
+}
+
 // SaveEncodedSecret mocks base method.
 func (m *MockSecretStorage) SaveEncodedSecret(arg0 context.Context, arg1 model.EncodedSecret) error {
 	m.ctrl.T.Helper()
@@ -170,3 +523,17 @@ func (mr *MockSecretStorageMockRecorder) SaveEncodedSecret(arg0, arg1 interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEncodedSecret", reflect.TypeOf((*MockSecretStorage)(nil).SaveEncodedSecret), arg0, arg1)
 }
+
+// SetSecretChunks mocks base method.
+func (m *MockSecretStorage) SetSecretChunks(arg0 context.Context, arg1 int, arg2 string, arg3 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecretChunks", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecretChunks indicates an expected call of SetSecretChunks.
+func (mr *MockSecretStorageMockRecorder) SetSecretChunks(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecretChunks", reflect.TypeOf((*MockSecretStorage)(nil).SetSecretChunks), arg0, arg1, arg2, arg3)
+}
@@ -33,6 +33,21 @@ func (m *MockTokenManager) EXPECT() *MockTokenManagerMockRecorder {
 	return m.recorder
 }
 
+// GenerateRestrictedToken mocks base method.
+func (m *MockTokenManager) GenerateRestrictedToken(arg0 int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRestrictedToken", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRestrictedToken indicates an expected call of GenerateRestrictedToken.
+func (mr *MockTokenManagerMockRecorder) GenerateRestrictedToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRestrictedToken", reflect.TypeOf((*MockTokenManager)(nil).GenerateRestrictedToken), arg0)
+}
+
 // GenerateToken mocks base method.
 func (m *MockTokenManager) GenerateToken(arg0 int64) (string, error) {
 	m.ctrl.T.Helper()
@@ -48,6 +63,21 @@ func (mr *MockTokenManagerMockRecorder) GenerateToken(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockTokenManager)(nil).GenerateToken), arg0)
 }
 
+// ParseRestrictedToken mocks base method.
+func (m *MockTokenManager) ParseRestrictedToken(arg0 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseRestrictedToken", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseRestrictedToken indicates an expected call of ParseRestrictedToken.
+func (mr *MockTokenManagerMockRecorder) ParseRestrictedToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseRestrictedToken", reflect.TypeOf((*MockTokenManager)(nil).ParseRestrictedToken), arg0)
+}
+
 // ParseToken mocks base method.
 func (m *MockTokenManager) ParseToken(arg0 string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -7,4 +7,12 @@ var (
 	ErrorEmptyValue = errors.New("empty values are not allowed")
 	// ErrorInvalidPassword  error when password is invalid.
 	ErrorInvalidPassword = errors.New("invalid password")
+	// ErrorInvalidKDFParams error when key derivation parameters are unknown or too weak.
+	ErrorInvalidKDFParams = errors.New("invalid key derivation parameters")
+	// ErrorUnknownCipherSuite error when cipher suite is not supported.
+	ErrorUnknownCipherSuite = errors.New("unknown cipher suite")
+	// ErrorInvalidRecoveryKey error when recovery key is invalid or not set for user.
+	ErrorInvalidRecoveryKey = errors.New("invalid recovery key")
+	// ErrorInvalidTwoFactorCode error when one-time password or two-factor recovery code is invalid.
+	ErrorInvalidTwoFactorCode = errors.New("invalid two-factor code")
 )
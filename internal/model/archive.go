@@ -0,0 +1,211 @@
+package model
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveExtension suffix of file name of binary secret which stores directory.
+const ArchiveExtension = ".tar.gz"
+
+var (
+	// ErrUnsafeArchivePath appears when archive entry points outside of output directory or of extracted root directory.
+	ErrUnsafeArchivePath = errors.New("archive entry points outside of output directory")
+	// ErrArchiveRootExists appears when root directory of archive already exists in output directory.
+	ErrArchiveRootExists = errors.New("directory already exists in output directory")
+)
+
+// packDirectory writes directory into compressed tar archive. Entries are named relative to parent of directory,
+// so directory itself is restored on extraction. Only regular files and directories are packed with their
+// permission bits and modification times, symbolic links and special files are skipped.
+func packDirectory(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	parent := filepath.Dir(dir)
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(parent, filePath)
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    filepath.ToSlash(name),
+			Mode:    int64(info.Mode().Perm()),
+			ModTime: info.ModTime(),
+			Format:  tar.FormatPAX,
+		}
+		if entry.IsDir() {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			return tw.WriteHeader(header)
+		}
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		return copyFile(tw, filePath)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack directory: %w", err)
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func copyFile(w io.Writer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// extractArchive extracts compressed tar archive made by packDirectory into output directory,
+// returns path of extracted root directory. Root is taken from the first entry and must not exist yet,
+// so existing files are never overwritten. Entries which point outside of root directory,
+// are written through symbolic link or are neither regular files nor directories are rejected.
+func extractArchive(r io.Reader, outputDir string) (string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	var root string
+	// modes and times of directories are restored last, extraction of files changes them
+	var dirs []*tar.Header
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read archive: %w", err)
+		}
+		target, err := archiveEntryPath(outputDir, header.Name)
+		if err != nil {
+			return "", err
+		}
+		if err = checkNoSymlinks(outputDir, target); err != nil {
+			return "", err
+		}
+		entryRoot := filepath.Join(outputDir, strings.SplitN(path.Clean(header.Name), "/", 2)[0])
+		if root == "" {
+			root = entryRoot
+			if _, err = os.Lstat(root); !os.IsNotExist(err) {
+				if err != nil {
+					return "", err
+				}
+				return "", fmt.Errorf("%w: %s", ErrArchiveRootExists, root)
+			}
+		}
+		if entryRoot != root {
+			return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0700); err != nil {
+				return "", err
+			}
+			dirs = append(dirs, header)
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return "", err
+			}
+			if err = extractFile(tr, target, header); err != nil {
+				return "", err
+			}
+		default:
+			return "", fmt.Errorf("unsupported archive entry %s", header.Name)
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		target, _ := archiveEntryPath(outputDir, dirs[i].Name)
+		if err = restoreAttributes(target, dirs[i]); err != nil {
+			return "", err
+		}
+	}
+	return root, nil
+}
+
+// archiveEntryPath returns path entry is extracted to, fails if entry points outside of output directory.
+func archiveEntryPath(outputDir, name string) (string, error) {
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+	return filepath.Join(outputDir, filepath.FromSlash(cleaned)), nil
+}
+
+// checkNoSymlinks fails if target or any of its parents inside of output directory is symbolic link,
+// so entry can not be redirected outside by link which already exists in output directory.
+func checkNoSymlinks(outputDir, target string) error {
+	rel, err := filepath.Rel(outputDir, target)
+	if err != nil {
+		return err
+	}
+	current := outputDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symbolic link", ErrUnsafeArchivePath, current)
+		}
+	}
+	return nil
+}
+
+// extractFile creates file from archive entry, file which already exists is not overwritten.
+func extractFile(r io.Reader, target string, header *tar.Header) error {
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	_, err = io.Copy(file, r)
+	closeErr := file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to extract file: %w", err)
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return restoreAttributes(target, header)
+}
+
+func restoreAttributes(target string, header *tar.Header) error {
+	if err := os.Chmod(target, fs.FileMode(header.Mode).Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
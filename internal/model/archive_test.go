@@ -0,0 +1,181 @@
+package model
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDirectory(t *testing.T, size int) string {
+	dir := filepath.Join(t.TempDir(), ".kube")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config"), randomContent(7, size), 0640))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cache", "token"), []byte("token"), 0600))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")))
+	mtime := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "config"), mtime, mtime))
+	require.NoError(t, os.Chmod(filepath.Join(dir, "cache"), 0750))
+	return dir
+}
+
+func assertExtractedDirectory(t *testing.T, dir string, root string) {
+	assert.Equal(t, filepath.Base(dir), filepath.Base(root))
+	extracted, err := os.ReadFile(filepath.Join(root, "config"))
+	require.NoError(t, err)
+	original, err := os.ReadFile(filepath.Join(dir, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, original, extracted)
+
+	info, err := os.Stat(filepath.Join(root, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.True(t, info.ModTime().Equal(time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)))
+	info, err = os.Stat(filepath.Join(root, "cache"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+	token, err := os.ReadFile(filepath.Join(root, "cache", "token"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("token"), token)
+	_, err = os.Lstat(filepath.Join(root, "passwd"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDirectorySecretItem(t *testing.T) {
+	dir := newTestDirectory(t, 1024)
+	item, err := NewBinarySecretItem("kube", "kubernetes config", dir)
+	require.NoError(t, err)
+	assert.True(t, item.Archive)
+	assert.False(t, item.IsChunked())
+	assert.False(t, item.NeedsChunking())
+	assert.Empty(t, item.sourcePath)
+	assert.Equal(t, ".kube"+ArchiveExtension, item.Filename)
+
+	encoded, err := item.NewEncodedSecret(boundEncode, 1, "secret")
+	require.NoError(t, err)
+	decoded, err := DecodeBinarySecretItem(boundDecode, encoded)
+	require.NoError(t, err)
+	outputDir := t.TempDir()
+	require.NoError(t, decoded.SetOutputPath(outputDir))
+	assert.Contains(t, decoded.GetSecretPayload(), "directory extracted to: "+filepath.Join(outputDir, ".kube"))
+	assertExtractedDirectory(t, dir, filepath.Join(outputDir, ".kube"))
+}
+
+func TestChunkedDirectorySecretItem(t *testing.T) {
+	dir := newTestDirectory(t, 3*AvgContentChunkSize)
+	item, err := NewBinarySecretItem("kube", "kubernetes config", dir)
+	require.NoError(t, err)
+	require.True(t, item.NeedsChunking())
+	assert.Nil(t, item.Binary)
+	archive, err := os.Stat(item.sourcePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), archive.Mode().Perm())
+	require.NoError(t, item.IndexChunks(testContentKey, CipherSuiteAESGCM))
+
+	outputDir := t.TempDir()
+	require.NoError(t, item.SetOutputPath(outputDir))
+	require.NoError(t, os.WriteFile(item.OutputFilePath(), []byte("existing"), 0600))
+	writer, err := item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	for _, chunk := range downloadChunks(item, readChunks(t, item, item.ChunkHashes())) {
+		require.NoError(t, writer.Write(chunk))
+	}
+	require.NoError(t, writer.Close())
+	assert.Contains(t, item.GetSecretPayload(), "directory extracted to")
+	assertExtractedDirectory(t, dir, filepath.Join(outputDir, ".kube"))
+	existing, err := os.ReadFile(item.OutputFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("existing"), existing)
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	sourcePath := item.sourcePath
+	require.NoError(t, item.Close())
+	_, err = os.Stat(sourcePath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func newTestArchive(t *testing.T, headers ...*tar.Header) []byte {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		require.NoError(t, tw.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tw.Write(make([]byte, header.Size))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return archive.Bytes()
+}
+
+func TestExtractArchivePathTraversal(t *testing.T) {
+	for _, header := range []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+		{Name: "dir/../../evil", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+		{Name: "/tmp/evil", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+		{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "/etc", Mode: 0777},
+	} {
+		outputDir := t.TempDir()
+		_, err := extractArchive(bytes.NewReader(newTestArchive(t, header)), outputDir)
+		assert.Error(t, err, header.Name)
+		_, err = os.Stat(filepath.Join(filepath.Dir(outputDir), "evil"))
+		assert.True(t, os.IsNotExist(err), header.Name)
+	}
+}
+
+func TestExtractArchiveThroughSymlink(t *testing.T) {
+	outputDir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(outputDir, "dir")))
+	archive := newTestArchive(t, &tar.Header{Name: "dir/evil", Typeflag: tar.TypeReg, Mode: 0600, Size: 1})
+	_, err := extractArchive(bytes.NewReader(archive), outputDir)
+	assert.ErrorIs(t, err, ErrUnsafeArchivePath)
+	_, err = os.Stat(filepath.Join(outside, "evil"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExtractArchiveKeepsExistingFiles(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, "dir"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "dir", "config"), []byte("existing"), 0600))
+	archive := newTestArchive(t,
+		&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0700},
+		&tar.Header{Name: "dir/config", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+	)
+	_, err := extractArchive(bytes.NewReader(archive), outputDir)
+	assert.ErrorIs(t, err, ErrArchiveRootExists)
+	content, err := os.ReadFile(filepath.Join(outputDir, "dir", "config"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("existing"), content)
+
+	duplicated := newTestArchive(t,
+		&tar.Header{Name: "other/config", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+		&tar.Header{Name: "other/config", Typeflag: tar.TypeReg, Mode: 0600, Size: 2},
+	)
+	_, err = extractArchive(bytes.NewReader(duplicated), outputDir)
+	assert.Error(t, err)
+}
+
+func TestExtractArchiveSingleRoot(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "notes"), []byte("existing"), 0600))
+	archive := newTestArchive(t,
+		&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0700},
+		&tar.Header{Name: "notes", Typeflag: tar.TypeReg, Mode: 0600, Size: 1},
+	)
+	_, err := extractArchive(bytes.NewReader(archive), outputDir)
+	assert.ErrorIs(t, err, ErrUnsafeArchivePath)
+	content, err := os.ReadFile(filepath.Join(outputDir, "notes"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("existing"), content)
+}
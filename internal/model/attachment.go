@@ -0,0 +1,169 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// attachmentFilePermissions downloaded attachments are readable only by owner.
+const attachmentFilePermissions = 0600
+
+// ErrAttachmentNotDownloaded appears when content of attachment is requested before it is downloaded.
+var ErrAttachmentNotDownloaded = errors.New("attachment content is not downloaded")
+
+// EncodedAttachment encrypted file attached to secret item. Attachments are stored apart from secret,
+// so secrets are listed and synchronized without transferring files, content is downloaded on demand.
+// Attachment is never changed after it is created, it can only be re-encoded with another vault key or deleted.
+type EncodedAttachment struct {
+	// ID attachment identifier.
+	ID string
+	// SecretID identifier of secret item attachment belongs to.
+	SecretID string
+	// Owner identifier of attachment owner.
+	Owner int64
+	// Name encrypted file name in base64.
+	Name string
+	// Size size of file in bytes.
+	Size int64
+	// EncodedContent encrypted file, nil if attachment is not downloaded yet.
+	EncodedContent []byte
+	// Hash of encoded attachment, see SyncHash.
+	Hash string
+	// Timestamp of last modification of attachment.
+	Timestamp int64
+	// KeyVersion version of vault key attachment is encrypted with.
+	KeyVersion int64
+}
+
+// AttachmentAssociatedData returns data that ciphertext of attachment is authenticated against,
+// so attachment moved to another secret or owner fails to decode.
+func AttachmentAssociatedData(id, secretID string, owner int64) []byte {
+	data := make([]byte, 0, 4+len(id)+4+len(secretID)+8)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(id)))
+	data = append(data, id...)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(secretID)))
+	data = append(data, secretID...)
+	data = binary.BigEndian.AppendUint64(data, uint64(owner))
+	return data
+}
+
+// NewEncodedAttachment encrypts file name and content of new attachment of secret item.
+func NewEncodedAttachment(encode func(byteToEncode, associatedData []byte) ([]byte, error), owner int64, secretID, id, name string, content []byte) (EncodedAttachment, error) {
+	attachment := EncodedAttachment{
+		ID:        id,
+		SecretID:  secretID,
+		Owner:     owner,
+		Size:      int64(len(content)),
+		Timestamp: time.Now().UTC().UnixMilli(),
+	}
+	encName, err := encode([]byte(name), attachment.associatedData("name"))
+	if err != nil {
+		return EncodedAttachment{}, fmt.Errorf("failed to encode attachment name: %w", err)
+	}
+	encContent, err := encode(content, attachment.associatedData("content"))
+	if err != nil {
+		return EncodedAttachment{}, fmt.Errorf("failed to encode attachment: %w", err)
+	}
+	attachment.Name = base64.StdEncoding.EncodeToString(encName)
+	attachment.EncodedContent = encContent
+	attachment.Hash = attachment.SyncHash()
+	return attachment, nil
+}
+
+// ReadAttachmentFile reads file to attach, returns its name without directory.
+func ReadAttachmentFile(path string) (string, []byte, error) {
+	stats, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, fmt.Errorf("file specified by path does not exist")
+		}
+		return "", nil, fmt.Errorf("failed to get information about specified file: %w", err)
+	}
+	if stats.IsDir() {
+		return "", nil, fmt.Errorf("a directory was passed, you must specify the path to the FILE")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read specified file: %w", err)
+	}
+	return filepath.Base(path), content, nil
+}
+
+// WriteAttachmentFile writes decoded attachment into directory, returns path of written file.
+// Only base of name is used, so name can not point outside of directory.
+func WriteAttachmentFile(dir, name string, content []byte) (string, error) {
+	err := isCorrectDirectoryPath(dir)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(name)
+	if base == "." || base == ".." || base == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid attachment name \"%s\"", name)
+	}
+	path := filepath.Join(dir, base)
+	err = os.WriteFile(path, content, attachmentFilePermissions)
+	if err != nil {
+		return "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return path, nil
+}
+
+func (a *EncodedAttachment) associatedData(field string) []byte {
+	return append(AttachmentAssociatedData(a.ID, a.SecretID, a.Owner), field...)
+}
+
+// IsDownloaded reports whether encrypted content of attachment is stored locally.
+func (a *EncodedAttachment) IsDownloaded() bool {
+	return a.EncodedContent != nil
+}
+
+// SyncHash returns hash of encoded attachment used to detect changes during synchronization.
+func (a *EncodedAttachment) SyncHash() string {
+	h := sha256.New()
+	for _, field := range [][]byte{AttachmentAssociatedData(a.ID, a.SecretID, a.Owner), a.EncodedContent, []byte(a.Name)} {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(field))))
+		h.Write(field)
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// DecodeName decrypts file name of attachment.
+func (a *EncodedAttachment) DecodeName(decode func(byteToDecode, associatedData []byte) ([]byte, error)) (string, error) {
+	name, err := decodeInfoField(decode, a.Name, a.associatedData("name"))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode attachment name: %w", err)
+	}
+	return name, nil
+}
+
+// DecodeContent decrypts content of downloaded attachment.
+func (a *EncodedAttachment) DecodeContent(decode func(byteToDecode, associatedData []byte) ([]byte, error)) ([]byte, error) {
+	if !a.IsDownloaded() {
+		return nil, ErrAttachmentNotDownloaded
+	}
+	content, err := decode(a.EncodedContent, a.associatedData("content"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attachment: %w", err)
+	}
+	return content, nil
+}
+
+// ReEncode re-encrypts name and content of downloaded attachment with another key, identifiers are kept.
+func (a *EncodedAttachment) ReEncode(decode func(byteToDecode, associatedData []byte) ([]byte, error),
+	encode func(byteToEncode, associatedData []byte) ([]byte, error)) (EncodedAttachment, error) {
+	name, err := a.DecodeName(decode)
+	if err != nil {
+		return EncodedAttachment{}, err
+	}
+	content, err := a.DecodeContent(decode)
+	if err != nil {
+		return EncodedAttachment{}, err
+	}
+	return NewEncodedAttachment(encode, a.Owner, a.SecretID, a.ID, name, content)
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// boundEncode appends associated data to plaintext, boundDecode checks and strips it.
+func boundEncode(data, associatedData []byte) ([]byte, error) {
+	return append(append([]byte{}, data...), associatedData...), nil
+}
+
+func boundDecode(data, associatedData []byte) ([]byte, error) {
+	if !bytes.HasSuffix(data, associatedData) {
+		return nil, errors.New("associated data mismatch")
+	}
+	return data[:len(data)-len(associatedData)], nil
+}
+
+func TestEncodedAttachment(t *testing.T) {
+	attachment, err := NewEncodedAttachment(boundEncode, 1, "secret", "attachment", "report.pdf", []byte("content"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("content")), attachment.Size)
+	assert.Equal(t, attachment.SyncHash(), attachment.Hash)
+	assert.True(t, attachment.IsDownloaded())
+
+	name, err := attachment.DecodeName(boundDecode)
+	require.NoError(t, err)
+	assert.Equal(t, "report.pdf", name)
+	content, err := attachment.DecodeContent(boundDecode)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("content"), content)
+
+	moved := attachment
+	moved.SecretID = "another secret"
+	_, err = moved.DecodeContent(boundDecode)
+	assert.Error(t, err)
+
+	meta := attachment
+	meta.EncodedContent = nil
+	_, err = meta.DecodeContent(boundDecode)
+	assert.ErrorIs(t, err, ErrAttachmentNotDownloaded)
+}
+
+func TestReEncodeAttachment(t *testing.T) {
+	attachment, err := NewEncodedAttachment(boundEncode, 1, "secret", "attachment", "report.pdf", []byte("content"))
+	require.NoError(t, err)
+	reEncoded, err := attachment.ReEncode(boundDecode, boundEncode)
+	require.NoError(t, err)
+	assert.Equal(t, attachment.ID, reEncoded.ID)
+	assert.Equal(t, attachment.SecretID, reEncoded.SecretID)
+	content, err := reEncoded.DecodeContent(boundDecode)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("content"), content)
+}
+
+func TestWriteAttachmentFile(t *testing.T) {
+	dir := t.TempDir()
+	path, err := WriteAttachmentFile(dir, "../../outside.txt", []byte("content"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "outside.txt"), path)
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("content"), written)
+
+	_, err = WriteAttachmentFile(dir, "..", []byte("content"))
+	assert.Error(t, err)
+	_, err = WriteAttachmentFile(filepath.Join(dir, "missing"), "file.txt", []byte("content"))
+	assert.Error(t, err)
+
+	name, content, err := ReadAttachmentFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "outside.txt", name)
+	assert.Equal(t, []byte("content"), content)
+	_, _, err = ReadAttachmentFile(dir)
+	assert.Error(t, err)
+}
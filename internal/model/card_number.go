@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Card brands detected by number prefix.
+const (
+	CardBrandVisa       string = "Visa"
+	CardBrandMasterCard string = "MasterCard"
+	CardBrandMir        string = "Mir"
+	CardBrandMaestro    string = "Maestro"
+	CardBrandAmex       string = "American Express"
+	CardBrandDiscover   string = "Discover"
+	CardBrandJCB        string = "JCB"
+	CardBrandUnionPay   string = "UnionPay"
+	CardBrandDiners     string = "Diners Club"
+	CardBrandUnknown    string = "Unknown"
+)
+
+const (
+	minCardNumberLength = 12
+	maxCardNumberLength = 19
+	// cardNumberVisibleDigits number of last digits shown in masked card number.
+	cardNumberVisibleDigits = 4
+)
+
+// cardBrandRange range of number prefixes of the same length issued for card brand.
+type cardBrandRange struct {
+	from  int
+	to    int
+	brand string
+}
+
+// cardBrandRanges prefixes of card brands, more specific ranges go first.
+var cardBrandRanges = []cardBrandRange{
+	{2200, 2204, CardBrandMir},
+	{2221, 2720, CardBrandMasterCard},
+	{51, 55, CardBrandMasterCard},
+	{34, 34, CardBrandAmex},
+	{37, 37, CardBrandAmex},
+	{3528, 3589, CardBrandJCB},
+	{300, 305, CardBrandDiners},
+	{36, 36, CardBrandDiners},
+	{38, 39, CardBrandDiners},
+	{6011, 6011, CardBrandDiscover},
+	{644, 649, CardBrandDiscover},
+	{65, 65, CardBrandDiscover},
+	{62, 62, CardBrandUnionPay},
+	{50, 50, CardBrandMaestro},
+	{56, 58, CardBrandMaestro},
+	{63, 63, CardBrandMaestro},
+	{67, 67, CardBrandMaestro},
+	{4, 4, CardBrandVisa},
+}
+
+// NormalizeCardNumber removes spaces and dashes people use to group digits of card number.
+func NormalizeCardNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// IsValidCardNumber checks length and Luhn checksum of normalized card number.
+func IsValidCardNumber(number string) bool {
+	if len(number) < minCardNumberLength || len(number) > maxCardNumberLength || !isDigits(number) {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// DetectCardBrand returns brand of normalized card number by its prefix.
+func DetectCardBrand(number string) string {
+	for _, r := range cardBrandRanges {
+		length := len(strconv.Itoa(r.from))
+		if len(number) < length {
+			continue
+		}
+		prefix, err := strconv.Atoi(number[:length])
+		if err != nil {
+			return CardBrandUnknown
+		}
+		if prefix >= r.from && prefix <= r.to {
+			return r.brand
+		}
+	}
+	return CardBrandUnknown
+}
+
+// MaskCardNumber replaces all digits of card number except the last four.
+func MaskCardNumber(number string) string {
+	if len(number) <= cardNumberVisibleDigits {
+		return number
+	}
+	return strings.Repeat("*", len(number)-cardNumberVisibleDigits) + number[len(number)-cardNumberVisibleDigits:]
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
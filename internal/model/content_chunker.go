@@ -0,0 +1,116 @@
+package model
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	// MinContentChunkSize minimal size of content-defined chunk, only the last chunk of file can be smaller.
+	MinContentChunkSize = 256 << 10
+	// AvgContentChunkSize expected distance between chunk boundaries after minimal size, must be power of two.
+	AvgContentChunkSize = 1 << 20
+	// MaxContentChunkSize maximal size of content-defined chunk, encrypted chunk fits into default grpc message.
+	MaxContentChunkSize = 2 << 20
+)
+
+// gearTable random values of gear rolling hash, table is generated from fixed seed,
+// changing it moves all chunk boundaries, so nothing is deduplicated with chunks uploaded before.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x676f70686b656570)
+	for i := range table {
+		// splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// ContentChunker splits stream into content-defined chunks with gear rolling hash.
+// Boundary depends only on bytes before it, so insertion or removal of data changes only chunks around the edit,
+// and unchanged parts of file produce the same chunks in every version.
+type ContentChunker struct {
+	reader  io.Reader
+	buf     []byte
+	minSize int
+	maxSize int
+	mask    uint64
+	eof     bool
+}
+
+// NewContentChunker creates ContentChunker with default chunk sizes.
+func NewContentChunker(reader io.Reader) *ContentChunker {
+	return newContentChunker(reader, MinContentChunkSize, AvgContentChunkSize, MaxContentChunkSize)
+}
+
+func newContentChunker(reader io.Reader, minSize, avgSize, maxSize int) *ContentChunker {
+	return &ContentChunker{
+		reader:  reader,
+		buf:     make([]byte, 0, maxSize),
+		minSize: minSize,
+		maxSize: maxSize,
+		// hash has zero bits under mask once per avgSize bytes on average, the top bits are mixed best
+		mask: uint64(avgSize-1) << (64 - bitLength(avgSize-1)),
+	}
+}
+
+// Next returns next chunk, io.EOF after the last one. Returned slice is valid until the next call.
+func (c *ContentChunker) Next() ([]byte, error) {
+	if err := c.fill(); err != nil {
+		return nil, err
+	}
+	if len(c.buf) == 0 {
+		return nil, io.EOF
+	}
+	n := c.boundary()
+	chunk := make([]byte, n)
+	copy(chunk, c.buf[:n])
+	c.buf = c.buf[:copy(c.buf, c.buf[n:])]
+	return chunk, nil
+}
+
+// fill reads stream until buffer holds maximal chunk or stream ends.
+func (c *ContentChunker) fill() error {
+	if c.eof || len(c.buf) == c.maxSize {
+		return nil
+	}
+	n, err := io.ReadFull(c.reader, c.buf[len(c.buf):c.maxSize])
+	c.buf = c.buf[:len(c.buf)+n]
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		c.eof = true
+		return nil
+	}
+	return err
+}
+
+// boundary returns size of the next chunk in buffer.
+func (c *ContentChunker) boundary() int {
+	if len(c.buf) <= c.minSize {
+		return len(c.buf)
+	}
+	var hash uint64
+	// gear hash depends only on the last 64 bytes, so hashing starts shortly before minimal size
+	start := c.minSize - 64
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(c.buf); i++ {
+		hash = (hash << 1) + gearTable[c.buf[i]]
+		if i >= c.minSize && hash&c.mask == 0 {
+			return i + 1
+		}
+	}
+	return len(c.buf)
+}
+
+func bitLength(x int) int {
+	n := 0
+	for ; x > 0; x >>= 1 {
+		n++
+	}
+	return n
+}
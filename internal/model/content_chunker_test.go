@@ -0,0 +1,61 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func splitContent(t *testing.T, content []byte) [][]byte {
+	chunker := newContentChunker(bytes.NewReader(content), 256, 1024, 4096)
+	var chunks [][]byte
+	for {
+		chunk, err := chunker.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestContentChunker(t *testing.T) {
+	content := randomContent(5, 64<<10)
+	chunks := splitContent(t, content)
+	assert.Greater(t, len(chunks), 16)
+	assert.Equal(t, content, bytes.Join(chunks, nil))
+	for _, chunk := range chunks[:len(chunks)-1] {
+		assert.Greater(t, len(chunk), 256)
+		assert.LessOrEqual(t, len(chunk), 4096)
+	}
+	assert.Equal(t, chunks, splitContent(t, content))
+}
+
+func TestContentChunkerEdit(t *testing.T) {
+	content := randomContent(6, 64<<10)
+	chunks := splitContent(t, content)
+	edited := append(append([]byte("prefix"), content[:32<<10]...), content[32<<10+100:]...)
+	editedChunks := splitContent(t, edited)
+
+	known := make(map[string]bool)
+	for _, chunk := range chunks {
+		known[string(chunk)] = true
+	}
+	var changed int
+	for _, chunk := range editedChunks {
+		if !known[string(chunk)] {
+			changed++
+		}
+	}
+	// only chunks around inserted prefix and removed range differ
+	assert.LessOrEqual(t, changed, 4)
+}
+
+func TestContentChunkerSmallContent(t *testing.T) {
+	assert.Empty(t, splitContent(t, nil))
+	assert.Equal(t, [][]byte{[]byte("small")}, splitContent(t, []byte("small")))
+}
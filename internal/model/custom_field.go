@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of custom field, kind defines how value is validated and entered.
+const (
+	FieldText   string = "text"
+	FieldHidden string = "hidden"
+	FieldURL    string = "url"
+	FieldEmail  string = "email"
+	FieldDate   string = "date"
+)
+
+// FieldDateLayout layout of date custom field value.
+const FieldDateLayout = "2006-01-02"
+
+// FieldKinds all supported kinds of custom field.
+var FieldKinds = []string{FieldText, FieldHidden, FieldURL, FieldEmail, FieldDate}
+
+var (
+	// ErrInvalidCustomField appears when custom field has empty name or unknown kind.
+	ErrInvalidCustomField = errors.New("custom field must have name and one of kinds: " + strings.Join(FieldKinds, ", "))
+	// ErrDuplicateCustomField appears when secret item has two custom fields with the same name.
+	ErrDuplicateCustomField = errors.New("custom field names must be unique")
+)
+
+// CustomField user defined field of secret item, encrypted together with secret item payload.
+type CustomField struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Validate checks name, kind and value of custom field.
+func (f CustomField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return ErrInvalidCustomField
+	}
+	switch f.Kind {
+	case FieldText, FieldHidden:
+		return nil
+	case FieldURL:
+		u, err := url.ParseRequestURI(f.Value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("field \"%s\": value is not absolute URL", f.Name)
+		}
+	case FieldEmail:
+		address, err := mail.ParseAddress(f.Value)
+		if err != nil || address.Address != f.Value {
+			return fmt.Errorf("field \"%s\": value is not email address", f.Name)
+		}
+	case FieldDate:
+		if _, err := time.Parse(FieldDateLayout, f.Value); err != nil {
+			return fmt.Errorf("field \"%s\": value is not date of format YYYY-MM-DD", f.Name)
+		}
+	default:
+		return ErrInvalidCustomField
+	}
+	return nil
+}
+
+// NewCustomField CustomField constructor.
+func NewCustomField(name, kind, value string) (CustomField, error) {
+	field := CustomField{Name: strings.TrimSpace(name), Kind: kind, Value: strings.TrimSpace(value)}
+	if kind == FieldText || kind == FieldHidden {
+		// spaces of free text and hidden values are significant
+		field.Value = value
+	}
+	if err := field.Validate(); err != nil {
+		return CustomField{}, err
+	}
+	return field, nil
+}
+
+// CustomFields ordered list of custom fields, embedded into every secret item.
+type CustomFields struct {
+	Fields []CustomField `json:"fields,omitempty"`
+}
+
+// GetCustomFields returns custom fields in order they were added.
+func (c *CustomFields) GetCustomFields() []CustomField {
+	return c.Fields
+}
+
+// SetCustomFields replaces custom fields, names must be unique.
+func (c *CustomFields) SetCustomFields(fields []CustomField) error {
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+		if names[field.Name] {
+			return ErrDuplicateCustomField
+		}
+		names[field.Name] = true
+	}
+	c.Fields = append([]CustomField(nil), fields...)
+	return nil
+}
+
+// customFieldsPayload returns text implementation of custom fields, appended to secret item payload.
+func (c *CustomFields) customFieldsPayload() string {
+	var sb strings.Builder
+	for _, field := range c.Fields {
+		sb.WriteString(fmt.Sprintf("[%s]: %s \n", strings.ToUpper(field.Name), field.Value))
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCustomField(t *testing.T) {
+	tests := []struct {
+		kind  string
+		value string
+		valid bool
+	}{
+		{FieldText, " any text ", true},
+		{FieldHidden, "secret", true},
+		{FieldURL, "https://example.com/login", true},
+		{FieldURL, "example.com", false},
+		{FieldEmail, "john@example.com", true},
+		{FieldEmail, "John <john@example.com>", false},
+		{FieldDate, "2024-02-29", true},
+		{FieldDate, "29.02.2024", false},
+		{"number", "1", false},
+	}
+	for _, tt := range tests {
+		_, err := NewCustomField("field", tt.kind, tt.value)
+		assert.Equal(t, tt.valid, err == nil, "kind %s, value %q", tt.kind, tt.value)
+	}
+	_, err := NewCustomField(" ", FieldText, "value")
+	assert.ErrorIs(t, err, ErrInvalidCustomField)
+}
+
+func TestCustomFieldsRoundTrip(t *testing.T) {
+	item := NewCredentialsSecretItem("github", "", "john", "password")
+	website, err := NewCustomField("website", FieldURL, "https://github.com")
+	require.NoError(t, err)
+	pin, err := NewCustomField("pin", FieldHidden, "1234")
+	require.NoError(t, err)
+	require.NoError(t, item.SetCustomFields([]CustomField{website, pin}))
+	assert.ErrorIs(t, item.SetCustomFields([]CustomField{website, website}), ErrDuplicateCustomField)
+
+	identity := func(data, _ []byte) ([]byte, error) { return data, nil }
+	encoded, err := item.NewEncodedSecret(identity, 1, NewSecretID())
+	require.NoError(t, err)
+	decoded, err := encoded.Decode(identity)
+	require.NoError(t, err)
+	assert.Equal(t, []CustomField{website, pin}, decoded.GetCustomFields())
+	assert.Contains(t, decoded.GetSecretPayload(), "[WEBSITE]: https://github.com \n[PIN]: 1234 \n")
+}
@@ -0,0 +1,11 @@
+package dto
+
+// AttachmentInfo decrypted information about file attached to secret item.
+type AttachmentInfo struct {
+	// ID attachment identifier.
+	ID string
+	// Name file name.
+	Name string
+	// Size size of file in bytes.
+	Size int64
+}
@@ -0,0 +1,28 @@
+package dto
+
+const (
+	// PendingChangeCreate secret was created while server was not available.
+	PendingChangeCreate = "create"
+	// PendingChangeSave secret was saved while server was not available.
+	PendingChangeSave = "save"
+	// PendingChangeDelete secret was deleted while server was not available.
+	PendingChangeDelete = "delete"
+	// PendingChangeSaveAttachment attachment was added while server was not available.
+	PendingChangeSaveAttachment = "save attachment"
+	// PendingChangeDeleteAttachment attachment was deleted while server was not available.
+	PendingChangeDeleteAttachment = "delete attachment"
+)
+
+// PendingChange change of secret item which is not sent to server yet.
+type PendingChange struct {
+	// ID sequence number of change in queue, set by storage.
+	ID int64
+	// SecretID identifier of changed secret item or attachment.
+	SecretID string
+	// Owner identifier of secret item owner.
+	Owner int64
+	// Operation one of PendingChange operations.
+	Operation string
+	// Timestamp of change.
+	Timestamp int64
+}
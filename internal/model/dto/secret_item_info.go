@@ -8,4 +8,5 @@ type SecretItemInfo struct {
 	Description string
 	// SecretType type of secret item.
 	SecretType string
+	SecretLabels
 }
@@ -0,0 +1,9 @@
+package dto
+
+// SecretLabels folder and tags secret item is organized with.
+type SecretLabels struct {
+	// Folder path of folder with segments separated by slash, empty for root folder.
+	Folder string
+	// Tags free-form tags in order they were added.
+	Tags []string
+}
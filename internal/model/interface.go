@@ -1,8 +1,14 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
+	"github.com/google/uuid"
 )
 
 const (
@@ -14,6 +20,10 @@ const (
 	Binary string = "Binary"
 	// Card secret item type for some credit card.
 	Card string = "Card"
+	// OTP secret item type for time-based one-time password seed.
+	OTP string = "OTP"
+	// SSHKey secret item type for SSH key pair.
+	SSHKey string = "SSHKey"
 )
 
 // SecretItem item to be kept secure.
@@ -22,42 +32,164 @@ type SecretItem interface {
 	GetSecretPayload() string
 	// GetType returns secret item type.
 	GetType() string
-	// NewEncodedSecret encodes secret item.
-	NewEncodedSecret(decodeFunction func(byteToDecode []byte) ([]byte, error), owner int64) (EncodedSecret, error)
+	// NewEncodedSecret encodes secret item, ciphertext is bound to secret id, owner and type.
+	NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), owner int64, id string) (EncodedSecret, error)
+	// GetCustomFields returns custom fields of secret item in order they were added.
+	GetCustomFields() []CustomField
+	// SetCustomFields replaces custom fields of secret item.
+	SetCustomFields(fields []CustomField) error
 }
 
 // EncodedSecret encoded SecretItem.
 type EncodedSecret struct {
 	// ID SecretItem identifier.
 	ID string
-	// Name SecretItem name.
+	// Name encrypted SecretItem name in base64, plaintext for secrets stored without NameIndex.
 	Name string
+	// NameIndex keyed blind index of SecretItem name, used for lookup by name.
+	NameIndex string
 	// Owner identifier SecretItem owner.
 	Owner int64
-	// Description of SecretItem.
+	// Description encrypted description of SecretItem in base64, plaintext for secrets stored without NameIndex.
 	Description string
 	// Type of SecretItem.
 	Type string
 	// EncodedContent of SecretItem.
 	EncodedContent []byte
-	// Hash of SecretItem.
+	// Hash of encoded SecretItem, see SyncHash.
 	Hash string
 	// Timestamp of last modification of SecretItem.
 	Timestamp int64
+	// KeyVersion version of vault key EncodedContent is encrypted with.
+	KeyVersion int64
+	// Folder encrypted folder path of SecretItem in base64, empty for secret in root folder.
+	Folder string
+	// FolderIndex keyed blind index of folder path, used for lookup by folder.
+	FolderIndex string
+	// Tags encrypted tags of SecretItem in base64, empty for secret without tags.
+	Tags string
+	// TagIndexes keyed blind indexes of tags, used for lookup by tag.
+	TagIndexes []string
+}
+
+// ErrSecretTypeMismatch appears when decoded content belongs to secret of another type.
+var ErrSecretTypeMismatch = errors.New("decoded secret type does not match record type")
+
+// NewSecretID generates new secret identifier.
+func NewSecretID() string {
+	return uuid.New().String()
+}
+
+// SecretAssociatedData returns data that ciphertext of secret is authenticated against,
+// so content moved to a record with another id, owner or type fails to decode.
+func SecretAssociatedData(id string, owner int64, secretType string) []byte {
+	data := make([]byte, 0, 4+len(id)+8+4+len(secretType))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(id)))
+	data = append(data, id...)
+	data = binary.BigEndian.AppendUint64(data, uint64(owner))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(secretType)))
+	data = append(data, secretType...)
+	return data
+}
+
+// AssociatedData returns data that EncodedContent is authenticated against.
+func (e *EncodedSecret) AssociatedData() []byte {
+	return SecretAssociatedData(e.ID, e.Owner, e.Type)
+}
+
+// infoAssociatedData returns data that encrypted name or description is authenticated against.
+func (e *EncodedSecret) infoAssociatedData(field string) []byte {
+	return append(e.AssociatedData(), field...)
+}
+
+// SyncHash returns hash of encoded secret used to detect changes during synchronization,
+// it covers ciphertext only, so it reveals nothing about plaintext and differs for equal secrets.
+func (e *EncodedSecret) SyncHash() string {
+	h := sha256.New()
+	fields := [][]byte{e.AssociatedData(), e.EncodedContent, []byte(e.Name), []byte(e.Description), []byte(e.NameIndex)}
+	if e.hasLabels() {
+		// labels are hashed only when set, so hash of secrets without labels stays the same
+		fields = append(fields, []byte(e.Folder), []byte(e.FolderIndex), []byte(e.Tags))
+		for _, tagIndex := range e.TagIndexes {
+			fields = append(fields, []byte(tagIndex))
+		}
+	}
+	for _, field := range fields {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(field))))
+		h.Write(field)
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// IsHashOutdated reports whether Hash was computed in outdated way, from plaintext of secret.
+func (e *EncodedSecret) IsHashOutdated() bool {
+	return e.Hash != e.SyncHash()
+}
+
+// IsInfoEncoded reports whether name and description of EncodedSecret are encrypted.
+func (e *EncodedSecret) IsInfoEncoded() bool {
+	return e.NameIndex != ""
+}
+
+// EncodeInfo encrypts name and description of EncodedSecret and sets blind index of name.
+func (e *EncodedSecret) EncodeInfo(encode func(byteToEncode, associatedData []byte) ([]byte, error), nameIndex func(name string) (string, error)) error {
+	index, err := nameIndex(e.Name)
+	if err != nil {
+		return fmt.Errorf("failed to build name index: %w", err)
+	}
+	encName, err := encode([]byte(e.Name), e.infoAssociatedData("name"))
+	if err != nil {
+		return fmt.Errorf("failed to encode secret name: %w", err)
+	}
+	encDescription, err := encode([]byte(e.Description), e.infoAssociatedData("description"))
+	if err != nil {
+		return fmt.Errorf("failed to encode secret description: %w", err)
+	}
+	e.Name = base64.StdEncoding.EncodeToString(encName)
+	e.Description = base64.StdEncoding.EncodeToString(encDescription)
+	e.NameIndex = index
+	e.Hash = e.SyncHash()
+	return nil
+}
+
+// DecodeInfo decrypts name and description of EncodedSecret.
+func (e *EncodedSecret) DecodeInfo(decode func(byteToDecode, associatedData []byte) ([]byte, error)) (dto.SecretItemInfo, error) {
+	if !e.IsInfoEncoded() {
+		return dto.SecretItemInfo{Name: e.Name, Description: e.Description, SecretType: e.Type}, nil
+	}
+	name, err := decodeInfoField(decode, e.Name, e.infoAssociatedData("name"))
+	if err != nil {
+		return dto.SecretItemInfo{}, fmt.Errorf("failed to decode secret name: %w", err)
+	}
+	description, err := decodeInfoField(decode, e.Description, e.infoAssociatedData("description"))
+	if err != nil {
+		return dto.SecretItemInfo{}, fmt.Errorf("failed to decode secret description: %w", err)
+	}
+	labels, err := e.DecodeLabels(decode)
+	if err != nil {
+		return dto.SecretItemInfo{}, err
+	}
+	return dto.SecretItemInfo{Name: name, Description: description, SecretType: e.Type, SecretLabels: labels}, nil
+}
+
+func decodeInfoField(decode func(byteToDecode, associatedData []byte) ([]byte, error), field string, associatedData []byte) (string, error) {
+	encoded, err := base64.StdEncoding.DecodeString(field)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := decode(encoded, associatedData)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
 }
 
-// Decode decodes EncodedSecret.
-func (e *EncodedSecret) Decode(decode func(byteToEncode []byte) ([]byte, error)) (SecretItem, error) {
-	switch e.Type {
-	case Credentials:
-		return DecodeCredentialsSecretItem(decode, *e)
-	case Text:
-		return DecodeTextSecretItem(decode, *e)
-	case Binary:
-		return DecodeBinarySecretItem(decode, *e)
-	case Card:
-		return DecodeCardSecretItem(decode, *e)
-	default:
-		return nil, errors.New(fmt.Sprintf("failed to decode secret: unknown type %s", e.Type))
+// Decode decodes EncodedSecret with decoder of registered type,
+// secret of unknown type is decoded into OpaqueSecretItem, so it is kept unchanged.
+func (e *EncodedSecret) Decode(decode func(byteToDecode, associatedData []byte) ([]byte, error)) (SecretItem, error) {
+	secretType, ok := LookupSecretType(e.Type)
+	if !ok {
+		return secretDecoder(DecodeOpaqueSecretItem)(decode, *e)
 	}
+	return secretType.Decode(decode, *e)
 }
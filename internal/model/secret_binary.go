@@ -1,19 +1,34 @@
 package model
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 var _ SecretItem = (*BinarySecretItem)(nil)
 
+func init() {
+	RegisterSecretType(SecretType{
+		Name:  Binary,
+		Code:  2,
+		Title: "binary",
+		Questions: []Question{
+			secretNameQuestion,
+			{Name: "Filepath", Message: "Enter path to file or directory", Kind: QuestionInput, MinLength: 1},
+			secretDescriptionQuestion,
+		},
+		New: func(answers Answers) (SecretItem, error) {
+			return secretItem(NewBinarySecretItem(answers["Name"], answers["Description"], answers["Filepath"]))
+		},
+		Decode: secretDecoder(DecodeBinarySecretItem),
+	})
+}
+
 // BinarySecretItem binary implementation of SecretItem.
 type BinarySecretItem struct {
 	Name        string `json:"name"`
@@ -21,11 +36,43 @@ type BinarySecretItem struct {
 	SecretType  string `json:"secretType"`
 	Binary      []byte `json:"binary"`
 	Filename    string `json:"filename"`
-	outputPath  string
+	// Size size of file in bytes.
+	Size int64 `json:"size,omitempty"`
+	// Chunks content-defined chunks of file stored apart from secret, empty for file embedded into Binary.
+	Chunks []ChunkRef `json:"chunks,omitempty"`
+	// ContentKey and CipherSuite chunks of file are encrypted with.
+	ContentKey  []byte `json:"contentKey,omitempty"`
+	CipherSuite string `json:"cipherSuite,omitempty"`
+	// ChunkSize and ChunkCount fixed-size chunks of file uploaded before content-defined chunking,
+	// every chunk except the last one has ChunkSize bytes.
+	ChunkSize  int64 `json:"chunkSize,omitempty"`
+	ChunkCount int64 `json:"chunkCount,omitempty"`
+	outputPath string
+	// Archive is set when file is compressed tar archive of directory, it is extracted on retrieval.
+	Archive bool `json:"archive,omitempty"`
+	// sourcePath path of file of new chunked secret, chunks are read from it on upload.
+	sourcePath string
+	// temporarySource is set when source file is temporary archive of directory, it is removed by Close.
+	temporarySource bool
+	// downloadPath path of partial file chunks of file are downloaded into.
+	downloadPath string
+	CustomFields
 }
 
 // GetSecretPayload returns text implementation of secret item payload.
+// Embedded file is saved to output path, chunked file is expected to be already downloaded there,
+// archive of directory is extracted into output path.
 func (c *BinarySecretItem) GetSecretPayload() string {
+	if c.Archive {
+		root, err := c.extractArchive()
+		if err != nil {
+			return fmt.Sprintf("[Binary]: failed to extract directory: %s \n", err.Error()) + c.customFieldsPayload()
+		}
+		return fmt.Sprintf("[Binary]: directory extracted to: %s \n", root) + c.customFieldsPayload()
+	}
+	if c.IsChunked() {
+		return fmt.Sprintf("[Binary]: file saved to: %s \n", c.OutputFilePath()) + c.customFieldsPayload()
+	}
 	var output string
 	var outputDirPath string
 	if c.outputPath != "" {
@@ -40,31 +87,30 @@ func (c *BinarySecretItem) GetSecretPayload() string {
 	} else {
 		output = fmt.Sprintf("file saved to: %s", absFilepath)
 	}
-	return fmt.Sprintf("[Binary]: %s \n", output)
+	return fmt.Sprintf("[Binary]: %s \n", output) + c.customFieldsPayload()
 }
 
 // NewEncodedSecret encodes secret item.
-func (c *BinarySecretItem) NewEncodedSecret(encodeFunction func(byteToDecode []byte) ([]byte, error), ownerID int64) (EncodedSecret, error) {
+func (c *BinarySecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
 	jsonBytes, err := json.Marshal(c)
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	encoded, err := encodeFunction(jsonBytes)
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, Binary))
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	hash := sha256.Sum256(jsonBytes)
 
 	encodedSecret := EncodedSecret{
-		ID:             uuid.New().String(),
+		ID:             id,
 		Name:           c.Name,
 		Owner:          ownerID,
 		Description:    c.Description,
 		Type:           Binary,
 		EncodedContent: encoded,
-		Hash:           base64.StdEncoding.EncodeToString(hash[:]),
 		Timestamp:      time.Now().UTC().UnixMilli(),
 	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
 	return encodedSecret, nil
 }
 
@@ -74,9 +120,9 @@ func (c *BinarySecretItem) GetType() string {
 }
 
 // DecodeBinarySecretItem decodes EncodedSecret item into BinarySecretItem.
-func DecodeBinarySecretItem(decode func(byteToEncode []byte) ([]byte, error), encoded EncodedSecret) (*BinarySecretItem, error) {
+func DecodeBinarySecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*BinarySecretItem, error) {
 	var binarySecret BinarySecretItem
-	decodedBytes, err := decode(encoded.EncodedContent)
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode binary secret: %w", err)
 	}
@@ -84,10 +130,14 @@ func DecodeBinarySecretItem(decode func(byteToEncode []byte) ([]byte, error), en
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse binary secret: %w", err)
 	}
+	if binarySecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
 	return &binarySecret, nil
 }
 
-// NewBinarySecretItem BinarySecretItem constructor.
+// NewBinarySecretItem BinarySecretItem constructor, file larger than InlineBinaryLimit is not read,
+// it is uploaded as chunks when secret is saved. Directory is packed into compressed tar archive.
 func NewBinarySecretItem(name, description, path string) (*BinarySecretItem, error) {
 	stats, err := os.Stat(path)
 	if err != nil {
@@ -97,10 +147,20 @@ func NewBinarySecretItem(name, description, path string) (*BinarySecretItem, err
 		return nil, fmt.Errorf("failed to get information about specified file: %w", err)
 	}
 	if stats.IsDir() {
-		return nil, fmt.Errorf("a directory was passed, you must specify the path to the FILE")
+		return newDirectorySecretItem(name, description, path)
 	}
 
 	filename := filepath.Base(path)
+	if stats.Size() > InlineBinaryLimit {
+		return &BinarySecretItem{
+			Name:        name,
+			Description: description,
+			SecretType:  Binary,
+			Filename:    filename,
+			Size:        stats.Size(),
+			sourcePath:  path,
+		}, nil
+	}
 	fileBytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read specified file: %w", err)
@@ -112,9 +172,96 @@ func NewBinarySecretItem(name, description, path string) (*BinarySecretItem, err
 		SecretType:  Binary,
 		Binary:      fileBytes,
 		Filename:    filename,
+		Size:        int64(len(fileBytes)),
 	}, nil
 }
 
+// newDirectorySecretItem packs directory into temporary archive, archive larger than InlineBinaryLimit is uploaded as chunks,
+// so directory is never held in memory at once. Archive is removed by Close.
+func newDirectorySecretItem(name, description, path string) (*BinarySecretItem, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dirName := filepath.Base(absPath)
+	if dirName == string(filepath.Separator) {
+		return nil, fmt.Errorf("root directory can not be stored")
+	}
+	// temporary file is created with 0600 permissions
+	archive, err := os.CreateTemp("", "gophkeeper-*"+ArchiveExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	err = packDirectory(absPath, archive)
+	closeErr := archive.Close()
+	if err == nil {
+		err = closeErr
+	}
+	item := &BinarySecretItem{
+		Name:            name,
+		Description:     description,
+		SecretType:      Binary,
+		Filename:        dirName + ArchiveExtension,
+		Archive:         true,
+		sourcePath:      archive.Name(),
+		temporarySource: true,
+	}
+	if err != nil {
+		_ = item.Close()
+		return nil, err
+	}
+	stats, err := os.Stat(item.sourcePath)
+	if err != nil {
+		_ = item.Close()
+		return nil, err
+	}
+	item.Size = stats.Size()
+	if item.Size > InlineBinaryLimit {
+		return item, nil
+	}
+	item.Binary, err = os.ReadFile(item.sourcePath)
+	closeErr = item.Close()
+	if err != nil {
+		return nil, err
+	}
+	return item, closeErr
+}
+
+// Close removes temporary archive of directory of new secret, it does nothing for other secrets.
+func (c *BinarySecretItem) Close() error {
+	if !c.temporarySource {
+		return nil
+	}
+	c.temporarySource = false
+	path := c.sourcePath
+	c.sourcePath = ""
+	return os.Remove(path)
+}
+
+// extractArchive extracts archive of directory into output path, downloaded archive of chunked secret is removed after extraction.
+func (c *BinarySecretItem) extractArchive() (string, error) {
+	outputDirPath := c.outputPath
+	if outputDirPath == "" {
+		outputDirPath, _ = os.UserHomeDir()
+	}
+	if !c.IsChunked() {
+		return extractArchive(bytes.NewReader(c.Binary), outputDirPath)
+	}
+	if c.downloadPath == "" {
+		return "", errors.New("archive of directory is not downloaded")
+	}
+	file, err := os.Open(c.downloadPath)
+	if err != nil {
+		return "", err
+	}
+	root, err := extractArchive(file, outputDirPath)
+	_ = file.Close()
+	if err != nil {
+		return "", err
+	}
+	return root, os.Remove(c.downloadPath)
+}
+
 // SetOutputPath sets output path, uses for decode binary file.
 func (c *BinarySecretItem) SetOutputPath(path string) error {
 	err := isCorrectDirectoryPath(path)
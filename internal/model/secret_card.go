@@ -1,18 +1,62 @@
 package model
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 var _ SecretItem = (*CardSecretItem)(nil)
 
-// CardSecretItem binary implementation of SecretItem.
+func init() {
+	RegisterSecretType(SecretType{
+		Name:  Card,
+		Code:  3,
+		Title: "card",
+		Questions: []Question{
+			secretNameQuestion,
+			{Name: "CardNumber", Message: "Enter card number", Kind: QuestionInput, MinLength: 1},
+			{Name: "CardName", Message: "Enter card owner name", Kind: QuestionInput, MinLength: 1},
+			{Name: "CardExpiry", Message: "Enter expiry (MM/YY)", Kind: QuestionInput, MinLength: 1},
+			{Name: "CardCVV", Message: "Enter cvv", Kind: QuestionPassword, MinLength: 1},
+			{Name: "CardPIN", Message: "Enter PIN (leave empty to skip)", Kind: QuestionPassword},
+			{Name: "Bank", Message: "Enter issuing bank (leave empty to skip)", Kind: QuestionInput},
+			{Name: "Address", Message: "Enter cardholder address (leave empty to skip)", Kind: QuestionInput},
+			secretDescriptionQuestion,
+		},
+		New: func(answers Answers) (SecretItem, error) {
+			return secretItem(NewCardSecretItem(answers["Name"], answers["Description"], answers["CardName"], answers["CardNumber"],
+				answers["CardCVV"], answers["CardExpiry"], answers["CardPIN"], answers["Bank"], answers["Address"]))
+		},
+		Decode: secretDecoder(DecodeCardSecretItem),
+	})
+}
+
+// CardExpiryWarningPeriod period before expiry when client warns that card is about to expire.
+const CardExpiryWarningPeriod = 30 * 24 * time.Hour
+
+const (
+	minCardExpiryYear = 2000
+	maxCardExpiryYear = 2099
+	minCardPINLength  = 4
+	maxCardPINLength  = 12
+)
+
+var (
+	// ErrInvalidCardNumber appears when card number has wrong length or Luhn checksum.
+	ErrInvalidCardNumber = errors.New("invalid card number")
+	// ErrInvalidCardExpiry appears when card expiry is not valid month and year.
+	ErrInvalidCardExpiry = errors.New("invalid card expiry, expected MM/YY")
+	// ErrInvalidCardCVV appears when CVV is not 3 or 4 digits.
+	ErrInvalidCardCVV = errors.New("CVV must contain 3 or 4 digits")
+	// ErrInvalidCardPIN appears when PIN is not 4 to 12 digits.
+	ErrInvalidCardPIN = errors.New("PIN must contain 4 to 12 digits")
+)
+
+// CardSecretItem payment card implementation of SecretItem.
 type CardSecretItem struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -20,6 +64,15 @@ type CardSecretItem struct {
 	OwnerName   string `json:"owner"`
 	Number      string `json:"number"`
 	CVV         string `json:"cvv"`
+	// ExpiryMonth and ExpiryYear are zero for cards stored before expiry was introduced.
+	ExpiryMonth int    `json:"expiryMonth,omitempty"`
+	ExpiryYear  int    `json:"expiryYear,omitempty"`
+	PIN         string `json:"pin,omitempty"`
+	Bank        string `json:"bank,omitempty"`
+	Address     string `json:"address,omitempty"`
+	CustomFields
+	// revealed is set when number, CVV and PIN should be shown unmasked.
+	revealed bool
 }
 
 // GetType returns secret item type.
@@ -27,40 +80,117 @@ func (c *CardSecretItem) GetType() string {
 	return c.SecretType
 }
 
-// GetSecretPayload returns text implementation of secret item payload.
+// GetSecretPayload returns text implementation of secret item payload,
+// number, CVV and PIN are masked unless Reveal is called.
 func (c *CardSecretItem) GetSecretPayload() string {
-	return fmt.Sprintf("[OWNER]: %s \n[NUMBER]: %s \n[CVV]: %s \n", c.OwnerName, c.Number, c.CVV)
+	number, cvv, pin := MaskCardNumber(c.Number), maskCardCode(c.CVV), maskCardCode(c.PIN)
+	if c.revealed {
+		number, cvv, pin = c.Number, c.CVV, c.PIN
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[OWNER]: %s \n[NUMBER]: %s \n[BRAND]: %s \n", c.OwnerName, number, DetectCardBrand(c.Number)))
+	if c.HasExpiry() {
+		sb.WriteString(fmt.Sprintf("[EXPIRY]: %s %s\n", c.Expiry(), c.expiryNote(time.Now())))
+	}
+	sb.WriteString(fmt.Sprintf("[CVV]: %s \n", cvv))
+	if c.PIN != "" {
+		sb.WriteString(fmt.Sprintf("[PIN]: %s \n", pin))
+	}
+	if c.Bank != "" {
+		sb.WriteString(fmt.Sprintf("[BANK]: %s \n", c.Bank))
+	}
+	if c.Address != "" {
+		sb.WriteString(fmt.Sprintf("[ADDRESS]: %s \n", c.Address))
+	}
+	return sb.String() + c.customFieldsPayload()
+}
+
+// Reveal makes GetSecretPayload show number, CVV and PIN unmasked.
+func (c *CardSecretItem) Reveal() {
+	c.revealed = true
+}
+
+// HasExpiry reports whether card expiry is set.
+func (c *CardSecretItem) HasExpiry() bool {
+	return c.ExpiryMonth != 0 && c.ExpiryYear != 0
+}
+
+// Expiry returns card expiry in MM/YY format printed on cards.
+func (c *CardSecretItem) Expiry() string {
+	return fmt.Sprintf("%02d/%02d", c.ExpiryMonth, c.ExpiryYear%100)
+}
+
+// ExpiresAt returns the moment card stops working, card is valid until the end of expiry month.
+func (c *CardSecretItem) ExpiresAt() time.Time {
+	return time.Date(c.ExpiryYear, time.Month(c.ExpiryMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// IsExpired reports whether card with set expiry is expired at specified time.
+func (c *CardSecretItem) IsExpired(at time.Time) bool {
+	return c.HasExpiry() && !at.Before(c.ExpiresAt())
+}
+
+// ExpiresSoon reports whether card with set expiry expires within CardExpiryWarningPeriod after specified time.
+func (c *CardSecretItem) ExpiresSoon(at time.Time) bool {
+	return c.HasExpiry() && !c.IsExpired(at) && c.ExpiresAt().Sub(at) <= CardExpiryWarningPeriod
+}
+
+func (c *CardSecretItem) expiryNote(at time.Time) string {
+	switch {
+	case c.IsExpired(at):
+		return "(expired) "
+	case c.ExpiresSoon(at):
+		return "(expires soon) "
+	default:
+		return ""
+	}
+}
+
+// Validate checks number, expiry, CVV and PIN of card.
+func (c *CardSecretItem) Validate() error {
+	if !IsValidCardNumber(c.Number) {
+		return ErrInvalidCardNumber
+	}
+	if c.ExpiryMonth < 1 || c.ExpiryMonth > 12 || c.ExpiryYear < minCardExpiryYear || c.ExpiryYear > maxCardExpiryYear {
+		return ErrInvalidCardExpiry
+	}
+	if !isDigits(c.CVV) || len(c.CVV) < 3 || len(c.CVV) > 4 {
+		return ErrInvalidCardCVV
+	}
+	if c.PIN != "" && (!isDigits(c.PIN) || len(c.PIN) < minCardPINLength || len(c.PIN) > maxCardPINLength) {
+		return ErrInvalidCardPIN
+	}
+	return nil
 }
 
 // NewEncodedSecret encodes secret item.
-func (c *CardSecretItem) NewEncodedSecret(encodeFunction func(byteToDecode []byte) ([]byte, error), ownerID int64) (EncodedSecret, error) {
+func (c *CardSecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
 	jsonBytes, err := json.Marshal(c)
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	encoded, err := encodeFunction(jsonBytes)
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, Card))
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	hash := sha256.Sum256(jsonBytes)
 
 	encodedSecret := EncodedSecret{
-		ID:             uuid.New().String(),
+		ID:             id,
 		Name:           c.Name,
 		Owner:          ownerID,
 		Description:    c.Description,
 		Type:           Card,
 		EncodedContent: encoded,
-		Hash:           base64.StdEncoding.EncodeToString(hash[:]),
 		Timestamp:      time.Now().UTC().UnixMilli(),
 	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
 	return encodedSecret, nil
 }
 
 // DecodeCardSecretItem decodes EncodedSecret item into CardSecretItem.
-func DecodeCardSecretItem(decode func(byteToEncode []byte) ([]byte, error), encoded EncodedSecret) (*CardSecretItem, error) {
+func DecodeCardSecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*CardSecretItem, error) {
 	var credentialsSecret CardSecretItem
-	decodedBytes, err := decode(encoded.EncodedContent)
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode card secret: %w", err)
 	}
@@ -68,17 +198,55 @@ func DecodeCardSecretItem(decode func(byteToEncode []byte) ([]byte, error), enco
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse card secret: %w", err)
 	}
+	if credentialsSecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
 	return &credentialsSecret, nil
 }
 
-// NewCardSecretItem CardSecretItem constructor.
-func NewCardSecretItem(name, description, owner, number, cvv string) *CardSecretItem {
-	return &CardSecretItem{
+// NewCardSecretItem CardSecretItem constructor, expiry is in MM/YY or MM/YYYY format, PIN is optional.
+func NewCardSecretItem(name, description, owner, number, cvv, expiry, pin, bank, address string) (*CardSecretItem, error) {
+	month, year, err := ParseCardExpiry(expiry)
+	if err != nil {
+		return nil, err
+	}
+	item := &CardSecretItem{
 		Name:        name,
 		Description: description,
 		OwnerName:   owner,
-		Number:      number,
-		CVV:         cvv,
+		Number:      NormalizeCardNumber(number),
+		CVV:         strings.TrimSpace(cvv),
+		ExpiryMonth: month,
+		ExpiryYear:  year,
+		PIN:         strings.TrimSpace(pin),
+		Bank:        bank,
+		Address:     address,
 		SecretType:  Card,
 	}
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ParseCardExpiry parses card expiry in MM/YY or MM/YYYY format.
+func ParseCardExpiry(expiry string) (int, int, error) {
+	monthPart, yearPart, found := strings.Cut(strings.TrimSpace(expiry), "/")
+	if !found || len(monthPart) > 2 || (len(yearPart) != 2 && len(yearPart) != 4) || !isDigits(monthPart) || !isDigits(yearPart) {
+		return 0, 0, ErrInvalidCardExpiry
+	}
+	month, _ := strconv.Atoi(monthPart)
+	year, _ := strconv.Atoi(yearPart)
+	if len(yearPart) == 2 {
+		year += minCardExpiryYear
+	}
+	if month < 1 || month > 12 || year < minCardExpiryYear || year > maxCardExpiryYear {
+		return 0, 0, ErrInvalidCardExpiry
+	}
+	return month, year, nil
+}
+
+// maskCardCode hides all digits of CVV or PIN.
+func maskCardCode(code string) string {
+	return strings.Repeat("*", len(code))
 }
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardNumberValidationAndBrand(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+		brand  string
+	}{
+		{"4111 1111 1111 1111", true, CardBrandVisa},
+		{"5555-5555-5555-4444", true, CardBrandMasterCard},
+		{"2221000000000009", true, CardBrandMasterCard},
+		{"2200000000000004", true, CardBrandMir},
+		{"378282246310005", true, CardBrandAmex},
+		{"6011111111111117", true, CardBrandDiscover},
+		{"3530111333300000", true, CardBrandJCB},
+		{"6200000000000005", true, CardBrandUnionPay},
+		{"4111111111111112", false, CardBrandVisa},
+		{"4111", false, CardBrandVisa},
+		{"9111111111111111", false, CardBrandUnknown},
+	}
+	for _, tt := range tests {
+		number := NormalizeCardNumber(tt.number)
+		assert.Equal(t, tt.valid, IsValidCardNumber(number), tt.number)
+		assert.Equal(t, tt.brand, DetectCardBrand(number), tt.number)
+	}
+}
+
+func TestParseCardExpiry(t *testing.T) {
+	month, year, err := ParseCardExpiry("03/27")
+	require.NoError(t, err)
+	assert.Equal(t, 3, month)
+	assert.Equal(t, 2027, year)
+
+	month, year, err = ParseCardExpiry("3/2031")
+	require.NoError(t, err)
+	assert.Equal(t, 3, month)
+	assert.Equal(t, 2031, year)
+
+	for _, expiry := range []string{"13/27", "00/27", "0327", "03/2", "ab/cd", ""} {
+		_, _, err = ParseCardExpiry(expiry)
+		assert.ErrorIs(t, err, ErrInvalidCardExpiry, expiry)
+	}
+}
+
+func TestNewCardSecretItem(t *testing.T) {
+	card, err := NewCardSecretItem("card", "", "JOHN DOE", "4111 1111 1111 1111", "123", "03/27", "1234", "Bank", "Street 1")
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", card.Number)
+	payload := card.GetSecretPayload()
+	assert.Contains(t, payload, "[NUMBER]: ************1111 \n")
+	assert.Contains(t, payload, "[BRAND]: Visa \n")
+	assert.Contains(t, payload, "[CVV]: *** \n")
+	assert.Contains(t, payload, "[PIN]: **** \n")
+	assert.NotContains(t, payload, "4111111111111111")
+
+	card.Reveal()
+	payload = card.GetSecretPayload()
+	assert.Contains(t, payload, "[NUMBER]: 4111111111111111 \n")
+	assert.Contains(t, payload, "[CVV]: 123 \n")
+
+	_, err = NewCardSecretItem("card", "", "JOHN DOE", "4111111111111112", "123", "03/27", "", "", "")
+	assert.ErrorIs(t, err, ErrInvalidCardNumber)
+	_, err = NewCardSecretItem("card", "", "JOHN DOE", "4111111111111111", "12", "03/27", "", "", "")
+	assert.ErrorIs(t, err, ErrInvalidCardCVV)
+	_, err = NewCardSecretItem("card", "", "JOHN DOE", "4111111111111111", "123", "03/27", "12", "", "")
+	assert.ErrorIs(t, err, ErrInvalidCardPIN)
+}
+
+func TestCardExpiry(t *testing.T) {
+	card := CardSecretItem{ExpiryMonth: 3, ExpiryYear: 2027}
+	assert.False(t, card.IsExpired(time.Date(2027, 3, 31, 23, 59, 0, 0, time.UTC)))
+	assert.True(t, card.ExpiresSoon(time.Date(2027, 3, 15, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, card.IsExpired(time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, card.ExpiresSoon(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	legacy := CardSecretItem{}
+	assert.False(t, legacy.IsExpired(time.Now()))
+	assert.False(t, legacy.ExpiresSoon(time.Now()))
+}
@@ -0,0 +1,390 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// InlineBinaryLimit files larger than limit are split into content-defined chunks stored apart from secret,
+	// smaller files can not be split and are embedded into secret.
+	InlineBinaryLimit int64 = MinContentChunkSize
+	// partialFileSuffix suffix of file which is being downloaded, download is resumed from it.
+	partialFileSuffix = ".part"
+	// chunkAddressLabel domain separation label of key chunk addresses are computed with.
+	chunkAddressLabel = "gophkeeper chunk address"
+)
+
+var (
+	// ErrChunkOutOfOrder appears when chunk does not continue already transferred chunks.
+	ErrChunkOutOfOrder = errors.New("chunk is out of order")
+	// ErrIncompleteChunks appears when not all chunks of binary secret are transferred.
+	ErrIncompleteChunks = errors.New("not all chunks of file are transferred")
+	// ErrMissingChunks appears when secret references chunks which are not uploaded.
+	ErrMissingChunks = errors.New("chunks of file are not uploaded")
+	// ErrInvalidChunkHash appears when chunk address is not hex encoded SHA-256.
+	ErrInvalidChunkHash = errors.New("invalid chunk hash")
+	// errFileChanged appears when file of new secret is changed between indexing and upload.
+	errFileChanged = errors.New("file was changed while it was uploaded")
+)
+
+// ChunkRef reference to content-defined chunk of binary secret.
+type ChunkRef struct {
+	// Hash address of chunk, see ChunkAddress.
+	Hash string `json:"hash"`
+	// Size size of plaintext chunk in bytes.
+	Size int64 `json:"size"`
+}
+
+// SecretChunk encrypted content-defined chunk of binary secret. Chunk is stored once per owner
+// and shared by every secret containing the same data.
+type SecretChunk struct {
+	// SecretID identifier of secret chunk is downloaded for, empty on upload.
+	SecretID string
+	// Index number of chunk in file of secret starting from zero, set on download.
+	Index int64
+	// Hash address of chunk.
+	Hash string
+	// Data encrypted chunk.
+	Data []byte
+}
+
+// ChunkAssociatedData returns data that ciphertext of chunk is authenticated against,
+// so chunk stored under another address or owner fails to decode.
+func ChunkAssociatedData(hash string, owner int64) []byte {
+	data := make([]byte, 0, 4+len(hash)+8)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(hash)))
+	data = append(data, hash...)
+	data = binary.BigEndian.AppendUint64(data, uint64(owner))
+	return data
+}
+
+// legacyChunkAssociatedData returns data that ciphertext of fixed-size chunk is authenticated against,
+// chunk moved to another secret or position, or file truncated by dropping last chunks fails to decode.
+func legacyChunkAssociatedData(secretID string, owner, index, count int64) []byte {
+	data := make([]byte, 0, 4+len(secretID)+8*3)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(secretID)))
+	data = append(data, secretID...)
+	data = binary.BigEndian.AppendUint64(data, uint64(owner))
+	data = binary.BigEndian.AppendUint64(data, uint64(index))
+	data = binary.BigEndian.AppendUint64(data, uint64(count))
+	return data
+}
+
+// ChunkAddress returns address of plaintext chunk. Address is keyed hash, so identical chunks of one vault
+// have the same address, while server can not check whether chunk contains guessed data.
+func ChunkAddress(contentKey, data []byte) string {
+	return chunkAddress(chunkAddressKey(contentKey), data)
+}
+
+func chunkAddress(addressKey, data []byte) string {
+	mac := hmac.New(sha256.New, addressKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsValidChunkHash reports whether hash has format of chunk address.
+func IsValidChunkHash(hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+func chunkAddressKey(contentKey []byte) []byte {
+	mac := hmac.New(sha256.New, contentKey)
+	mac.Write([]byte(chunkAddressLabel))
+	return mac.Sum(nil)
+}
+
+// FileChunkReader reads file of new chunked binary secret and encrypts requested chunks.
+type FileChunkReader struct {
+	source     io.ReadCloser
+	chunker    *ContentChunker
+	encode     func(byteToEncode, associatedData []byte) ([]byte, error)
+	addressKey []byte
+	owner      int64
+	chunks     []ChunkRef
+	// wanted addresses of chunks to return, every chunk is returned once even if file contains it several times.
+	wanted map[string]bool
+	next   int
+}
+
+// Next returns next requested encrypted chunk, io.EOF after the last one.
+func (r *FileChunkReader) Next() (SecretChunk, error) {
+	for len(r.wanted) > 0 {
+		data, err := r.chunker.Next()
+		if errors.Is(err, io.EOF) {
+			return SecretChunk{}, errFileChanged
+		}
+		if err != nil {
+			return SecretChunk{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		if r.next >= len(r.chunks) || chunkAddress(r.addressKey, data) != r.chunks[r.next].Hash {
+			return SecretChunk{}, errFileChanged
+		}
+		hash := r.chunks[r.next].Hash
+		r.next++
+		if !r.wanted[hash] {
+			continue
+		}
+		delete(r.wanted, hash)
+		encoded, err := r.encode(data, ChunkAssociatedData(hash, r.owner))
+		if err != nil {
+			return SecretChunk{}, fmt.Errorf("failed to encode chunk: %w", err)
+		}
+		return SecretChunk{Hash: hash, Data: encoded}, nil
+	}
+	return SecretChunk{}, io.EOF
+}
+
+// Close closes file.
+func (r *FileChunkReader) Close() error {
+	return r.source.Close()
+}
+
+// FileChunkWriter decrypts chunks of binary secret and writes them into file.
+// Chunks are written into partial file which is renamed when the last chunk is written,
+// so interrupted download is resumed from the last written chunk.
+type FileChunkWriter struct {
+	file       *os.File
+	decode     func(byteToDecode, associatedData []byte) ([]byte, error)
+	addressKey []byte
+	secretID   string
+	owner      int64
+	chunks     []ChunkRef
+	// legacy is set for fixed-size chunks, they have no addresses and are authenticated against their position.
+	legacy bool
+	next   int64
+	// written number of bytes written into file.
+	written int64
+	path    string
+	// keepPartial is set for archive of directory, it is extracted from partial file and never saved under its name,
+	// so file which already exists in output directory is not replaced.
+	keepPartial bool
+}
+
+// Offset returns index of the next expected chunk.
+func (w *FileChunkWriter) Offset() int64 {
+	return w.next
+}
+
+// Write decrypts chunk, checks its address and appends it to file, chunks must be written in order.
+func (w *FileChunkWriter) Write(chunk SecretChunk) error {
+	if chunk.SecretID != w.secretID || chunk.Index != w.next || w.next >= int64(len(w.chunks)) {
+		return ErrChunkOutOfOrder
+	}
+	ref := w.chunks[w.next]
+	if chunk.Hash != ref.Hash {
+		return ErrChunkOutOfOrder
+	}
+	associatedData := ChunkAssociatedData(ref.Hash, w.owner)
+	if w.legacy {
+		associatedData = legacyChunkAssociatedData(w.secretID, w.owner, w.next, int64(len(w.chunks)))
+	}
+	data, err := w.decode(chunk.Data, associatedData)
+	if err != nil {
+		return fmt.Errorf("failed to decode chunk %d: %w", chunk.Index, err)
+	}
+	if int64(len(data)) != ref.Size || (!w.legacy && chunkAddress(w.addressKey, data) != ref.Hash) {
+		return fmt.Errorf("chunk %d does not match its address", chunk.Index)
+	}
+	_, err = w.file.WriteAt(data, w.written)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	w.written += ref.Size
+	w.next++
+	return nil
+}
+
+// Close closes partial file and renames it when all chunks are written, returns ErrIncompleteChunks otherwise.
+func (w *FileChunkWriter) Close() error {
+	err := w.file.Close()
+	if err != nil {
+		return err
+	}
+	if w.next != int64(len(w.chunks)) {
+		return ErrIncompleteChunks
+	}
+	if w.keepPartial {
+		return nil
+	}
+	return os.Rename(partialFilePath(w.path, w.secretID), w.path)
+}
+
+// partialFilePath returns path of partial file, it is bound to secret, so download of another file is not resumed from it.
+func partialFilePath(path, secretID string) string {
+	return path + "." + secretID + partialFileSuffix
+}
+
+// IsChunked reports whether content of binary secret is stored as chunks apart from secret.
+// It is decided by stored format, not by size, so secrets saved with another InlineBinaryLimit are read as they were stored.
+func (c *BinarySecretItem) IsChunked() bool {
+	return len(c.Chunks) > 0 || c.isLegacyChunked()
+}
+
+// isLegacyChunked reports whether content of binary secret is stored as fixed-size chunks.
+func (c *BinarySecretItem) isLegacyChunked() bool {
+	return len(c.Chunks) == 0 && c.ChunkCount > 0
+}
+
+// NeedsChunking reports whether file of new binary secret is too large to be embedded into secret,
+// such file has to be indexed and uploaded as chunks before secret is saved.
+func (c *BinarySecretItem) NeedsChunking() bool {
+	return c.Binary == nil && c.sourcePath != ""
+}
+
+// legacyChunkRefs returns references to fixed-size chunks of file, they have no addresses.
+func (c *BinarySecretItem) legacyChunkRefs() ([]ChunkRef, error) {
+	if c.ChunkSize <= 0 || c.Size <= (c.ChunkCount-1)*c.ChunkSize || c.Size > c.ChunkCount*c.ChunkSize {
+		return nil, fmt.Errorf("invalid chunks of file: size %d, chunk size %d, count %d", c.Size, c.ChunkSize, c.ChunkCount)
+	}
+	refs := make([]ChunkRef, c.ChunkCount)
+	for i := range refs {
+		refs[i].Size = c.ChunkSize
+	}
+	refs[len(refs)-1].Size = c.Size - (c.ChunkCount-1)*c.ChunkSize
+	return refs, nil
+}
+
+// ChunkHashes returns addresses of chunks in order of file, address is repeated if file contains chunk several times.
+func (c *BinarySecretItem) ChunkHashes() []string {
+	hashes := make([]string, len(c.Chunks))
+	for i, chunk := range c.Chunks {
+		hashes[i] = chunk.Hash
+	}
+	return hashes
+}
+
+// IndexChunks splits file of new chunked binary secret into content-defined chunks addressed with key.
+// The same key is used for every secret of vault, so chunks are deduplicated across secrets,
+// key is stored inside of encrypted secret, so chunks are not re-encrypted when vault key is rotated.
+func (c *BinarySecretItem) IndexChunks(contentKey []byte, cipherSuite string) error {
+	source, err := c.openSource()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	var chunks []ChunkRef
+	var size int64
+	chunker := NewContentChunker(source)
+	for {
+		data, err := chunker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read specified file: %w", err)
+		}
+		chunks = append(chunks, ChunkRef{Hash: ChunkAddress(contentKey, data), Size: int64(len(data))})
+		size += int64(len(data))
+	}
+	if size != c.Size {
+		return errFileChanged
+	}
+	c.Chunks = chunks
+	c.ContentKey = contentKey
+	c.CipherSuite = cipherSuite
+	return nil
+}
+
+// OpenChunkReader opens file of new chunked binary secret, only chunks with specified addresses are read.
+func (c *BinarySecretItem) OpenChunkReader(encode func(byteToEncode, associatedData []byte) ([]byte, error), owner int64, hashes []string) (*FileChunkReader, error) {
+	if len(c.Chunks) == 0 {
+		return nil, errors.New("file of binary secret is not indexed")
+	}
+	source, err := c.openSource()
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		wanted[hash] = true
+	}
+	return &FileChunkReader{
+		source:     source,
+		chunker:    NewContentChunker(source),
+		encode:     encode,
+		addressKey: chunkAddressKey(c.ContentKey),
+		owner:      owner,
+		chunks:     c.Chunks,
+		wanted:     wanted,
+	}, nil
+}
+
+// openSource opens content of new chunked binary secret, it is either specified file or archive of directory.
+func (c *BinarySecretItem) openSource() (io.ReadCloser, error) {
+	if c.sourcePath == "" {
+		return nil, errors.New("file of binary secret is not specified")
+	}
+	file, err := os.Open(c.sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open specified file: %w", err)
+	}
+	return file, nil
+}
+
+// CreateChunkWriter creates file of chunked binary secret in output directory,
+// if partial file of previous download exists, writing is resumed after its last complete chunk.
+// Archive of directory stays in partial file until it is extracted.
+func (c *BinarySecretItem) CreateChunkWriter(decode func(byteToDecode, associatedData []byte) ([]byte, error), secretID string, owner int64) (*FileChunkWriter, error) {
+	if !c.IsChunked() {
+		return nil, errors.New("content of binary secret is not chunked")
+	}
+	chunks := c.Chunks
+	if c.isLegacyChunked() {
+		var err error
+		chunks, err = c.legacyChunkRefs()
+		if err != nil {
+			return nil, err
+		}
+	}
+	path := c.OutputFilePath()
+	c.downloadPath = partialFilePath(path, secretID)
+	file, err := os.OpenFile(c.downloadPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	stats, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	var offset, written int64
+	for ; offset < int64(len(chunks)) && written+chunks[offset].Size <= stats.Size(); offset++ {
+		written += chunks[offset].Size
+	}
+	err = file.Truncate(written)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &FileChunkWriter{
+		file:        file,
+		decode:      decode,
+		addressKey:  chunkAddressKey(c.ContentKey),
+		secretID:    secretID,
+		owner:       owner,
+		chunks:      chunks,
+		legacy:      c.isLegacyChunked(),
+		next:        offset,
+		written:     written,
+		path:        path,
+		keepPartial: c.Archive,
+	}, nil
+}
+
+// OutputFilePath returns path file of binary secret is saved to, only base of file name is used.
+func (c *BinarySecretItem) OutputFilePath() string {
+	outputDirPath := c.outputPath
+	if outputDirPath == "" {
+		outputDirPath, _ = os.UserHomeDir()
+	}
+	return filepath.Join(outputDirPath, filepath.Base(c.Filename))
+}
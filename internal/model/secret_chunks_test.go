@@ -0,0 +1,213 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testContentKey = []byte("0123456789abcdef0123456789abcdef")
+
+func randomContent(seed int64, size int) []byte {
+	content := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(content)
+	return content
+}
+
+func newChunkedBinarySecret(t *testing.T, content []byte) *BinarySecretItem {
+	path := filepath.Join(t.TempDir(), "backup.img")
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	item, err := NewBinarySecretItem("backup", "disk image", path)
+	require.NoError(t, err)
+	require.True(t, item.NeedsChunking())
+	require.NoError(t, item.IndexChunks(testContentKey, CipherSuiteAESGCM))
+	require.True(t, item.IsChunked())
+	return item
+}
+
+func readChunks(t *testing.T, item *BinarySecretItem, hashes []string) []SecretChunk {
+	reader, err := item.OpenChunkReader(boundEncode, 1, hashes)
+	require.NoError(t, err)
+	defer reader.Close()
+	var chunks []SecretChunk
+	for {
+		chunk, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+}
+
+// downloadChunks returns chunks as server sends them for secret.
+func downloadChunks(item *BinarySecretItem, uploaded []SecretChunk) []SecretChunk {
+	byHash := make(map[string][]byte)
+	for _, chunk := range uploaded {
+		byHash[chunk.Hash] = chunk.Data
+	}
+	chunks := make([]SecretChunk, len(item.Chunks))
+	for i, ref := range item.Chunks {
+		chunks[i] = SecretChunk{SecretID: "secret", Index: int64(i), Hash: ref.Hash, Data: byHash[ref.Hash]}
+	}
+	return chunks
+}
+
+func TestBinarySecretChunks(t *testing.T) {
+	content := randomContent(1, 5*AvgContentChunkSize)
+	item := newChunkedBinarySecret(t, content)
+	assert.Equal(t, int64(len(content)), item.Size)
+	assert.Nil(t, item.Binary)
+	assert.Greater(t, len(item.Chunks), 1)
+
+	uploaded := readChunks(t, item, item.ChunkHashes())
+	require.Len(t, uploaded, len(item.Chunks))
+	assert.Equal(t, uploaded[1:2], readChunks(t, item, item.ChunkHashes()[1:2]))
+
+	outputDir := t.TempDir()
+	require.NoError(t, item.SetOutputPath(outputDir))
+	writer, err := item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	for _, chunk := range downloadChunks(item, uploaded) {
+		require.NoError(t, writer.Write(chunk))
+	}
+	require.NoError(t, writer.Close())
+	written, err := os.ReadFile(filepath.Join(outputDir, "backup.img"))
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
+
+func TestBinarySecretChunksDeduplication(t *testing.T) {
+	content := randomContent(2, 5*AvgContentChunkSize)
+	item := newChunkedBinarySecret(t, content)
+
+	edited := append(append(append([]byte{}, content[:len(content)/2]...), "patched"...), content[len(content)/2:]...)
+	editedItem := newChunkedBinarySecret(t, edited)
+	known := make(map[string]bool)
+	for _, hash := range item.ChunkHashes() {
+		known[hash] = true
+	}
+	var changed int
+	for _, hash := range editedItem.ChunkHashes() {
+		if !known[hash] {
+			changed++
+		}
+	}
+	assert.Greater(t, changed, 0)
+	assert.LessOrEqual(t, changed, 2)
+
+	otherKey := newChunkedBinarySecret(t, content)
+	require.NoError(t, otherKey.IndexChunks([]byte("another content key of 32 bytes!"), CipherSuiteAESGCM))
+	assert.NotEqual(t, item.ChunkHashes()[0], otherKey.ChunkHashes()[0])
+}
+
+func TestBinarySecretChunksResume(t *testing.T) {
+	content := randomContent(3, 5*AvgContentChunkSize)
+	item := newChunkedBinarySecret(t, content)
+	chunks := downloadChunks(item, readChunks(t, item, item.ChunkHashes()))
+	require.NoError(t, item.SetOutputPath(t.TempDir()))
+
+	writer, err := item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(chunks[0]))
+	assert.ErrorIs(t, writer.Close(), ErrIncompleteChunks)
+
+	writer, err = item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), writer.Offset())
+	assert.ErrorIs(t, writer.Write(chunks[0]), ErrChunkOutOfOrder)
+	for _, chunk := range chunks[1:] {
+		require.NoError(t, writer.Write(chunk))
+	}
+	require.NoError(t, writer.Close())
+	written, err := os.ReadFile(item.OutputFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
+
+func TestBinarySecretChunksTampered(t *testing.T) {
+	item := newChunkedBinarySecret(t, randomContent(4, 5*AvgContentChunkSize))
+	chunks := downloadChunks(item, readChunks(t, item, item.ChunkHashes()))
+	require.NoError(t, item.SetOutputPath(t.TempDir()))
+
+	writer, err := item.CreateChunkWriter(boundDecode, "secret", 2)
+	require.NoError(t, err)
+	assert.Error(t, writer.Write(chunks[0]))
+
+	writer, err = item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	swapped := chunks[1]
+	swapped.Index = 0
+	assert.ErrorIs(t, writer.Write(swapped), ErrChunkOutOfOrder)
+	forged := chunks[0]
+	forged.Data, err = boundEncode([]byte("forged"), ChunkAssociatedData(forged.Hash, 1))
+	require.NoError(t, err)
+	assert.Error(t, writer.Write(forged))
+	require.ErrorIs(t, writer.Close(), ErrIncompleteChunks)
+}
+
+func TestEmbeddedBinarySecretLargerThanInlineLimit(t *testing.T) {
+	// files up to 1 MiB were embedded before content-defined chunking
+	content := randomContent(5, 2*int(InlineBinaryLimit))
+	item := &BinarySecretItem{Name: "backup", SecretType: Binary, Binary: content, Filename: "backup.img", Size: int64(len(content))}
+	encoded, err := item.NewEncodedSecret(boundEncode, 1, "secret")
+	require.NoError(t, err)
+	decoded, err := DecodeBinarySecretItem(boundDecode, encoded)
+	require.NoError(t, err)
+	assert.False(t, decoded.IsChunked())
+	assert.False(t, decoded.NeedsChunking())
+
+	outputDir := t.TempDir()
+	require.NoError(t, decoded.SetOutputPath(outputDir))
+	assert.Contains(t, decoded.GetSecretPayload(), "file saved to")
+	written, err := os.ReadFile(filepath.Join(outputDir, "backup.img"))
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
+
+func TestLegacyBinarySecretChunks(t *testing.T) {
+	const chunkSize = 1 << 20
+	content := randomContent(6, 2*chunkSize+100)
+	secret := []byte(`{"name":"backup","secretType":"` + Binary + `","filename":"backup.img","size":2097252,` +
+		`"chunkSize":1048576,"chunkCount":3}`)
+	var item BinarySecretItem
+	require.NoError(t, json.Unmarshal(secret, &item))
+	require.True(t, item.IsChunked())
+	var chunks []SecretChunk
+	for i := int64(0); i < item.ChunkCount; i++ {
+		end := (i + 1) * chunkSize
+		if end > item.Size {
+			end = item.Size
+		}
+		data, err := boundEncode(content[i*chunkSize:end], legacyChunkAssociatedData("secret", 1, i, item.ChunkCount))
+		require.NoError(t, err)
+		chunks = append(chunks, SecretChunk{SecretID: "secret", Index: i, Data: data})
+	}
+	require.NoError(t, item.SetOutputPath(t.TempDir()))
+
+	writer, err := item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(chunks[0]))
+	assert.ErrorIs(t, writer.Close(), ErrIncompleteChunks)
+
+	writer, err = item.CreateChunkWriter(boundDecode, "secret", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), writer.Offset())
+	swapped := chunks[2]
+	swapped.Index = 1
+	assert.Error(t, writer.Write(swapped))
+	for _, chunk := range chunks[1:] {
+		require.NoError(t, writer.Write(chunk))
+	}
+	require.NoError(t, writer.Close())
+	written, err := os.ReadFile(item.OutputFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, content, written)
+}
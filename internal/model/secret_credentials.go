@@ -1,17 +1,31 @@
 package model
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 var _ SecretItem = (*CredentialsSecretItem)(nil)
 
+func init() {
+	RegisterSecretType(SecretType{
+		Name:  Credentials,
+		Code:  0,
+		Title: "credentials",
+		Questions: []Question{
+			{Name: "Name", Message: "Enter secret name to store", Kind: QuestionInput, MinLength: 3},
+			{Name: "Login", Message: "Enter your Login to store", Kind: QuestionInput, MinLength: 1},
+			{Name: "Password", Message: "Enter your Password to store", Kind: QuestionPassword, MinLength: 1},
+			{Name: "Description", Message: "Enter description to store", Kind: QuestionInput, MinLength: 1},
+		},
+		New: func(answers Answers) (SecretItem, error) {
+			return NewCredentialsSecretItem(answers["Name"], answers["Description"], answers["Login"], answers["Password"]), nil
+		},
+		Decode: secretDecoder(DecodeCredentialsSecretItem),
+	})
+}
+
 // CredentialsSecretItem binary implementation of SecretItem.
 type CredentialsSecretItem struct {
 	Name        string `json:"name"`
@@ -19,6 +33,7 @@ type CredentialsSecretItem struct {
 	SecretType  string `json:"secretType"`
 	Login       string `json:"login"`
 	Password    string `json:"password"`
+	CustomFields
 }
 
 // GetType returns secret item type.
@@ -28,38 +43,37 @@ func (c *CredentialsSecretItem) GetType() string {
 
 // GetSecretPayload returns text implementation of secret item payload.
 func (c *CredentialsSecretItem) GetSecretPayload() string {
-	return fmt.Sprintf("[LOGIN]: %s \n[PASSWORD]: %s \n", c.Login, c.Password)
+	return fmt.Sprintf("[LOGIN]: %s \n[PASSWORD]: %s \n", c.Login, c.Password) + c.customFieldsPayload()
 }
 
 // NewEncodedSecret encodes secret item.
-func (c *CredentialsSecretItem) NewEncodedSecret(encodeFunction func(byteToDecode []byte) ([]byte, error), ownerID int64) (EncodedSecret, error) {
+func (c *CredentialsSecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
 	jsonBytes, err := json.Marshal(c)
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	encoded, err := encodeFunction(jsonBytes)
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, Credentials))
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	hash := sha256.Sum256(jsonBytes)
 
 	encodedSecret := EncodedSecret{
-		ID:             uuid.New().String(),
+		ID:             id,
 		Name:           c.Name,
 		Owner:          ownerID,
 		Description:    c.Description,
 		Type:           Credentials,
 		EncodedContent: encoded,
-		Hash:           base64.StdEncoding.EncodeToString(hash[:]),
 		Timestamp:      time.Now().UTC().UnixMilli(),
 	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
 	return encodedSecret, nil
 }
 
 // DecodeCredentialsSecretItem decodes EncodedSecret item into CredentialsSecretItem.
-func DecodeCredentialsSecretItem(decode func(byteToEncode []byte) ([]byte, error), encoded EncodedSecret) (*CredentialsSecretItem, error) {
+func DecodeCredentialsSecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*CredentialsSecretItem, error) {
 	var credentialsSecret CredentialsSecretItem
-	decodedBytes, err := decode(encoded.EncodedContent)
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode credentials secret: %w", err)
 	}
@@ -67,6 +81,9 @@ func DecodeCredentialsSecretItem(decode func(byteToEncode []byte) ([]byte, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse credentials secret: %w", err)
 	}
+	if credentialsSecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
 	return &credentialsSecret, nil
 }
 
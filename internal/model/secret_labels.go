@@ -0,0 +1,168 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
+)
+
+// FolderSeparator separates segments of folder path.
+const FolderSeparator = "/"
+
+const (
+	tagsSeparator = ","
+	// folderIndexPrefix and tagIndexPrefix separate blind indexes of folders and tags from indexes of names.
+	folderIndexPrefix = "folder\x00"
+	tagIndexPrefix    = "tag\x00"
+)
+
+// NormalizeFolder trims segments of folder path and drops empty ones, so "/work//aws " becomes "work/aws".
+func NormalizeFolder(folder string) string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(folder, FolderSeparator) {
+		segment = strings.TrimSpace(segment)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, FolderSeparator)
+}
+
+// ParseTags splits comma separated tags, trims them and drops empty and repeated ones.
+func ParseTags(tags string) []string {
+	return NormalizeTags(strings.Split(tags, tagsSeparator))
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones, order is kept.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// FolderIndex returns keyed blind index of folder path, empty for root folder.
+func FolderIndex(nameIndex func(name string) (string, error), folder string) (string, error) {
+	if folder == "" {
+		return "", nil
+	}
+	return nameIndex(folderIndexPrefix + folder)
+}
+
+// TagIndex returns keyed blind index of tag.
+func TagIndex(nameIndex func(name string) (string, error), tag string) (string, error) {
+	return nameIndex(tagIndexPrefix + tag)
+}
+
+// ParentFolders returns folder and all its parents, "work/aws" gives "work" and "work/aws".
+func ParentFolders(folder string) []string {
+	if folder == "" {
+		return nil
+	}
+	segments := strings.Split(folder, FolderSeparator)
+	folders := make([]string, 0, len(segments))
+	for i := range segments {
+		folders = append(folders, strings.Join(segments[:i+1], FolderSeparator))
+	}
+	return folders
+}
+
+// SubFolders returns direct sub folders of parent among folders, sorted by name, empty parent is root folder.
+func SubFolders(folders []string, parent string) []string {
+	seen := make(map[string]bool)
+	subFolders := make([]string, 0)
+	for _, folder := range folders {
+		for _, candidate := range ParentFolders(folder) {
+			if seen[candidate] || !isDirectSubFolder(candidate, parent) {
+				continue
+			}
+			seen[candidate] = true
+			subFolders = append(subFolders, candidate)
+		}
+	}
+	sort.Strings(subFolders)
+	return subFolders
+}
+
+func isDirectSubFolder(folder, parent string) bool {
+	if parent == "" {
+		return !strings.Contains(folder, FolderSeparator)
+	}
+	rest, found := strings.CutPrefix(folder, parent+FolderSeparator)
+	return found && !strings.Contains(rest, FolderSeparator)
+}
+
+func (e *EncodedSecret) hasLabels() bool {
+	return e.Folder != "" || e.Tags != ""
+}
+
+// EncodeLabels encrypts folder and tags of EncodedSecret and sets their blind indexes.
+func (e *EncodedSecret) EncodeLabels(encode func(byteToEncode, associatedData []byte) ([]byte, error), nameIndex func(name string) (string, error), labels dto.SecretLabels) error {
+	folder := NormalizeFolder(labels.Folder)
+	tags := NormalizeTags(labels.Tags)
+	e.Folder, e.FolderIndex, e.Tags, e.TagIndexes = "", "", "", nil
+	if folder != "" {
+		encFolder, err := encode([]byte(folder), e.infoAssociatedData("folder"))
+		if err != nil {
+			return fmt.Errorf("failed to encode secret folder: %w", err)
+		}
+		e.FolderIndex, err = FolderIndex(nameIndex, folder)
+		if err != nil {
+			return fmt.Errorf("failed to build folder index: %w", err)
+		}
+		e.Folder = base64.StdEncoding.EncodeToString(encFolder)
+	}
+	if len(tags) > 0 {
+		tagsBytes, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		encTags, err := encode(tagsBytes, e.infoAssociatedData("tags"))
+		if err != nil {
+			return fmt.Errorf("failed to encode secret tags: %w", err)
+		}
+		for _, tag := range tags {
+			tagIndex, err := TagIndex(nameIndex, tag)
+			if err != nil {
+				return fmt.Errorf("failed to build tag index: %w", err)
+			}
+			e.TagIndexes = append(e.TagIndexes, tagIndex)
+		}
+		e.Tags = base64.StdEncoding.EncodeToString(encTags)
+	}
+	e.Hash = e.SyncHash()
+	return nil
+}
+
+// DecodeLabels decrypts folder and tags of EncodedSecret.
+func (e *EncodedSecret) DecodeLabels(decode func(byteToDecode, associatedData []byte) ([]byte, error)) (dto.SecretLabels, error) {
+	var labels dto.SecretLabels
+	if e.Folder != "" {
+		folder, err := decodeInfoField(decode, e.Folder, e.infoAssociatedData("folder"))
+		if err != nil {
+			return dto.SecretLabels{}, fmt.Errorf("failed to decode secret folder: %w", err)
+		}
+		labels.Folder = folder
+	}
+	if e.Tags != "" {
+		tags, err := decodeInfoField(decode, e.Tags, e.infoAssociatedData("tags"))
+		if err != nil {
+			return dto.SecretLabels{}, fmt.Errorf("failed to decode secret tags: %w", err)
+		}
+		if err = json.Unmarshal([]byte(tags), &labels.Tags); err != nil {
+			return dto.SecretLabels{}, fmt.Errorf("failed to parse secret tags: %w", err)
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/apolsh/yapr-gophkeeper/internal/model/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLabels(t *testing.T) {
+	assert.Equal(t, "work/aws", NormalizeFolder(" /work// aws /"))
+	assert.Equal(t, "", NormalizeFolder("/"))
+	assert.Equal(t, []string{"prod", "db"}, ParseTags(" prod, db,,prod "))
+	assert.Empty(t, ParseTags(""))
+}
+
+func TestSubFolders(t *testing.T) {
+	folders := []string{"work/aws/prod", "work/gcp", "home"}
+	assert.Equal(t, []string{"home", "work"}, SubFolders(folders, ""))
+	assert.Equal(t, []string{"work/aws", "work/gcp"}, SubFolders(folders, "work"))
+	assert.Equal(t, []string{"work/aws/prod"}, SubFolders(folders, "work/aws"))
+	assert.Empty(t, SubFolders(folders, "home"))
+}
+
+func TestEncodeLabels(t *testing.T) {
+	identity := func(data, _ []byte) ([]byte, error) { return data, nil }
+	nameIndex := func(name string) (string, error) { return "index of " + name, nil }
+	encoded, err := NewTextSecretItem("name", "description", "text").NewEncodedSecret(identity, 1, NewSecretID())
+	require.NoError(t, err)
+	require.NoError(t, encoded.EncodeInfo(identity, nameIndex))
+	hashWithoutLabels := encoded.Hash
+
+	require.NoError(t, encoded.EncodeLabels(identity, nameIndex, dto.SecretLabels{Folder: "work/aws", Tags: []string{"prod", "db"}}))
+	assert.NotEqual(t, hashWithoutLabels, encoded.Hash)
+	assert.False(t, encoded.IsHashOutdated())
+	folderIndex, err := FolderIndex(nameIndex, "work/aws")
+	require.NoError(t, err)
+	assert.Equal(t, folderIndex, encoded.FolderIndex)
+	assert.Len(t, encoded.TagIndexes, 2)
+	assert.NotEqual(t, encoded.NameIndex, encoded.FolderIndex)
+
+	info, err := encoded.DecodeInfo(identity)
+	require.NoError(t, err)
+	assert.Equal(t, dto.SecretLabels{Folder: "work/aws", Tags: []string{"prod", "db"}}, info.SecretLabels)
+
+	require.NoError(t, encoded.EncodeLabels(identity, nameIndex, dto.SecretLabels{}))
+	assert.Equal(t, hashWithoutLabels, encoded.Hash)
+	assert.Empty(t, encoded.TagIndexes)
+}
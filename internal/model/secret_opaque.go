@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var _ SecretItem = (*OpaqueSecretItem)(nil)
+
+// ErrOpaqueSecretReadOnly appears when secret of type unknown to this client is modified.
+var ErrOpaqueSecretReadOnly = errors.New("secret type is not supported by this client version, update client to modify it")
+
+// OpaqueSecretItem secret of type which is not registered, e.g. created by newer client version.
+// Decoded content is kept as is, so secret can be re-encoded without losing data.
+type OpaqueSecretItem struct {
+	Name        string
+	Description string
+	SecretType  string
+	// Content decoded content of secret, format is known only to client which created it.
+	Content []byte
+}
+
+// GetType returns secret item type.
+func (c *OpaqueSecretItem) GetType() string {
+	return c.SecretType
+}
+
+// GetSecretPayload returns text implementation of secret item payload, content is not shown.
+func (c *OpaqueSecretItem) GetSecretPayload() string {
+	return fmt.Sprintf("[TYPE]: %s \n[ERROR]: %s \n", c.SecretType, ErrOpaqueSecretReadOnly)
+}
+
+// GetCustomFields returns nil, custom fields are part of content which is not parsed.
+func (c *OpaqueSecretItem) GetCustomFields() []CustomField {
+	return nil
+}
+
+// SetCustomFields returns ErrOpaqueSecretReadOnly.
+func (c *OpaqueSecretItem) SetCustomFields(fields []CustomField) error {
+	return ErrOpaqueSecretReadOnly
+}
+
+// NewEncodedSecret encodes content of secret item unchanged.
+func (c *OpaqueSecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
+	encoded, err := encodeFunction(c.Content, SecretAssociatedData(id, ownerID, c.SecretType))
+	if err != nil {
+		return EncodedSecret{}, err
+	}
+
+	encodedSecret := EncodedSecret{
+		ID:             id,
+		Name:           c.Name,
+		Owner:          ownerID,
+		Description:    c.Description,
+		Type:           c.SecretType,
+		EncodedContent: encoded,
+		Timestamp:      time.Now().UTC().UnixMilli(),
+	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
+	return encodedSecret, nil
+}
+
+// DecodeOpaqueSecretItem decodes EncodedSecret item of any type into OpaqueSecretItem.
+func DecodeOpaqueSecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*OpaqueSecretItem, error) {
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret of type %s: %w", encoded.Type, err)
+	}
+	info, err := encoded.DecodeInfo(decode)
+	if err != nil {
+		return nil, err
+	}
+	return &OpaqueSecretItem{
+		Name:        info.Name,
+		Description: info.Description,
+		SecretType:  encoded.Type,
+		Content:     decodedBytes,
+	}, nil
+}
@@ -0,0 +1,352 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ SecretItem = (*OTPSecretItem)(nil)
+
+func init() {
+	manual := func(answers Answers) bool {
+		return answers["URI"] == ""
+	}
+	RegisterSecretType(SecretType{
+		Name:  OTP,
+		Code:  4,
+		Title: "otp",
+		Questions: []Question{
+			secretNameQuestion,
+			{Name: "URI", Message: "Enter otpauth:// URI (leave empty to enter secret manually)", Kind: QuestionInput},
+			{Name: "Issuer", Message: "Enter issuer", Kind: QuestionInput, When: manual},
+			{Name: "Account", Message: "Enter account", Kind: QuestionInput, When: manual},
+			{Name: "Secret", Message: "Enter base32 secret", Kind: QuestionPassword, MinLength: 1, When: manual},
+			{
+				Name: "Algorithm", Message: "Choose algorithm", Kind: QuestionSelect, When: manual,
+				Options: []string{OTPAlgorithmSHA1, OTPAlgorithmSHA256, OTPAlgorithmSHA512},
+				Default: DefaultOTPAlgorithm,
+			},
+			{
+				Name: "Digits", Message: "Choose number of digits", Kind: QuestionSelect, When: manual,
+				Options: []string{"6", "7", "8"},
+				Default: strconv.Itoa(DefaultOTPDigits),
+			},
+			{Name: "Period", Message: "Enter period in seconds", Kind: QuestionInput, Default: strconv.Itoa(DefaultOTPPeriod), When: manual},
+			secretDescriptionQuestion,
+		},
+		New:    newOTPSecretItemFromAnswers,
+		Decode: secretDecoder(DecodeOTPSecretItem),
+	})
+}
+
+// Hash algorithms of one-time password.
+const (
+	OTPAlgorithmSHA1   string = "SHA1"
+	OTPAlgorithmSHA256 string = "SHA256"
+	OTPAlgorithmSHA512 string = "SHA512"
+)
+
+// Default parameters of one-time password, used by most authenticator applications.
+const (
+	DefaultOTPAlgorithm = OTPAlgorithmSHA1
+	DefaultOTPDigits    = 6
+	DefaultOTPPeriod    = 30
+)
+
+const (
+	otpAuthScheme = "otpauth"
+	otpAuthTOTP   = "totp"
+	minOTPDigits  = 6
+	maxOTPDigits  = 8
+	// otpVerifySkew number of time steps before and after current one accepted by Verify.
+	otpVerifySkew = 1
+	otpSecretSize = 20
+)
+
+var (
+	// ErrInvalidOTPSecret appears when OTP seed is not valid base32 string.
+	ErrInvalidOTPSecret = errors.New("OTP secret must be non-empty base32 string")
+	// ErrInvalidOTPParams appears when digits, period or algorithm of OTP are not supported.
+	ErrInvalidOTPParams = errors.New("OTP parameters are not supported")
+	// ErrInvalidOTPAuthURI appears when otpauth URI can not be imported.
+	ErrInvalidOTPAuthURI = errors.New("invalid otpauth URI, expected otpauth://totp/label?secret=...")
+)
+
+// OTPSecretItem time-based one-time password (RFC 6238) implementation of SecretItem,
+// codes are generated with HOTP algorithm (RFC 4226) from shared seed.
+type OTPSecretItem struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SecretType  string `json:"secretType"`
+	Issuer      string `json:"issuer"`
+	Account     string `json:"account"`
+	// Secret shared seed encoded in base32.
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	// Period lifetime of code in seconds.
+	Period int `json:"period"`
+	CustomFields
+}
+
+// GetType returns secret item type.
+func (c *OTPSecretItem) GetType() string {
+	return c.SecretType
+}
+
+// GetSecretPayload returns text implementation of secret item payload with current code.
+func (c *OTPSecretItem) GetSecretPayload() string {
+	code, remaining, err := c.Code(time.Now())
+	if err != nil {
+		return fmt.Sprintf("[ISSUER]: %s \n[ACCOUNT]: %s \n[ERROR]: %s \n", c.Issuer, c.Account, err) + c.customFieldsPayload()
+	}
+	return fmt.Sprintf("[ISSUER]: %s \n[ACCOUNT]: %s \n[CODE]: %s \n[VALID FOR]: %ds \n", c.Issuer, c.Account, code, remaining) + c.customFieldsPayload()
+}
+
+// Code returns code valid at specified time and number of seconds it stays valid.
+func (c *OTPSecretItem) Code(at time.Time) (string, int, error) {
+	if err := c.Validate(); err != nil {
+		return "", 0, err
+	}
+	key, err := decodeOTPSecret(c.Secret)
+	if err != nil {
+		return "", 0, err
+	}
+	unix := at.Unix()
+	counter := uint64(unix / int64(c.Period))
+	remaining := c.Period - int(unix%int64(c.Period))
+	return hotp(otpHash(c.Algorithm), key, counter, c.Digits), remaining, nil
+}
+
+// Verify checks code against time steps around specified time, so small clock drift is tolerated,
+// returns time step of matched code.
+func (c *OTPSecretItem) Verify(code string, at time.Time) (int64, bool) {
+	if err := c.Validate(); err != nil || len(code) != c.Digits {
+		return 0, false
+	}
+	key, err := decodeOTPSecret(c.Secret)
+	if err != nil {
+		return 0, false
+	}
+	current := at.Unix() / int64(c.Period)
+	for counter := current - otpVerifySkew; counter <= current+otpVerifySkew; counter++ {
+		expected := hotp(otpHash(c.Algorithm), key, uint64(counter), c.Digits)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// Validate checks seed and parameters of one-time password.
+func (c *OTPSecretItem) Validate() error {
+	if _, err := decodeOTPSecret(c.Secret); err != nil {
+		return err
+	}
+	if otpHash(c.Algorithm) == nil || c.Digits < minOTPDigits || c.Digits > maxOTPDigits || c.Period <= 0 {
+		return ErrInvalidOTPParams
+	}
+	return nil
+}
+
+// NewEncodedSecret encodes secret item.
+func (c *OTPSecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
+	jsonBytes, err := json.Marshal(c)
+	if err != nil {
+		return EncodedSecret{}, err
+	}
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, OTP))
+	if err != nil {
+		return EncodedSecret{}, err
+	}
+
+	encodedSecret := EncodedSecret{
+		ID:             id,
+		Name:           c.Name,
+		Owner:          ownerID,
+		Description:    c.Description,
+		Type:           OTP,
+		EncodedContent: encoded,
+		Timestamp:      time.Now().UTC().UnixMilli(),
+	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
+	return encodedSecret, nil
+}
+
+// DecodeOTPSecretItem decodes EncodedSecret item into OTPSecretItem.
+func DecodeOTPSecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*OTPSecretItem, error) {
+	var otpSecret OTPSecretItem
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode otp secret: %w", err)
+	}
+	err = json.Unmarshal(decodedBytes, &otpSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse otp secret: %w", err)
+	}
+	if otpSecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
+	return &otpSecret, nil
+}
+
+// NewOTPSecretItem OTPSecretItem constructor, empty algorithm and zero digits or period are replaced with defaults.
+func NewOTPSecretItem(name, description, issuer, account, secret, algorithm string, digits, period int) (*OTPSecretItem, error) {
+	if algorithm == "" {
+		algorithm = DefaultOTPAlgorithm
+	}
+	if digits == 0 {
+		digits = DefaultOTPDigits
+	}
+	if period == 0 {
+		period = DefaultOTPPeriod
+	}
+	item := &OTPSecretItem{
+		Name:        name,
+		Description: description,
+		SecretType:  OTP,
+		Issuer:      issuer,
+		Account:     account,
+		Secret:      normalizeOTPSecret(secret),
+		Algorithm:   strings.ToUpper(algorithm),
+		Digits:      digits,
+		Period:      period,
+	}
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// NewOTPSecretItemFromURI imports OTPSecretItem from otpauth URI of Key Uri Format,
+// issuer and account are taken from label when parameters are missing.
+func NewOTPSecretItemFromURI(name, description, uri string) (*OTPSecretItem, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != otpAuthScheme || u.Host != otpAuthTOTP {
+		return nil, ErrInvalidOTPAuthURI
+	}
+	query := u.Query()
+	label := strings.TrimPrefix(u.Path, "/")
+	issuer, account, found := strings.Cut(label, ":")
+	if !found {
+		issuer, account = "", label
+	}
+	if query.Has("issuer") {
+		issuer = query.Get("issuer")
+	}
+	digits, err := optionalInt(query.Get("digits"))
+	if err != nil {
+		return nil, ErrInvalidOTPAuthURI
+	}
+	period, err := optionalInt(query.Get("period"))
+	if err != nil {
+		return nil, ErrInvalidOTPAuthURI
+	}
+	return NewOTPSecretItem(name, description, strings.TrimSpace(issuer), strings.TrimSpace(account),
+		query.Get("secret"), query.Get("algorithm"), digits, period)
+}
+
+// newOTPSecretItemFromAnswers creates OTPSecretItem from otpauth URI or from manually entered parameters.
+func newOTPSecretItemFromAnswers(answers Answers) (SecretItem, error) {
+	if answers["URI"] != "" {
+		return secretItem(NewOTPSecretItemFromURI(answers["Name"], answers["Description"], answers["URI"]))
+	}
+	digits, err := optionalInt(answers["Digits"])
+	if err != nil {
+		return nil, ErrInvalidOTPParams
+	}
+	period, err := optionalInt(answers["Period"])
+	if err != nil {
+		return nil, ErrInvalidOTPParams
+	}
+	return secretItem(NewOTPSecretItem(answers["Name"], answers["Description"], answers["Issuer"], answers["Account"],
+		answers["Secret"], answers["Algorithm"], digits, period))
+}
+
+// NewOTPSecret generates random seed encoded in base32.
+func NewOTPSecret() (string, error) {
+	key := make([]byte, otpSecretSize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate OTP secret: %w", err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key), nil
+}
+
+// OTPAuthURI returns otpauth URI of Key Uri Format, which authenticator applications import.
+func (c *OTPSecretItem) OTPAuthURI() string {
+	query := url.Values{}
+	query.Set("secret", c.Secret)
+	if c.Issuer != "" {
+		query.Set("issuer", c.Issuer)
+	}
+	query.Set("algorithm", c.Algorithm)
+	query.Set("digits", strconv.Itoa(c.Digits))
+	query.Set("period", strconv.Itoa(c.Period))
+	label := c.Account
+	if c.Issuer != "" {
+		label = c.Issuer + ":" + c.Account
+	}
+	u := url.URL{Scheme: otpAuthScheme, Host: otpAuthTOTP, Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// normalizeOTPSecret removes spaces and padding, authenticator applications show seed in groups.
+func normalizeOTPSecret(secret string) string {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return strings.TrimRight(secret, "=")
+}
+
+func decodeOTPSecret(secret string) ([]byte, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalizeOTPSecret(secret))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidOTPSecret
+	}
+	return key, nil
+}
+
+func otpHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case OTPAlgorithmSHA1:
+		return sha1.New
+	case OTPAlgorithmSHA256:
+		return sha256.New
+	case OTPAlgorithmSHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// hotp computes HMAC-based one-time password of RFC 4226 with dynamic truncation.
+func hotp(h func() hash.Hash, key []byte, counter uint64, digits int) string {
+	mac := hmac.New(h, key)
+	mac.Write(binary.BigEndian.AppendUint64(nil, counter))
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
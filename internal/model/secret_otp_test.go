@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seeds of RFC 6238 appendix B test vectors.
+var (
+	rfc6238SHA1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	rfc6238SHA256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+	rfc6238SHA512 = base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+)
+
+func TestOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm string
+		secret    string
+		code      string
+	}{
+		{59, OTPAlgorithmSHA1, rfc6238SHA1, "94287082"},
+		{59, OTPAlgorithmSHA256, rfc6238SHA256, "46119246"},
+		{59, OTPAlgorithmSHA512, rfc6238SHA512, "90693936"},
+		{1111111109, OTPAlgorithmSHA1, rfc6238SHA1, "07081804"},
+		{1234567890, OTPAlgorithmSHA256, rfc6238SHA256, "91819424"},
+		{20000000000, OTPAlgorithmSHA512, rfc6238SHA512, "47863826"},
+	}
+	for _, tt := range tests {
+		item, err := NewOTPSecretItem("name", "", "", "", tt.secret, tt.algorithm, 8, 30)
+		require.NoError(t, err)
+		code, remaining, err := item.Code(time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "time %d, %s", tt.unix, tt.algorithm)
+		assert.Equal(t, 30-int(tt.unix%30), remaining)
+	}
+}
+
+func TestNewOTPSecretItemFromURI(t *testing.T) {
+	item, err := NewOTPSecretItemFromURI("github", "", "otpauth://totp/ACME%20Co:john@example.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, "ACME Co", item.Issuer)
+	assert.Equal(t, "john@example.com", item.Account)
+	assert.Equal(t, OTPAlgorithmSHA256, item.Algorithm)
+	assert.Equal(t, 8, item.Digits)
+	assert.Equal(t, 60, item.Period)
+
+	item, err = NewOTPSecretItemFromURI("github", "", "otpauth://totp/john?secret=hxdm vjec jjws rb3h wizr 4ifu gftm xboz")
+	require.NoError(t, err)
+	assert.Equal(t, "john", item.Account)
+	assert.Equal(t, DefaultOTPAlgorithm, item.Algorithm)
+	assert.Equal(t, DefaultOTPDigits, item.Digits)
+	assert.Equal(t, DefaultOTPPeriod, item.Period)
+
+	_, err = NewOTPSecretItemFromURI("github", "", "otpauth://hotp/john?secret=HXDMVJECJJWSRB3H&counter=1")
+	assert.ErrorIs(t, err, ErrInvalidOTPAuthURI)
+	_, err = NewOTPSecretItemFromURI("github", "", "otpauth://totp/john?secret=not-base32!")
+	assert.ErrorIs(t, err, ErrInvalidOTPSecret)
+	_, err = NewOTPSecretItemFromURI("github", "", "otpauth://totp/john?secret=HXDMVJECJJWSRB3H&algorithm=MD5")
+	assert.ErrorIs(t, err, ErrInvalidOTPParams)
+}
+
+func TestOTPVerify(t *testing.T) {
+	item, err := NewOTPSecretItem("name", "", "", "", rfc6238SHA1, OTPAlgorithmSHA1, 8, 30)
+	require.NoError(t, err)
+	at := time.Unix(1111111109, 0)
+
+	counter, ok := item.Verify("07081804", at)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1111111109/30), counter)
+
+	_, ok = item.Verify("07081804", at.Add(30*time.Second))
+	assert.True(t, ok, "code of previous time step is accepted")
+	_, ok = item.Verify("07081804", at.Add(90*time.Second))
+	assert.False(t, ok)
+	_, ok = item.Verify("7081804", at)
+	assert.False(t, ok)
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Kinds of question asked to create secret item, kind defines how answer is entered.
+const (
+	QuestionInput    string = "input"
+	QuestionPassword string = "password"
+	QuestionSelect   string = "select"
+)
+
+// UnknownSecretTypeCode wire code of secret type which is not registered.
+const UnknownSecretTypeCode int32 = -1
+
+// ErrUnknownSecretType appears when secret type is not registered.
+var ErrUnknownSecretType = errors.New("unknown secret type")
+
+// Answers answers to questions of secret type by question name.
+type Answers map[string]string
+
+// Question question asked to create secret item, view decides how to render it.
+type Question struct {
+	// Name key of answer in Answers.
+	Name    string
+	Message string
+	Kind    string
+	// Options of select question.
+	Options []string
+	Default string
+	// MinLength minimal length of answer, zero if answer can be empty.
+	MinLength int
+	// When question is asked only if it returns true for previous answers, always asked if nil.
+	When func(answers Answers) bool
+}
+
+// SecretType describes secret item type, every type registers itself with RegisterSecretType.
+type SecretType struct {
+	// Name type stored in EncodedSecret.Type, must never change.
+	Name string
+	// Code number of type in SECRET_TYPE enum of grpc protocol, must never change.
+	Code int32
+	// Title shown to user when type is chosen.
+	Title string
+	// Questions asked to create secret item, answers are passed to New.
+	Questions []Question
+	// New creates secret item from answers.
+	New func(answers Answers) (SecretItem, error)
+	// Decode decodes EncodedSecret of the type.
+	Decode func(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (SecretItem, error)
+}
+
+var (
+	secretTypesMu     sync.RWMutex
+	secretTypesByName = make(map[string]SecretType)
+	secretTypesByCode = make(map[int32]SecretType)
+)
+
+// RegisterSecretType registers secret type, panics if name or code is already registered.
+func RegisterSecretType(secretType SecretType) {
+	secretTypesMu.Lock()
+	defer secretTypesMu.Unlock()
+	if secretType.Name == "" || secretType.New == nil || secretType.Decode == nil {
+		panic("model: secret type must have name, constructor and decoder")
+	}
+	if _, dup := secretTypesByName[secretType.Name]; dup {
+		panic(fmt.Sprintf("model: secret type %s is registered twice", secretType.Name))
+	}
+	if _, dup := secretTypesByCode[secretType.Code]; dup || secretType.Code == UnknownSecretTypeCode {
+		panic(fmt.Sprintf("model: code %d of secret type %s is already used", secretType.Code, secretType.Name))
+	}
+	secretTypesByName[secretType.Name] = secretType
+	secretTypesByCode[secretType.Code] = secretType
+}
+
+// LookupSecretType returns registered secret type by name.
+func LookupSecretType(name string) (SecretType, bool) {
+	secretTypesMu.RLock()
+	defer secretTypesMu.RUnlock()
+	secretType, ok := secretTypesByName[name]
+	return secretType, ok
+}
+
+// LookupSecretTypeByCode returns registered secret type by wire code.
+func LookupSecretTypeByCode(code int32) (SecretType, bool) {
+	secretTypesMu.RLock()
+	defer secretTypesMu.RUnlock()
+	secretType, ok := secretTypesByCode[code]
+	return secretType, ok
+}
+
+// SecretTypes returns registered secret types ordered by code.
+func SecretTypes() []SecretType {
+	secretTypesMu.RLock()
+	defer secretTypesMu.RUnlock()
+	secretTypes := make([]SecretType, 0, len(secretTypesByName))
+	for _, secretType := range secretTypesByName {
+		secretTypes = append(secretTypes, secretType)
+	}
+	sort.Slice(secretTypes, func(i, j int) bool {
+		return secretTypes[i].Code < secretTypes[j].Code
+	})
+	return secretTypes
+}
+
+// NewSecretItem creates secret item of registered type from answers.
+func NewSecretItem(typeName string, answers Answers) (SecretItem, error) {
+	secretType, ok := LookupSecretType(typeName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSecretType, typeName)
+	}
+	return secretType.New(answers)
+}
+
+// secretDecoder adapts decoder of concrete secret item to SecretType.Decode.
+func secretDecoder[T SecretItem](decodeItem func(func(byteToDecode, associatedData []byte) ([]byte, error), EncodedSecret) (T, error)) func(func(byteToDecode, associatedData []byte) ([]byte, error), EncodedSecret) (SecretItem, error) {
+	return func(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (SecretItem, error) {
+		item, err := decodeItem(decode, encoded)
+		if err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+}
+
+// secretItem converts result of secret item constructor, so failed constructor returns nil interface.
+func secretItem[T SecretItem](item T, err error) (SecretItem, error) {
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// secretNameQuestion question about secret name, asked first for every type.
+var secretNameQuestion = Question{Name: "Name", Message: "Enter secret name", Kind: QuestionInput, MinLength: 3}
+
+// secretDescriptionQuestion question about secret description, asked last for every type.
+var secretDescriptionQuestion = Question{Name: "Description", Message: "Enter description", Kind: QuestionInput, MinLength: 1}
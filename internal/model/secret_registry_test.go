@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretTypesRegistered(t *testing.T) {
+	var names []string
+	for _, secretType := range SecretTypes() {
+		names = append(names, secretType.Name)
+		byCode, ok := LookupSecretTypeByCode(secretType.Code)
+		require.True(t, ok)
+		assert.Equal(t, secretType.Name, byCode.Name)
+	}
+	assert.Equal(t, []string{Credentials, Text, Binary, Card, OTP, SSHKey}, names)
+	assert.Panics(t, func() {
+		RegisterSecretType(SecretType{Name: "Other", Code: 0, New: func(Answers) (SecretItem, error) { return nil, nil }, Decode: secretDecoder(DecodeTextSecretItem)})
+	})
+}
+
+func TestNewSecretItem(t *testing.T) {
+	item, err := NewSecretItem(OTP, Answers{"Name": "otp", "Secret": "JBSWY3DPEHPK3PXP", "Algorithm": OTPAlgorithmSHA1, "Digits": "6", "Period": "30"})
+	require.NoError(t, err)
+	assert.Equal(t, OTP, item.GetType())
+
+	item, err = NewSecretItem(Card, Answers{"Name": "card", "CardNumber": "4111111111111112", "CardCVV": "123", "CardExpiry": "12/30"})
+	assert.ErrorIs(t, err, ErrInvalidCardNumber)
+	assert.Nil(t, item)
+
+	_, err = NewSecretItem("Passport", Answers{})
+	assert.ErrorIs(t, err, ErrUnknownSecretType)
+}
+
+func TestUnknownSecretTypeIsKeptOpaque(t *testing.T) {
+	identity := func(data, _ []byte) ([]byte, error) { return data, nil }
+	content := []byte(`{"name":"passport","secretType":"Passport","number":"123456"}`)
+	encoded := EncodedSecret{ID: NewSecretID(), Name: "passport", Owner: 1, Type: "Passport", EncodedContent: content}
+
+	decoded, err := encoded.Decode(identity)
+	require.NoError(t, err)
+	opaque, ok := decoded.(*OpaqueSecretItem)
+	require.True(t, ok)
+	assert.Equal(t, "passport", opaque.Name)
+	assert.NotContains(t, opaque.GetSecretPayload(), "123456")
+	assert.ErrorIs(t, opaque.SetCustomFields(nil), ErrOpaqueSecretReadOnly)
+
+	reEncoded, err := opaque.NewEncodedSecret(identity, encoded.Owner, encoded.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Passport", reEncoded.Type)
+	assert.Equal(t, content, reEncoded.EncodedContent)
+}
@@ -0,0 +1,212 @@
+package model
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var _ SecretItem = (*SSHKeySecretItem)(nil)
+
+func init() {
+	RegisterSecretType(SecretType{
+		Name:  SSHKey,
+		Code:  5,
+		Title: "ssh key",
+		Questions: []Question{
+			secretNameQuestion,
+			{Name: "PrivateKeyPath", Message: "Enter private key filepath", Kind: QuestionInput, MinLength: 1},
+			{Name: "PublicKeyPath", Message: "Enter public key filepath (leave empty to derive it from private key)", Kind: QuestionInput},
+			{Name: "Passphrase", Message: "Enter passphrase of private key (leave empty if it is not encrypted)", Kind: QuestionPassword},
+			{Name: "Comment", Message: "Enter comment (leave empty to use comment of public key)", Kind: QuestionInput},
+			secretDescriptionQuestion,
+		},
+		New:    newSSHKeySecretItemFromAnswers,
+		Decode: secretDecoder(DecodeSSHKeySecretItem),
+	})
+}
+
+var (
+	// ErrInvalidSSHPrivateKey appears when private key can not be parsed.
+	ErrInvalidSSHPrivateKey = errors.New("invalid SSH private key, expected PEM or OpenSSH format")
+	// ErrSSHKeyPassphraseRequired appears when private key is encrypted and passphrase is not set.
+	ErrSSHKeyPassphraseRequired = errors.New("SSH private key is encrypted, passphrase is required")
+	// ErrInvalidSSHKeyPassphrase appears when private key can not be decrypted with passphrase.
+	ErrInvalidSSHKeyPassphrase = errors.New("invalid passphrase of SSH private key")
+	// ErrInvalidSSHPublicKey appears when public key can not be parsed.
+	ErrInvalidSSHPublicKey = errors.New("invalid SSH public key, expected authorized_keys format")
+	// ErrSSHKeyMismatch appears when public key does not belong to private key.
+	ErrSSHKeyMismatch = errors.New("SSH public key does not match private key")
+)
+
+// SSHKeySecretItem SSH key pair implementation of SecretItem, keys are served by ssh-agent without being written to disk.
+type SSHKeySecretItem struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SecretType  string `json:"secretType"`
+	// PrivateKey private key in PEM or OpenSSH format.
+	PrivateKey string `json:"privateKey"`
+	// PublicKey public key in authorized_keys format.
+	PublicKey string `json:"publicKey"`
+	Comment   string `json:"comment"`
+	// Passphrase of encrypted private key, empty if private key is not encrypted.
+	Passphrase string `json:"passphrase"`
+	CustomFields
+}
+
+// GetType returns secret item type.
+func (c *SSHKeySecretItem) GetType() string {
+	return c.SecretType
+}
+
+// GetSecretPayload returns text implementation of secret item payload, private key is not shown.
+func (c *SSHKeySecretItem) GetSecretPayload() string {
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(c.PublicKey))
+	if err != nil {
+		return fmt.Sprintf("[COMMENT]: %s \n[ERROR]: %s \n", c.Comment, ErrInvalidSSHPublicKey) + c.customFieldsPayload()
+	}
+	return fmt.Sprintf("[COMMENT]: %s \n[FINGERPRINT]: %s \n[PUBLIC KEY]: %s \n",
+		c.Comment, ssh.FingerprintSHA256(publicKey), strings.TrimSpace(c.PublicKey)) + c.customFieldsPayload()
+}
+
+// RawPrivateKey returns decrypted private key, which is one of *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+func (c *SSHKeySecretItem) RawPrivateKey() (interface{}, error) {
+	key, err := ssh.ParseRawPrivateKey([]byte(c.PrivateKey))
+	if err == nil {
+		return key, nil
+	}
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return nil, ErrInvalidSSHPrivateKey
+	}
+	if c.Passphrase == "" {
+		return nil, ErrSSHKeyPassphraseRequired
+	}
+	key, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(c.PrivateKey), []byte(c.Passphrase))
+	if err != nil {
+		if errors.Is(x509.IncorrectPasswordError, err) {
+			return nil, ErrInvalidSSHKeyPassphrase
+		}
+		return nil, ErrInvalidSSHPrivateKey
+	}
+	return key, nil
+}
+
+// Validate checks that private key can be decrypted and public key belongs to it.
+func (c *SSHKeySecretItem) Validate() error {
+	rawKey, err := c.RawPrivateKey()
+	if err != nil {
+		return err
+	}
+	signer, err := ssh.NewSignerFromKey(rawKey)
+	if err != nil {
+		return ErrInvalidSSHPrivateKey
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(c.PublicKey))
+	if err != nil {
+		return ErrInvalidSSHPublicKey
+	}
+	if !bytes.Equal(publicKey.Marshal(), signer.PublicKey().Marshal()) {
+		return ErrSSHKeyMismatch
+	}
+	return nil
+}
+
+// NewEncodedSecret encodes secret item.
+func (c *SSHKeySecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
+	jsonBytes, err := json.Marshal(c)
+	if err != nil {
+		return EncodedSecret{}, err
+	}
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, SSHKey))
+	if err != nil {
+		return EncodedSecret{}, err
+	}
+
+	encodedSecret := EncodedSecret{
+		ID:             id,
+		Name:           c.Name,
+		Owner:          ownerID,
+		Description:    c.Description,
+		Type:           SSHKey,
+		EncodedContent: encoded,
+		Timestamp:      time.Now().UTC().UnixMilli(),
+	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
+	return encodedSecret, nil
+}
+
+// DecodeSSHKeySecretItem decodes EncodedSecret item into SSHKeySecretItem.
+func DecodeSSHKeySecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*SSHKeySecretItem, error) {
+	var sshKeySecret SSHKeySecretItem
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ssh key secret: %w", err)
+	}
+	err = json.Unmarshal(decodedBytes, &sshKeySecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key secret: %w", err)
+	}
+	if sshKeySecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
+	return &sshKeySecret, nil
+}
+
+// NewSSHKeySecretItem SSHKeySecretItem constructor, public key is derived from private key if empty,
+// comment of public key is used if comment is empty.
+func NewSSHKeySecretItem(name, description, privateKey, publicKey, comment, passphrase string) (*SSHKeySecretItem, error) {
+	item := &SSHKeySecretItem{
+		Name:        name,
+		Description: description,
+		SecretType:  SSHKey,
+		PrivateKey:  privateKey,
+		Comment:     comment,
+		Passphrase:  passphrase,
+	}
+	rawKey, err := item.RawPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(publicKey) == "" {
+		signer, err := ssh.NewSignerFromKey(rawKey)
+		if err != nil {
+			return nil, ErrInvalidSSHPrivateKey
+		}
+		publicKey = string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	} else if comment == "" {
+		_, publicKeyComment, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+		if err != nil {
+			return nil, ErrInvalidSSHPublicKey
+		}
+		item.Comment = publicKeyComment
+	}
+	item.PublicKey = strings.TrimSpace(publicKey)
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// newSSHKeySecretItemFromAnswers creates SSHKeySecretItem from key files.
+func newSSHKeySecretItemFromAnswers(answers Answers) (SecretItem, error) {
+	privateKey, err := os.ReadFile(answers["PrivateKeyPath"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	var publicKey []byte
+	if answers["PublicKeyPath"] != "" {
+		publicKey, err = os.ReadFile(answers["PublicKeyPath"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+	}
+	return secretItem(NewSSHKeySecretItem(answers["Name"], answers["Description"], string(privateKey), string(publicKey), answers["Comment"], answers["Passphrase"]))
+}
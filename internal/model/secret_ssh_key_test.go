@@ -0,0 +1,71 @@
+package model
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSSHKey(t *testing.T) (string, string) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return string(privatePEM), string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+func TestNewSSHKeySecretItem(t *testing.T) {
+	privateKey, publicKey := newTestSSHKey(t)
+
+	item, err := NewSSHKeySecretItem("deploy", "", privateKey, "", "deploy@ci", "")
+	require.NoError(t, err)
+	assert.Equal(t, SSHKey, item.GetType())
+	assert.Equal(t, publicKey[:len(publicKey)-1], item.PublicKey)
+	assert.Equal(t, "deploy@ci", item.Comment)
+
+	item, err = NewSSHKeySecretItem("deploy", "", privateKey, publicKey[:len(publicKey)-1]+" user@host\n", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "user@host", item.Comment)
+
+	_, anotherPublicKey := newTestSSHKey(t)
+	_, err = NewSSHKeySecretItem("deploy", "", privateKey, anotherPublicKey, "", "")
+	assert.ErrorIs(t, err, ErrSSHKeyMismatch)
+	_, err = NewSSHKeySecretItem("deploy", "", privateKey, "not a key", "", "")
+	assert.ErrorIs(t, err, ErrInvalidSSHPublicKey)
+	_, err = NewSSHKeySecretItem("deploy", "", "not a key", "", "", "")
+	assert.ErrorIs(t, err, ErrInvalidSSHPrivateKey)
+}
+
+func TestNewSSHKeySecretItemWithPassphrase(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	// legacy encrypted PEM, still produced by ssh-keygen -m PEM
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", der, []byte("passphrase"), x509.PEMCipherAES256)
+	require.NoError(t, err)
+	encryptedPEM := string(pem.EncodeToMemory(block))
+
+	_, err = NewSSHKeySecretItem("deploy", "", encryptedPEM, "", "", "")
+	assert.ErrorIs(t, err, ErrSSHKeyPassphraseRequired)
+	// legacy PEM encryption detects wrong passphrase only by padding, so error is not always ErrInvalidSSHKeyPassphrase
+	_, err = NewSSHKeySecretItem("deploy", "", encryptedPEM, "", "", "wrong")
+	assert.Error(t, err)
+
+	item, err := NewSSHKeySecretItem("deploy", "", encryptedPEM, "", "", "passphrase")
+	require.NoError(t, err)
+	rawKey, err := item.RawPrivateKey()
+	require.NoError(t, err)
+	assert.True(t, privateKey.Equal(rawKey))
+}
@@ -1,59 +1,72 @@
 package model
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 var _ SecretItem = (*TextSecretItem)(nil)
 
+func init() {
+	RegisterSecretType(SecretType{
+		Name:  Text,
+		Code:  1,
+		Title: "text",
+		Questions: []Question{
+			secretNameQuestion,
+			{Name: "Text", Message: "Enter your textual data", Kind: QuestionInput, MinLength: 1},
+			secretDescriptionQuestion,
+		},
+		New: func(answers Answers) (SecretItem, error) {
+			return NewTextSecretItem(answers["Name"], answers["Description"], answers["Text"]), nil
+		},
+		Decode: secretDecoder(DecodeTextSecretItem),
+	})
+}
+
 // TextSecretItem binary implementation of SecretItem.
 type TextSecretItem struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	SecretType  string `json:"secretType"`
 	Text        string `json:"text"`
+	CustomFields
 }
 
 // GetSecretPayload returns text implementation of secret item payload.
 func (c *TextSecretItem) GetSecretPayload() string {
-	return fmt.Sprintf("[TEXT]: %s \n", c.Text)
+	return fmt.Sprintf("[TEXT]: %s \n", c.Text) + c.customFieldsPayload()
 }
 
 // NewEncodedSecret encodes secret item.
-func (c *TextSecretItem) NewEncodedSecret(encodeFunction func(byteToDecode []byte) ([]byte, error), ownerID int64) (EncodedSecret, error) {
+func (c *TextSecretItem) NewEncodedSecret(encodeFunction func(byteToEncode, associatedData []byte) ([]byte, error), ownerID int64, id string) (EncodedSecret, error) {
 	jsonBytes, err := json.Marshal(c)
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	encoded, err := encodeFunction(jsonBytes)
+	encoded, err := encodeFunction(jsonBytes, SecretAssociatedData(id, ownerID, Text))
 	if err != nil {
 		return EncodedSecret{}, err
 	}
-	hash := sha256.Sum256(jsonBytes)
 
 	encodedSecret := EncodedSecret{
-		ID:             uuid.New().String(),
+		ID:             id,
 		Name:           c.Name,
 		Owner:          ownerID,
 		Description:    c.Description,
 		Type:           Text,
 		EncodedContent: encoded,
-		Hash:           base64.StdEncoding.EncodeToString(hash[:]),
 		Timestamp:      time.Now().UTC().UnixMilli(),
 	}
+	encodedSecret.Hash = encodedSecret.SyncHash()
 	return encodedSecret, nil
 }
 
 // DecodeTextSecretItem decodes EncodedSecret item into TextSecretItem.
-func DecodeTextSecretItem(decode func(byteToEncode []byte) ([]byte, error), encoded EncodedSecret) (*TextSecretItem, error) {
+func DecodeTextSecretItem(decode func(byteToDecode, associatedData []byte) ([]byte, error), encoded EncodedSecret) (*TextSecretItem, error) {
 	var textSecret TextSecretItem
-	decodedBytes, err := decode(encoded.EncodedContent)
+	decodedBytes, err := decode(encoded.EncodedContent, encoded.AssociatedData())
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode text secret: %w", err)
 	}
@@ -61,6 +74,9 @@ func DecodeTextSecretItem(decode func(byteToEncode []byte) ([]byte, error), enco
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse text secret: %w", err)
 	}
+	if textSecret.SecretType != encoded.Type {
+		return nil, ErrSecretTypeMismatch
+	}
 	return &textSecret, nil
 }
 
@@ -1,18 +1,200 @@
 package model
 
+import (
+	"bytes"
+
+	errs "github.com/apolsh/yapr-gophkeeper/internal/model/app_errors"
+)
+
+const (
+	// KDFLegacySHA256 single SHA-256 hash of the password, used by accounts registered without KDF parameters.
+	KDFLegacySHA256 string = ""
+	// KDFArgon2id memory-hard Argon2id key derivation function.
+	KDFArgon2id string = "argon2id"
+)
+
+const (
+	// CipherSuiteAESGCM AES-256 with Galois/Counter Mode, used by accounts registered without cipher suite.
+	CipherSuiteAESGCM string = "aes-256-gcm"
+	// CipherSuiteXChaCha20Poly1305 XChaCha20-Poly1305 with extended nonce.
+	CipherSuiteXChaCha20Poly1305 string = "xchacha20-poly1305"
+)
+
+// CipherSuites supported cipher suites, the first one is default.
+var CipherSuites = []string{CipherSuiteAESGCM, CipherSuiteXChaCha20Poly1305}
+
+// ValidateCipherSuite checks that cipher suite is supported, empty suite means default one.
+func ValidateCipherSuite(suite string) error {
+	if suite == "" {
+		return nil
+	}
+	for _, known := range CipherSuites {
+		if suite == known {
+			return nil
+		}
+	}
+	return errs.ErrorUnknownCipherSuite
+}
+
+const (
+	minKDFSaltSize = 16
+	minKDFMemory   = 19 * 1024
+)
+
+// Default Argon2id parameters for new users.
+const (
+	DefaultArgon2Time    uint32 = 3
+	DefaultArgon2Memory  uint32 = 64 * 1024
+	DefaultArgon2Threads uint8  = 4
+	DefaultKDFSaltSize          = 16
+)
+
+// KDFParams parameters of key derivation function which turns user password into encryption key.
+type KDFParams struct {
+	// Algorithm of key derivation.
+	Algorithm string
+	// Salt random per user salt.
+	Salt []byte
+	// Time number of passes over the memory.
+	Time uint32
+	// Memory size of the memory in KiB.
+	Memory uint32
+	// Threads number of threads.
+	Threads uint8
+}
+
+// Validate checks that parameters are known and strong enough.
+func (p KDFParams) Validate() error {
+	switch p.Algorithm {
+	case KDFLegacySHA256:
+		return nil
+	case KDFArgon2id:
+		if len(p.Salt) < minKDFSaltSize || p.Time < 1 || p.Memory < minKDFMemory || p.Threads < 1 {
+			return errs.ErrorInvalidKDFParams
+		}
+		return nil
+	default:
+		return errs.ErrorInvalidKDFParams
+	}
+}
+
+// NewArgon2idParams returns default Argon2id parameters with specified salt.
+func NewArgon2idParams(salt []byte) KDFParams {
+	return KDFParams{
+		Algorithm: KDFArgon2id,
+		Salt:      salt,
+		Time:      DefaultArgon2Time,
+		Memory:    DefaultArgon2Memory,
+		Threads:   DefaultArgon2Threads,
+	}
+}
+
+// EqualTo returns KDF parameters equality.
+func (p KDFParams) EqualTo(a KDFParams) bool {
+	return p.Algorithm == a.Algorithm && bytes.Equal(p.Salt, a.Salt) && p.Time == a.Time && p.Memory == a.Memory && p.Threads == a.Threads
+}
+
 // User gophkeeper user.
 type User struct {
 	// ID user identifier.
 	ID int64
 	// Login user login.
 	Login string
-	// HashedPassword user hashed password.
+	// HashedPassword hash of auth key derived from password on client, hash of password itself for legacy users.
 	HashedPassword string
+	// ZeroKnowledgeAuth reports whether HashedPassword is hash of auth key, stored only on server.
+	ZeroKnowledgeAuth bool
+	// KDF parameters used to derive encryption key from password.
+	KDF KDFParams
+	// WrappedVaultKey random vault key encrypted with key derived from password.
+	WrappedVaultKey []byte
+	// CipherSuite used to encrypt new secrets of user.
+	CipherSuite string
+	// KeyVersion version of current vault key, incremented by every key rotation.
+	KeyVersion int64
+	// PendingWrappedVaultKey new vault key wrapped with password, set while key rotation is in progress.
+	PendingWrappedVaultKey []byte
+	// RecoveryKeyHash hash of key derived from recovery key, proves possession of recovery key, stored only on server.
+	RecoveryKeyHash string
+	// RecoveryWrappedVaultKey vault key encrypted with key derived from recovery key.
+	RecoveryWrappedVaultKey []byte
+	// TwoFactorEnabled reports whether login requires one-time password after auth key.
+	TwoFactorEnabled bool
 	// Timestamp of last modification of user.
 	Timestamp int64
 }
 
 // EqualTo returns users equality.
 func (u *User) EqualTo(a User) bool {
-	return u.ID == a.ID && u.Login == a.Login && u.HashedPassword == a.HashedPassword && u.Timestamp == a.Timestamp
+	return u.ID == a.ID && u.Login == a.Login && u.HashedPassword == a.HashedPassword && u.KDF.EqualTo(a.KDF) &&
+		bytes.Equal(u.WrappedVaultKey, a.WrappedVaultKey) && u.CipherSuite == a.CipherSuite && u.KeyVersion == a.KeyVersion &&
+		bytes.Equal(u.PendingWrappedVaultKey, a.PendingWrappedVaultKey) && bytes.Equal(u.RecoveryWrappedVaultKey, a.RecoveryWrappedVaultKey) &&
+		u.Timestamp == a.Timestamp
+}
+
+// HasRecoveryKey reports whether vault key of user can be recovered with recovery key.
+func (u *User) HasRecoveryKey() bool {
+	return len(u.RecoveryWrappedVaultKey) > 0
+}
+
+// TwoFactorState second factor of user, stored only on server.
+type TwoFactorState struct {
+	// Secret TOTP seed encoded in base32, empty if second factor is disabled.
+	Secret string
+	// LastCounter time step of the last accepted code, codes of the same or earlier steps are rejected.
+	LastCounter int64
+	// FailedAttempts number of wrong codes entered in a row.
+	FailedAttempts int
+}
+
+// IsEnabled reports whether second factor is enabled.
+func (t TwoFactorState) IsEnabled() bool {
+	return t.Secret != ""
+}
+
+// AuthParams parameters client needs before login to derive auth key from password.
+type AuthParams struct {
+	// KDF parameters used to derive keys from password.
+	KDF KDFParams
+	// ZeroKnowledge reports whether server knows only auth key, otherwise legacy password is sent once to upgrade account.
+	ZeroKnowledge bool
+}
+
+// RecoveryKit second copy of vault key wrapped with recovery key, used to restore access to forgotten password account.
+type RecoveryKit struct {
+	// AuthKey key derived from recovery key, proves possession of recovery key without revealing it.
+	AuthKey string
+	// WrappedVaultKey vault key encrypted with key derived from recovery key.
+	WrappedVaultKey []byte
+}
+
+// IsEmpty reports whether recovery kit is not set.
+func (k RecoveryKit) IsEmpty() bool {
+	return k.AuthKey == "" && len(k.WrappedVaultKey) == 0
+}
+
+// PinUnlock local copy of vault key wrapped with key derived from short PIN, allows quick unlock after full login.
+type PinUnlock struct {
+	// Owner identifier of user.
+	Owner int64
+	// WrappedVaultKey vault key encrypted with key derived from PIN.
+	WrappedVaultKey []byte
+	// KDF parameters of PIN key derivation.
+	KDF KDFParams
+	// FailedAttempts number of wrong PINs entered in a row.
+	FailedAttempts int
+}
+
+// IsKeyRotationInProgress reports whether secrets of user are being re-encrypted with new vault key.
+func (u *User) IsKeyRotationInProgress() bool {
+	return len(u.PendingWrappedVaultKey) > 0
+}
+
+// WriteKeyVersion returns vault key version secrets must be encrypted with to be saved,
+// during key rotation only secrets encrypted with the new key are accepted.
+func (u *User) WriteKeyVersion() int64 {
+	if u.IsKeyRotationInProgress() {
+		return u.KeyVersion + 1
+	}
+	return u.KeyVersion
 }